@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/SamPCunningham/sleeper-system/internal/bundle"
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/joho/godotenv"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  bundle export -campaign <id> [-out file.json]")
+	fmt.Fprintln(os.Stderr, "  bundle import -gm-email <email> [-in file.json] [-name <name>] [-dry-run]")
+	os.Exit(2)
+}
+
+func main() {
+	// Load .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+
+	switch os.Args[1] {
+	case "export":
+		fs := flag.NewFlagSet("export", flag.ExitOnError)
+		campaignID := fs.Int("campaign", 0, "ID of the campaign to export")
+		out := fs.String("out", "", "file to write (defaults to stdout)")
+		fs.Parse(os.Args[2:])
+		if *campaignID == 0 {
+			usage()
+		}
+
+		db, err := database.NewDatabase(databaseURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		b, err := bundle.Export(db, *campaignID)
+		if err != nil {
+			log.Fatalf("Error exporting campaign: %v", err)
+		}
+
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Fatalf("Error creating %s: %v", *out, err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(b); err != nil {
+			log.Fatalf("Error writing bundle: %v", err)
+		}
+
+	case "import":
+		fs := flag.NewFlagSet("import", flag.ExitOnError)
+		in := fs.String("in", "", "bundle file to read (defaults to stdin)")
+		gmEmail := fs.String("gm-email", "", "email of the user who will GM the imported campaign")
+		name := fs.String("name", "", "override the campaign name")
+		dryRun := fs.Bool("dry-run", false, "report conflicts without creating anything")
+		fs.Parse(os.Args[2:])
+		if *gmEmail == "" {
+			usage()
+		}
+
+		var r io.Reader = os.Stdin
+		if *in != "" {
+			f, err := os.Open(*in)
+			if err != nil {
+				log.Fatalf("Error opening %s: %v", *in, err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		var b models.CampaignBundle
+		if err := json.NewDecoder(r).Decode(&b); err != nil {
+			log.Fatalf("Error reading bundle: %v", err)
+		}
+
+		db, err := database.NewDatabase(databaseURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		var gmUserID int
+		err = db.Get(&gmUserID, "SELECT id FROM users WHERE LOWER(email) = LOWER($1)", *gmEmail)
+		if err != nil {
+			log.Fatalf("No user with email %s", *gmEmail)
+		}
+
+		report, err := bundle.Import(db, &b, bundle.ImportOptions{
+			GMUserID: gmUserID,
+			Name:     *name,
+			DryRun:   *dryRun,
+		})
+		if err != nil {
+			log.Fatalf("Error importing campaign: %v", err)
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+
+		if len(report.Conflicts) > 0 {
+			os.Exit(1)
+		}
+
+	default:
+		usage()
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/SamPCunningham/sleeper-system/internal/database"
 	"github.com/SamPCunningham/sleeper-system/internal/handlers"
 	customMiddleware "github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/storage"
 	"github.com/SamPCunningham/sleeper-system/internal/websocket"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -118,14 +120,44 @@ func main() {
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
 
-	wsHandler := websocket.NewHandler(wsHub)
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "uploads"
+	}
+	uploadPublicURL := os.Getenv("UPLOAD_PUBLIC_URL")
+	if uploadPublicURL == "" {
+		uploadPublicURL = "/uploads"
+	}
+	maxUploadBytes := int64(10 << 20) // 10 MB
+	if v := os.Getenv("MAX_UPLOAD_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			maxUploadBytes = n
+		} else {
+			log.Printf("Invalid MAX_UPLOAD_BYTES %q, using default", v)
+		}
+	}
 
-	authHandler := handlers.NewAuthHandler(db)
-	adminHandler := handlers.NewAdminHandler(db)
+	blobStore, err := storage.NewLocalStore(uploadDir, uploadPublicURL, []byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	authCache := customMiddleware.NewAuthCache(db)
+	wsHandler := websocket.NewHandler(wsHub, db, authCache)
+	authHandler := handlers.NewAuthHandler(db, authCache)
+	adminHandler := handlers.NewAdminHandler(db, authCache)
 	campaignHandler := handlers.NewCampaignHandler(db, wsHub)
-	characterHandler := handlers.NewCharacterHandler(db)
+	characterHandler := handlers.NewCharacterHandler(db, wsHub)
+	traitHandler := handlers.NewTraitHandler(db, wsHub)
+	conditionHandler := handlers.NewConditionHandler(db, wsHub)
+	itemHandler := handlers.NewItemHandler(db, wsHub)
+	advancementHandler := handlers.NewAdvancementHandler(db, wsHub)
 	diceHandler := handlers.NewDiceHandler(db, wsHub)
 	challengeHandler := handlers.NewChallengeHandler(db, wsHub)
+	sessionHandler := handlers.NewSessionHandler(db, wsHub)
+	imageHandler := handlers.NewImageHandler(db, wsHub, blobStore, maxUploadBytes)
+	journalHandler := handlers.NewJournalHandler(db, wsHub)
+	sceneHandler := handlers.NewSceneHandler(db, wsHub, blobStore)
 
 	r := chi.NewRouter()
 
@@ -142,15 +174,22 @@ func main() {
 
 	r.Post("/api/auth/register", authHandler.Register)
 	r.Post("/api/auth/login", authHandler.Login)
+	r.Post("/api/auth/refresh", authHandler.Refresh)
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, "OK")
 	})
 
 	r.Get("/ws/campaigns/{campaignId}", wsHandler.ServeWS)
+	r.Handle("/uploads/*", blobStore.Handler("/uploads/"))
 
 	r.Group(func(r chi.Router) {
-		r.Use(customMiddleware.AuthMiddleware)
+		r.Use(customMiddleware.AuthMiddleware(authCache))
+
+		r.Post("/api/auth/logout", authHandler.Logout)
+		r.Post("/api/auth/logout-all", authHandler.LogoutAll)
+		r.Get("/api/auth/sessions", authHandler.ListSessions)
+		r.Delete("/api/auth/sessions/{id}", authHandler.RevokeSession)
 
 		r.Route("/api/admin", func(r chi.Router) {
 			r.Use(customMiddleware.RequireAdmin)
@@ -163,10 +202,24 @@ func main() {
 		})
 
 		r.Post("/api/campaigns", campaignHandler.Create)
+		r.Post("/api/campaigns/import", campaignHandler.Import)
 		r.Get("/api/campaigns", campaignHandler.List)
 		r.Get("/api/campaigns/{id}", campaignHandler.Get)
 		r.Post("/api/campaigns/{id}/increment-day", campaignHandler.IncrementDay)
+		r.Get("/api/campaigns/{id}/clock", campaignHandler.GetClock)
+		r.Post("/api/campaigns/{id}/clock/advance", campaignHandler.AdvanceClock)
+		r.Post("/api/campaigns/{id}/clock/rewind", campaignHandler.RewindClock)
+		r.Get("/api/campaigns/{id}/clock/log", campaignHandler.ListClockLog)
+		r.Put("/api/campaigns/{id}/calendar", campaignHandler.UpdateCalendar)
 		r.Get("/api/campaigns/{id}/users", campaignHandler.ListUsers)
+		r.Get("/api/campaigns/{id}/export", campaignHandler.Export)
+		r.Put("/api/campaigns/{id}/settings", campaignHandler.UpdateSettings)
+		r.Post("/api/campaigns/{id}/clone", campaignHandler.Clone)
+		r.Post("/api/campaigns/{id}/templates", campaignHandler.SaveTemplate)
+
+		r.Get("/api/campaign-templates", campaignHandler.ListTemplates)
+		r.Post("/api/campaign-templates/{id}/instantiate", campaignHandler.InstantiateTemplate)
+		r.Delete("/api/campaign-templates/{id}", campaignHandler.DeleteTemplate)
 
 		r.Get("/api/campaigns/{id}/members", campaignHandler.ListMembers)
 		r.Post("/api/campaigns/{id}/members", campaignHandler.AddMember)
@@ -176,6 +229,41 @@ func main() {
 		r.Get("/api/campaigns/{campaignId}/characters", characterHandler.ListByCampaign)
 		r.Get("/api/characters/{id}", characterHandler.Get)
 		r.Put("/api/characters/{id}", characterHandler.Update)
+		r.Put("/api/characters/{id}/mechanics", characterHandler.UpdateMechanics)
+		r.Delete("/api/characters/{id}", characterHandler.Delete)
+		r.Post("/api/characters/{id}/retire", characterHandler.Retire)
+		r.Post("/api/characters/{id}/reactivate", characterHandler.Reactivate)
+		r.Post("/api/characters/{id}/reassign", characterHandler.Reassign)
+		r.Get("/api/characters/{id}/versions", characterHandler.ListVersions)
+		r.Get("/api/characters/{id}/versions/diff", characterHandler.DiffVersions)
+		r.Get("/api/characters/{id}/versions/{version}", characterHandler.GetVersion)
+		r.Get("/api/campaigns/{campaignId}/npcs", characterHandler.ListNPCs)
+		r.Post("/api/campaigns/{campaignId}/npcs", characterHandler.CreateNPCs)
+		r.Put("/api/characters/{id}/npc", characterHandler.UpdateNPC)
+		r.Get("/api/characters/{id}/sheet", characterHandler.ExportSheet)
+		r.Post("/api/campaigns/{campaignId}/characters/import", characterHandler.ImportSheet)
+		r.Post("/api/campaigns/{campaignId}/characters/import-party", characterHandler.ImportParty)
+		r.Get("/api/character-sheets/schema", characterHandler.SheetSchema)
+
+		r.Get("/api/characters/{characterId}/traits", traitHandler.ListByCharacter)
+		r.Post("/api/characters/{characterId}/traits", traitHandler.Create)
+		r.Put("/api/traits/{id}", traitHandler.Update)
+		r.Delete("/api/traits/{id}", traitHandler.Delete)
+
+		r.Get("/api/characters/{characterId}/conditions", conditionHandler.ListByCharacter)
+		r.Post("/api/characters/{characterId}/conditions", conditionHandler.Create)
+		r.Delete("/api/conditions/{id}", conditionHandler.Remove)
+
+		r.Get("/api/characters/{characterId}/items", itemHandler.ListByCharacter)
+		r.Post("/api/characters/{characterId}/items", itemHandler.Grant)
+		r.Delete("/api/items/{id}", itemHandler.Remove)
+		r.Post("/api/items/{id}/transfer", itemHandler.Transfer)
+
+		r.Get("/api/characters/{characterId}/advancement", advancementHandler.GetProgress)
+		r.Post("/api/characters/{characterId}/experience", advancementHandler.AwardExperience)
+		r.Post("/api/characters/{characterId}/advancements", advancementHandler.RequestAdvancement)
+		r.Post("/api/advancements/{id}/approve", advancementHandler.Approve)
+		r.Post("/api/advancements/{id}/reject", advancementHandler.Reject)
 
 		r.Post("/api/characters/{characterId}/dice-pool", diceHandler.RollNewPool)
 		r.Get("/api/characters/{characterId}/dice-pool", diceHandler.GetCurrentPool)
@@ -188,6 +276,48 @@ func main() {
 		r.Post("/api/challenges", challengeHandler.Create)
 		r.Get("/api/campaigns/{campaignId}/challenges", challengeHandler.ListByCampaign)
 		r.Post("/api/challenges/{id}/complete", challengeHandler.Complete)
+		r.Post("/api/challenges/{id}/reopen", challengeHandler.Reopen)
+		r.Get("/api/challenges/{id}", challengeHandler.Get)
+		r.Put("/api/challenges/{id}", challengeHandler.Update)
+		r.Delete("/api/challenges/{id}", challengeHandler.Delete)
+		r.Put("/api/challenges/{id}/targeting", challengeHandler.SetTargeting)
+		r.Get("/api/challenge-templates", challengeHandler.ListTemplates)
+		r.Post("/api/challenge-templates", challengeHandler.CreateTemplate)
+		r.Get("/api/challenge-templates/{id}", challengeHandler.GetTemplate)
+		r.Put("/api/challenge-templates/{id}", challengeHandler.UpdateTemplate)
+		r.Delete("/api/challenge-templates/{id}", challengeHandler.DeleteTemplate)
+		r.Post("/api/challenge-templates/{id}/instantiate", challengeHandler.InstantiateTemplate)
+
+		r.Post("/api/campaigns/{campaignId}/sessions", sessionHandler.Start)
+		r.Get("/api/campaigns/{campaignId}/sessions", sessionHandler.ListByCampaign)
+		r.Get("/api/campaigns/{campaignId}/sessions/current", sessionHandler.GetCurrent)
+		r.Get("/api/sessions/{id}", sessionHandler.Get)
+		r.Put("/api/sessions/{id}", sessionHandler.Update)
+		r.Post("/api/sessions/{id}/end", sessionHandler.End)
+		r.Post("/api/sessions/{id}/attendance", sessionHandler.AddAttendee)
+		r.Delete("/api/sessions/{id}/attendance/{userId}", sessionHandler.RemoveAttendee)
+
+		r.Post("/api/campaigns/{campaignId}/images", imageHandler.Upload)
+		r.Get("/api/campaigns/{campaignId}/images", imageHandler.ListByCampaign)
+		r.Delete("/api/images/{id}", imageHandler.Delete)
+		r.Post("/api/images/{id}/show", imageHandler.Show)
+		r.Put("/api/images/{id}/scene", imageHandler.SetScene)
+
+		r.Post("/api/campaigns/{campaignId}/scenes", sceneHandler.Create)
+		r.Get("/api/campaigns/{campaignId}/scenes", sceneHandler.ListByCampaign)
+		r.Put("/api/campaigns/{campaignId}/scenes/order", sceneHandler.Reorder)
+		r.Get("/api/scenes/{id}/summary", sceneHandler.Summary)
+		r.Put("/api/scenes/{id}", sceneHandler.Update)
+		r.Delete("/api/scenes/{id}", sceneHandler.Delete)
+		r.Post("/api/scenes/{id}/activate", sceneHandler.Activate)
+		r.Post("/api/scenes/{id}/deactivate", sceneHandler.Deactivate)
+
+		r.Post("/api/campaigns/{campaignId}/journal", journalHandler.Create)
+		r.Get("/api/campaigns/{campaignId}/journal", journalHandler.ListByCampaign)
+		r.Get("/api/journal/{id}", journalHandler.Get)
+		r.Put("/api/journal/{id}", journalHandler.Update)
+		r.Delete("/api/journal/{id}", journalHandler.Delete)
+		r.Get("/api/journal/{id}/revisions", journalHandler.ListRevisions)
 	})
 
 	port := os.Getenv("PORT")
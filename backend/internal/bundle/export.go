@@ -0,0 +1,330 @@
+// Package bundle converts whole campaigns to and from portable JSON bundles
+// so they can be backed up or moved between server instances.
+package bundle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+)
+
+// Export builds a bundle containing everything that belongs to a campaign
+func Export(db *database.Database, campaignID int) (*models.CampaignBundle, error) {
+	b := &models.CampaignBundle{
+		Format:     models.BundleFormat,
+		Version:    models.BundleVersion,
+		ExportedAt: time.Now().UTC(),
+	}
+
+	campaignQuery := `
+		SELECT c.name, c.current_day, c.time_phase, c.settings, c.created_at, u.username as gm_username, u.email as gm_email
+		FROM campaigns c
+		JOIN users u ON c.gm_user_id = u.id
+		WHERE c.id = $1
+	`
+	if err := db.Get(&b.Campaign, campaignQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching campaign: %w", err)
+	}
+
+	memberQuery := `
+		SELECT u.username, u.email, cm.joined_at
+		FROM campaign_members cm
+		JOIN users u ON cm.user_id = u.id
+		WHERE cm.campaign_id = $1
+		ORDER BY cm.joined_at ASC
+	`
+	if err := db.Select(&b.Members, memberQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching members: %w", err)
+	}
+
+	characterQuery := `
+		SELECT
+			ch.id, u.username as owner_username, u.email as owner_email, ch.name, ch.description, ch.notes,
+			ch.skill_name, ch.skill_modifier, ch.weakness_name, ch.weakness_modifier,
+			ch.max_daily_dice, ch.kind, ch.is_revealed, ch.stat_block,
+			ch.status, ch.retired_at, ch.retired_reason, ch.created_at
+		FROM characters ch
+		LEFT JOIN users u ON ch.user_id = u.id
+		WHERE ch.campaign_id = $1
+		ORDER BY ch.id ASC
+	`
+	if err := db.Select(&b.Characters, characterQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching characters: %w", err)
+	}
+
+	var traits []models.BundleTrait
+	traitQuery := `
+		SELECT ct.id, ct.character_id, ct.name, ct.kind, ct.modifier, ct.description, ct.uses_per_day
+		FROM character_traits ct
+		JOIN characters ch ON ct.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY ct.id ASC
+	`
+	if err := db.Select(&traits, traitQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching traits: %w", err)
+	}
+
+	characterIndex := make(map[int]int, len(b.Characters))
+	for i := range b.Characters {
+		b.Characters[i].Traits = []models.BundleTrait{}
+		characterIndex[b.Characters[i].ID] = i
+	}
+	for _, t := range traits {
+		if i, ok := characterIndex[t.CharacterID]; ok {
+			b.Characters[i].Traits = append(b.Characters[i].Traits, t)
+		}
+	}
+
+	var conditions []models.BundleCondition
+	conditionQuery := `
+		SELECT
+			cc.id, cc.character_id, cc.name, cc.description, cc.d6_modifier, cc.dice_modifier,
+			cc.started_on_day, cc.expires_after_day, cc.rolls_remaining, cc.created_at,
+			cc.ended_at, cc.ended_reason
+		FROM character_conditions cc
+		JOIN characters ch ON cc.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY cc.id ASC
+	`
+	if err := db.Select(&conditions, conditionQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching conditions: %w", err)
+	}
+	for _, c := range conditions {
+		if i, ok := characterIndex[c.CharacterID]; ok {
+			b.Characters[i].Conditions = append(b.Characters[i].Conditions, c)
+		}
+	}
+
+	var items []models.BundleItem
+	itemQuery := `
+		SELECT ci.id, ci.character_id, ci.name, ci.description, ci.quantity, ci.d6_modifier, ci.consumable
+		FROM character_items ci
+		JOIN characters ch ON ci.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY ci.id ASC
+	`
+	if err := db.Select(&items, itemQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching items: %w", err)
+	}
+	for _, item := range items {
+		if i, ok := characterIndex[item.CharacterID]; ok {
+			b.Characters[i].Items = append(b.Characters[i].Items, item)
+		}
+	}
+
+	var experience []models.BundleExperience
+	experienceQuery := `
+		SELECT e.character_id, e.amount, e.reason, e.is_milestone, e.campaign_day, e.created_at
+		FROM experience_entries e
+		JOIN characters ch ON e.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY e.id ASC
+	`
+	if err := db.Select(&experience, experienceQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching experience: %w", err)
+	}
+	for _, e := range experience {
+		if i, ok := characterIndex[e.CharacterID]; ok {
+			b.Characters[i].Experience = append(b.Characters[i].Experience, e)
+		}
+	}
+
+	var advancements []models.BundleAdvancement
+	advancementQuery := `
+		SELECT
+			a.character_id, a.kind, a.status, a.trait_id, a.trait_name, a.trait_kind, a.trait_modifier,
+			a.note, a.review_note, a.created_at, a.reviewed_at
+		FROM character_advancements a
+		JOIN characters ch ON a.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY a.id ASC
+	`
+	if err := db.Select(&advancements, advancementQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching advancements: %w", err)
+	}
+	for _, a := range advancements {
+		if i, ok := characterIndex[a.CharacterID]; ok {
+			b.Characters[i].Advancements = append(b.Characters[i].Advancements, a)
+		}
+	}
+
+	var versions []models.BundleCharacterVersion
+	versionQuery := `
+		SELECT cv.character_id, cv.version, cv.change, cv.snapshot, cv.created_at
+		FROM character_versions cv
+		JOIN characters ch ON cv.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY cv.character_id ASC, cv.version ASC
+	`
+	if err := db.Select(&versions, versionQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching character versions: %w", err)
+	}
+	for _, v := range versions {
+		if i, ok := characterIndex[v.CharacterID]; ok {
+			b.Characters[i].Versions = append(b.Characters[i].Versions, v)
+		}
+	}
+
+	poolQuery := `
+		SELECT dp.id, dp.character_id, dp.rolled_at
+		FROM dice_pools dp
+		JOIN characters ch ON dp.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY dp.id ASC
+	`
+	if err := db.Select(&b.DicePools, poolQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching dice pools: %w", err)
+	}
+
+	var dice []models.BundlePoolDie
+	diceQuery := `
+		SELECT pd.id, pd.pool_id, pd.die_result, pd.is_used, pd.position
+		FROM pool_dice pd
+		JOIN dice_pools dp ON pd.pool_id = dp.id
+		JOIN characters ch ON dp.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY pd.pool_id ASC, pd.position ASC
+	`
+	if err := db.Select(&dice, diceQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching pool dice: %w", err)
+	}
+
+	poolIndex := make(map[int]int, len(b.DicePools))
+	for i := range b.DicePools {
+		b.DicePools[i].Dice = []models.BundlePoolDie{}
+		poolIndex[b.DicePools[i].ID] = i
+	}
+	for _, die := range dice {
+		if i, ok := poolIndex[die.PoolID]; ok {
+			b.DicePools[i].Dice = append(b.DicePools[i].Dice, die)
+		}
+	}
+
+	challengeQuery := `
+		SELECT id, description, difficulty_modifier, is_group_challenge, is_active, status,
+		       starts_on_day, ends_after_day, progress_clock,
+		       max_attempts_per_character, is_hidden, success_text, neutral_text, failure_text,
+		       consequence_tables, created_at
+		FROM challenges
+		WHERE campaign_id = $1
+		ORDER BY id ASC
+	`
+	if err := db.Select(&b.Challenges, challengeQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching challenges: %w", err)
+	}
+
+	var targets []struct {
+		ChallengeID int `db:"challenge_id"`
+		CharacterID int `db:"character_id"`
+	}
+	targetQuery := `
+		SELECT tgt.challenge_id, tgt.character_id
+		FROM challenge_targets tgt
+		JOIN challenges ch ON tgt.challenge_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY tgt.challenge_id ASC, tgt.character_id ASC
+	`
+	if err := db.Select(&targets, targetQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching challenge targets: %w", err)
+	}
+	challengeIndex := make(map[int]int, len(b.Challenges))
+	for i, ch := range b.Challenges {
+		challengeIndex[ch.ID] = i
+	}
+	for _, t := range targets {
+		if i, ok := challengeIndex[t.ChallengeID]; ok {
+			b.Challenges[i].TargetCharacterIDs = append(b.Challenges[i].TargetCharacterIDs, t.CharacterID)
+		}
+	}
+
+	rollQuery := `
+		SELECT
+			rh.character_id, rh.pool_dice_id, rh.d20_roll, rh.action_type, rh.success,
+			rh.outcome, rh.notes, rh.challenge_id, rh.skill_applied, rh.other_modifiers,
+			rh.modified_d6, rh.campaign_day, rh.character_version, rh.consequence, rh.created_at, rh.id
+		FROM roll_history rh
+		JOIN characters ch ON rh.character_id = ch.id
+		WHERE ch.campaign_id = $1
+		ORDER BY rh.created_at ASC, rh.id ASC
+	`
+	if err := db.Select(&b.Rolls, rollQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching roll history: %w", err)
+	}
+
+	var rollTraits []models.BundleRollTrait
+	rollTraitQuery := `
+		SELECT rt.roll_id, rt.trait_id, rt.name, rt.kind, rt.modifier
+		FROM roll_history_traits rt
+		JOIN roll_history rh ON rt.roll_id = rh.id
+		JOIN characters ch ON rh.character_id = ch.id
+		WHERE ch.campaign_id = $1
+	`
+	if err := db.Select(&rollTraits, rollTraitQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching roll traits: %w", err)
+	}
+
+	rollIndex := make(map[int]int, len(b.Rolls))
+	for i := range b.Rolls {
+		rollIndex[b.Rolls[i].ID] = i
+	}
+	for _, t := range rollTraits {
+		if i, ok := rollIndex[t.RollID]; ok {
+			b.Rolls[i].Traits = append(b.Rolls[i].Traits, t)
+		}
+	}
+
+	var rollConditions []models.BundleRollCondition
+	rollConditionQuery := `
+		SELECT rc.roll_id, rc.condition_id, rc.name, rc.d6_modifier
+		FROM roll_history_conditions rc
+		JOIN roll_history rh ON rc.roll_id = rh.id
+		JOIN characters ch ON rh.character_id = ch.id
+		WHERE ch.campaign_id = $1
+	`
+	if err := db.Select(&rollConditions, rollConditionQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching roll conditions: %w", err)
+	}
+	for _, c := range rollConditions {
+		if i, ok := rollIndex[c.RollID]; ok {
+			b.Rolls[i].Conditions = append(b.Rolls[i].Conditions, c)
+		}
+	}
+
+	var rollItems []models.BundleRollItem
+	rollItemQuery := `
+		SELECT ri.roll_id, ri.item_id, ri.name, ri.d6_modifier, ri.consumed
+		FROM roll_history_items ri
+		JOIN roll_history rh ON ri.roll_id = rh.id
+		JOIN characters ch ON rh.character_id = ch.id
+		WHERE ch.campaign_id = $1
+	`
+	if err := db.Select(&rollItems, rollItemQuery, campaignID); err != nil {
+		return nil, fmt.Errorf("error fetching roll items: %w", err)
+	}
+	for _, item := range rollItems {
+		if i, ok := rollIndex[item.RollID]; ok {
+			b.Rolls[i].Items = append(b.Rolls[i].Items, item)
+		}
+	}
+
+	// Keep empty sections as [] rather than null in the JSON
+	if b.Members == nil {
+		b.Members = []models.BundleMember{}
+	}
+	if b.Characters == nil {
+		b.Characters = []models.BundleCharacter{}
+	}
+	if b.DicePools == nil {
+		b.DicePools = []models.BundleDicePool{}
+	}
+	if b.Challenges == nil {
+		b.Challenges = []models.BundleChallenge{}
+	}
+	if b.Rolls == nil {
+		b.Rolls = []models.BundleRoll{}
+	}
+
+	return b, nil
+}
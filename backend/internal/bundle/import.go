@@ -0,0 +1,610 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Issue kinds reported on import
+const (
+	IssueUnsupportedFormat = "unsupported_format"
+	IssueInvalidRecord     = "invalid_record"
+	IssueMissingUser       = "missing_user"
+	IssueDuplicateOwner    = "duplicate_owner"
+	IssueDanglingReference = "dangling_reference"
+	IssueNameInUse         = "name_in_use"
+)
+
+// ImportOptions controls how a bundle is recreated
+type ImportOptions struct {
+	// GMUserID becomes the GM of the imported campaign
+	GMUserID int
+
+	// Name overrides the campaign name stored in the bundle
+	Name string
+
+	// DryRun runs the whole import inside a transaction and rolls it back
+	DryRun bool
+}
+
+// Import recreates a bundled campaign on this instance.
+// Users are matched by email; a character whose owner can't be found is a
+// conflict, while members that can't be found are skipped with a warning.
+// Nothing is written when there are conflicts or when DryRun is set.
+// The returned error is only set for unexpected database failures.
+func Import(db *database.Database, b *models.CampaignBundle, opts ImportOptions) (*models.BundleImportReport, error) {
+	report := &models.BundleImportReport{
+		DryRun:    opts.DryRun,
+		Conflicts: []models.BundleIssue{},
+		Warnings:  []models.BundleIssue{},
+		Created:   map[string]int{},
+	}
+	conflict := func(kind, format string, args ...any) {
+		report.Conflicts = append(report.Conflicts, models.BundleIssue{Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(kind, format string, args ...any) {
+		report.Warnings = append(report.Warnings, models.BundleIssue{Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if b.Format != models.BundleFormat {
+		conflict(IssueUnsupportedFormat, "expected format %q, got %q", models.BundleFormat, b.Format)
+		return report, nil
+	}
+	if b.Version < 1 || b.Version > models.BundleVersion {
+		conflict(IssueUnsupportedFormat, "bundle version %d is not supported (max %d)", b.Version, models.BundleVersion)
+		return report, nil
+	}
+
+	name := b.Campaign.Name
+	if opts.Name != "" {
+		name = opts.Name
+	}
+	if name == "" {
+		conflict(IssueInvalidRecord, "campaign name is required")
+	}
+
+	var nameInUse bool
+	err := db.Get(&nameInUse, "SELECT EXISTS(SELECT 1 FROM campaigns WHERE gm_user_id = $1 AND name = $2)", opts.GMUserID, name)
+	if err != nil {
+		return nil, fmt.Errorf("error checking campaign names: %w", err)
+	}
+	if nameInUse {
+		warn(IssueNameInUse, "you already run a campaign named %q", name)
+	}
+
+	// Resolve every referenced user by email in one round trip
+	var emails []string
+	for _, m := range b.Members {
+		emails = append(emails, strings.ToLower(m.Email))
+	}
+	for _, c := range b.Characters {
+		if c.OwnerEmail != nil {
+			emails = append(emails, strings.ToLower(*c.OwnerEmail))
+		}
+	}
+	var users []struct {
+		ID    int    `db:"id"`
+		Email string `db:"email"`
+	}
+	err = db.Select(&users, "SELECT id, LOWER(email) as email FROM users WHERE LOWER(email) = ANY($1)", pq.Array(emails))
+	if err != nil {
+		return nil, fmt.Errorf("error matching users: %w", err)
+	}
+	userIDs := make(map[string]int, len(users))
+	for _, u := range users {
+		userIDs[u.Email] = u.ID
+	}
+
+	memberIDs := []int{opts.GMUserID}
+	for _, m := range b.Members {
+		id, ok := userIDs[strings.ToLower(m.Email)]
+		if !ok {
+			warn(IssueMissingUser, "member %s <%s> has no account here and will be skipped", m.Username, m.Email)
+			continue
+		}
+		memberIDs = append(memberIDs, id)
+	}
+
+	characterOwners := make(map[int]*int, len(b.Characters))
+	ownedBy := make(map[int][]string)
+	limit := b.Campaign.Settings.CharacterLimit()
+	for _, c := range b.Characters {
+		if c.Name == "" {
+			conflict(IssueInvalidRecord, "character %d has no name", c.ID)
+		}
+		if c.Status != "" && c.Status != models.CharacterStatusActive && c.Status != models.CharacterStatusRetired {
+			conflict(IssueInvalidRecord, "character %q has invalid status %q", c.Name, c.Status)
+		}
+		if c.Kind != "" && c.Kind != models.CharacterKindPC && c.Kind != models.CharacterKindNPC {
+			conflict(IssueInvalidRecord, "character %q has invalid kind %q", c.Name, c.Kind)
+		}
+		if c.OwnerEmail == nil {
+			characterOwners[c.ID] = nil
+			continue
+		}
+		if c.Kind == models.CharacterKindNPC {
+			conflict(IssueInvalidRecord, "NPC %q can't belong to <%s>", c.Name, *c.OwnerEmail)
+			continue
+		}
+		id, ok := userIDs[strings.ToLower(*c.OwnerEmail)]
+		if !ok {
+			conflict(IssueMissingUser, "character %q belongs to <%s>, who has no account here", c.Name, *c.OwnerEmail)
+			continue
+		}
+		// Retired characters don't count towards the per-player limit
+		if c.Status != models.CharacterStatusRetired {
+			if len(ownedBy[id]) >= limit {
+				conflict(IssueDuplicateOwner, "<%s> would own more than %d active characters (%s and %q)",
+					*c.OwnerEmail, limit, strings.Join(ownedBy[id], ", "), c.Name)
+				continue
+			}
+			ownedBy[id] = append(ownedBy[id], fmt.Sprintf("%q", c.Name))
+		}
+		characterOwners[c.ID] = &id
+		memberIDs = append(memberIDs, id)
+	}
+
+	knownCharacters := make(map[int]bool, len(b.Characters))
+	for _, c := range b.Characters {
+		knownCharacters[c.ID] = true
+		for _, cond := range c.Conditions {
+			if cond.EndedReason != nil && *cond.EndedReason != models.ConditionEndedExpired &&
+				*cond.EndedReason != models.ConditionEndedUsedUp && *cond.EndedReason != models.ConditionEndedRemoved {
+				conflict(IssueInvalidRecord, "character %q has condition %q with invalid end reason %q", c.Name, cond.Name, *cond.EndedReason)
+			}
+		}
+		for _, item := range c.Items {
+			if item.Quantity < 1 {
+				conflict(IssueInvalidRecord, "character %q has item %q with quantity %d", c.Name, item.Name, item.Quantity)
+			}
+		}
+		seenVersions := make(map[int]bool, len(c.Versions))
+		for _, v := range c.Versions {
+			if v.Version < 1 || seenVersions[v.Version] {
+				conflict(IssueInvalidRecord, "character %q has invalid or repeated version %d", c.Name, v.Version)
+			}
+			seenVersions[v.Version] = true
+		}
+		for _, a := range c.Advancements {
+			if a.Kind != models.AdvancementMaxDailyDice && a.Kind != models.AdvancementNewTrait && a.Kind != models.AdvancementTraitModifier {
+				conflict(IssueInvalidRecord, "character %q has advancement with invalid kind %q", c.Name, a.Kind)
+			}
+			if a.Status != models.AdvancementPending && a.Status != models.AdvancementApproved && a.Status != models.AdvancementRejected {
+				conflict(IssueInvalidRecord, "character %q has advancement with invalid status %q", c.Name, a.Status)
+			}
+		}
+		for _, t := range c.Traits {
+			if t.Kind != models.TraitKindSkill && t.Kind != models.TraitKindWeakness {
+				conflict(IssueInvalidRecord, "character %q has trait %q with invalid kind %q", c.Name, t.Name, t.Kind)
+			}
+		}
+	}
+	knownDice := make(map[int]bool)
+	for _, p := range b.DicePools {
+		if !knownCharacters[p.CharacterID] {
+			conflict(IssueDanglingReference, "dice pool %d references unknown character %d", p.ID, p.CharacterID)
+		}
+		for _, d := range p.Dice {
+			if d.DieResult < 1 || d.DieResult > 6 {
+				conflict(IssueInvalidRecord, "die %d in pool %d has invalid result %d", d.ID, p.ID, d.DieResult)
+			}
+			knownDice[d.ID] = true
+		}
+	}
+	knownChallenges := make(map[int]bool, len(b.Challenges))
+	for _, ch := range b.Challenges {
+		knownChallenges[ch.ID] = true
+		for _, characterID := range ch.TargetCharacterIDs {
+			if !knownCharacters[characterID] {
+				conflict(IssueDanglingReference, "challenge %d targets unknown character %d", ch.ID, characterID)
+			}
+		}
+		if ch.MaxAttemptsPerCharacter != nil && *ch.MaxAttemptsPerCharacter < 1 {
+			conflict(IssueInvalidRecord, "challenge %d has invalid max_attempts_per_character %d", ch.ID, *ch.MaxAttemptsPerCharacter)
+		}
+		switch ch.Status {
+		case "", models.ChallengeStatusScheduled, models.ChallengeStatusActive,
+			models.ChallengeStatusCompleted, models.ChallengeStatusExpired:
+		default:
+			conflict(IssueInvalidRecord, "challenge %d has invalid status %q", ch.ID, ch.Status)
+		}
+		if (ch.StartsOnDay != nil && *ch.StartsOnDay < 1) || (ch.EndsAfterDay != nil && *ch.EndsAfterDay < 1) ||
+			(ch.StartsOnDay != nil && ch.EndsAfterDay != nil && *ch.EndsAfterDay < *ch.StartsOnDay) {
+			conflict(IssueInvalidRecord, "challenge %d has an invalid day window", ch.ID)
+		}
+	}
+	for i, roll := range b.Rolls {
+		if roll.Outcome != "success" && roll.Outcome != "neutral" && roll.Outcome != "failure" {
+			conflict(IssueInvalidRecord, "roll %d has invalid outcome %q", i, roll.Outcome)
+		}
+		if !knownCharacters[roll.CharacterID] {
+			conflict(IssueDanglingReference, "roll %d references unknown character %d", i, roll.CharacterID)
+		}
+		if roll.PoolDieID != nil && !knownDice[*roll.PoolDieID] {
+			conflict(IssueDanglingReference, "roll %d references unknown pool die %d", i, *roll.PoolDieID)
+		}
+		if roll.ChallengeID != nil && !knownChallenges[*roll.ChallengeID] {
+			conflict(IssueDanglingReference, "roll %d references unknown challenge %d", i, *roll.ChallengeID)
+		}
+	}
+
+	if len(report.Conflicts) > 0 {
+		return report, nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	campaignID, err := insertBundle(tx, b, name, opts.GMUserID, memberIDs, characterOwners, report)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing import: %w", err)
+	}
+	report.CampaignID = &campaignID
+	return report, nil
+}
+
+// insertBundle writes every record in the bundle, remapping source IDs to new ones
+func insertBundle(tx *sqlx.Tx, b *models.CampaignBundle, name string, gmUserID int, memberIDs []int, characterOwners map[int]*int, report *models.BundleImportReport) (int, error) {
+	var campaignID int
+	err := tx.Get(&campaignID, `
+		INSERT INTO campaigns (name, gm_user_id, current_day, time_phase, settings, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, name, gmUserID, b.Campaign.CurrentDay, b.Campaign.TimePhase, b.Campaign.Settings, b.Campaign.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("error creating campaign: %w", err)
+	}
+	report.Created["campaigns"] = 1
+
+	for _, userID := range memberIDs {
+		result, err := tx.Exec(`
+			INSERT INTO campaign_members (campaign_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (campaign_id, user_id) DO NOTHING
+		`, campaignID, userID)
+		if err != nil {
+			return 0, fmt.Errorf("error adding member: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		report.Created["members"] += int(n)
+	}
+
+	characterIDs := make(map[int]int, len(b.Characters))
+	traitIDs := make(map[int]int)
+	conditionIDs := make(map[int]int)
+	itemIDs := make(map[int]int)
+	for _, c := range b.Characters {
+		// Older bundles used unowned characters as NPCs, all of them visible
+		kind, revealed := c.Kind, c.IsRevealed
+		if kind == "" {
+			kind = models.CharacterKindPC
+			if c.OwnerEmail == nil {
+				kind, revealed = models.CharacterKindNPC, true
+			}
+		}
+
+		var id int
+		err := tx.Get(&id, `
+			INSERT INTO characters (
+				campaign_id, user_id, name, description, notes, skill_name, skill_modifier,
+				weakness_name, weakness_modifier, max_daily_dice, kind, is_revealed, stat_block,
+				status, retired_at, retired_reason, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+			        COALESCE(NULLIF($14, ''), 'active'), $15, $16, $17)
+			RETURNING id
+		`, campaignID, characterOwners[c.ID], c.Name, c.Description, c.Notes, c.SkillName, c.SkillModifier,
+			c.WeaknessName, c.WeaknessModifier, c.MaxDailyDice, kind, revealed, c.StatBlock,
+			c.Status, c.RetiredAt, c.RetiredReason, c.CreatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("error creating character %q: %w", c.Name, err)
+		}
+		characterIDs[c.ID] = id
+		report.Created["characters"]++
+
+		snapshotTraits := []models.SnapshotTrait{}
+		for _, t := range bundleTraits(c) {
+			var traitID int
+			err := tx.Get(&traitID, `
+				INSERT INTO character_traits (character_id, name, kind, modifier, description, uses_per_day)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING id
+			`, id, t.Name, t.Kind, t.Modifier, t.Description, t.UsesPerDay)
+			if err != nil {
+				return 0, fmt.Errorf("error creating trait %q: %w", t.Name, err)
+			}
+			if t.ID != 0 {
+				traitIDs[t.ID] = traitID
+			}
+			snapshotTraits = append(snapshotTraits, models.SnapshotTrait{
+				ID: traitID, Name: t.Name, Kind: t.Kind, Modifier: t.Modifier, UsesPerDay: t.UsesPerDay,
+			})
+			report.Created["traits"]++
+		}
+
+		for _, cond := range c.Conditions {
+			var conditionID int
+			err := tx.Get(&conditionID, `
+				INSERT INTO character_conditions (
+					character_id, name, description, d6_modifier, dice_modifier, started_on_day,
+					expires_after_day, rolls_remaining, created_at, ended_at, ended_reason
+				)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				RETURNING id
+			`, id, cond.Name, cond.Description, cond.D6Modifier, cond.DiceModifier, cond.StartedOnDay,
+				cond.ExpiresAfterDay, cond.RollsRemaining, cond.CreatedAt, cond.EndedAt, cond.EndedReason)
+			if err != nil {
+				return 0, fmt.Errorf("error creating condition %q: %w", cond.Name, err)
+			}
+			conditionIDs[cond.ID] = conditionID
+			report.Created["conditions"]++
+		}
+
+		for _, item := range c.Items {
+			var itemID int
+			err := tx.Get(&itemID, `
+				INSERT INTO character_items (character_id, name, description, quantity, d6_modifier, consumable, granted_by_user_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				RETURNING id
+			`, id, item.Name, item.Description, item.Quantity, item.D6Modifier, item.Consumable, gmUserID)
+			if err != nil {
+				return 0, fmt.Errorf("error creating item %q: %w", item.Name, err)
+			}
+			itemIDs[item.ID] = itemID
+			report.Created["items"]++
+		}
+
+		for _, e := range c.Experience {
+			_, err := tx.Exec(`
+				INSERT INTO experience_entries (character_id, amount, reason, is_milestone, campaign_day, awarded_by_user_id, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, id, e.Amount, e.Reason, e.IsMilestone, e.CampaignDay, gmUserID, e.CreatedAt)
+			if err != nil {
+				return 0, fmt.Errorf("error creating experience entry: %w", err)
+			}
+			report.Created["experience_entries"]++
+		}
+
+		for _, a := range c.Advancements {
+			var traitID *int
+			if a.TraitID != nil {
+				if tid, ok := traitIDs[*a.TraitID]; ok {
+					traitID = &tid
+				}
+			}
+			_, err := tx.Exec(`
+				INSERT INTO character_advancements (
+					character_id, kind, status, trait_id, trait_name, trait_kind, trait_modifier,
+					note, review_note, created_at, reviewed_at
+				)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			`, id, a.Kind, a.Status, traitID, a.TraitName, a.TraitKind, a.TraitModifier,
+				a.Note, a.ReviewNote, a.CreatedAt, a.ReviewedAt)
+			if err != nil {
+				return 0, fmt.Errorf("error creating advancement: %w", err)
+			}
+			report.Created["advancements"]++
+		}
+
+		versions := c.Versions
+		if len(versions) == 0 {
+			status := c.Status
+			if status == "" {
+				status = models.CharacterStatusActive
+			}
+			versions = []models.BundleCharacterVersion{{
+				Version: 1,
+				Change:  models.CharacterChangeImported,
+				Snapshot: models.CharacterSnapshot{
+					Name: c.Name, Description: c.Description, UserID: characterOwners[c.ID],
+					Status:    status,
+					SkillName: c.SkillName, SkillModifier: c.SkillModifier,
+					WeaknessName: c.WeaknessName, WeaknessModifier: c.WeaknessModifier,
+					MaxDailyDice: c.MaxDailyDice, Traits: snapshotTraits,
+				},
+				CreatedAt: c.CreatedAt,
+			}}
+		}
+		currentVersion := 0
+		for _, v := range versions {
+			// Point snapshots at this campaign's traits and owner
+			for i, t := range v.Snapshot.Traits {
+				if tid, ok := traitIDs[t.ID]; ok {
+					v.Snapshot.Traits[i].ID = tid
+				}
+			}
+			v.Snapshot.UserID = characterOwners[c.ID]
+			_, err := tx.Exec(`
+				INSERT INTO character_versions (character_id, version, change, snapshot, changed_by_user_id, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, id, v.Version, v.Change, v.Snapshot, gmUserID, v.CreatedAt)
+			if err != nil {
+				return 0, fmt.Errorf("error creating character version: %w", err)
+			}
+			currentVersion = max(currentVersion, v.Version)
+		}
+		if _, err := tx.Exec("UPDATE characters SET current_version = $1 WHERE id = $2", currentVersion, id); err != nil {
+			return 0, fmt.Errorf("error setting character version: %w", err)
+		}
+	}
+
+	dieIDs := make(map[int]int)
+	for _, p := range b.DicePools {
+		var poolID int
+		err := tx.Get(&poolID, `
+			INSERT INTO dice_pools (character_id, rolled_at)
+			VALUES ($1, $2)
+			RETURNING id
+		`, characterIDs[p.CharacterID], p.RolledAt)
+		if err != nil {
+			return 0, fmt.Errorf("error creating dice pool: %w", err)
+		}
+		report.Created["dice_pools"]++
+
+		for _, d := range p.Dice {
+			var dieID int
+			err := tx.Get(&dieID, `
+				INSERT INTO pool_dice (pool_id, die_result, is_used, position)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id
+			`, poolID, d.DieResult, d.IsUsed, d.Position)
+			if err != nil {
+				return 0, fmt.Errorf("error creating pool die: %w", err)
+			}
+			dieIDs[d.ID] = dieID
+			report.Created["pool_dice"]++
+		}
+	}
+
+	challengeIDs := make(map[int]int, len(b.Challenges))
+	for _, ch := range b.Challenges {
+		status := ch.Status
+		if status == "" {
+			// Older bundles only record whether a challenge was active
+			status = models.ChallengeStatusCompleted
+			if ch.IsActive {
+				status = models.ChallengeStatusActive
+			}
+		}
+
+		var id int
+		err := tx.Get(&id, `
+			INSERT INTO challenges (
+				campaign_id, created_by_user_id, description, difficulty_modifier,
+				is_group_challenge, status, starts_on_day, ends_after_day,
+				progress_clock, max_attempts_per_character, is_hidden,
+				success_text, neutral_text, failure_text, consequence_tables, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			RETURNING id
+		`, campaignID, gmUserID, ch.Description, ch.DifficultyModifier,
+			ch.IsGroupChallenge, status, ch.StartsOnDay, ch.EndsAfterDay,
+			ch.ProgressClock, ch.MaxAttemptsPerCharacter, ch.IsHidden,
+			ch.SuccessText, ch.NeutralText, ch.FailureText, ch.ConsequenceTables, ch.CreatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("error creating challenge: %w", err)
+		}
+		for _, characterID := range ch.TargetCharacterIDs {
+			_, err := tx.Exec("INSERT INTO challenge_targets (challenge_id, character_id) VALUES ($1, $2)",
+				id, characterIDs[characterID])
+			if err != nil {
+				return 0, fmt.Errorf("error creating challenge target: %w", err)
+			}
+		}
+		challengeIDs[ch.ID] = id
+		report.Created["challenges"]++
+	}
+
+	for _, roll := range b.Rolls {
+		var poolDieID, challengeID *int
+		if roll.PoolDieID != nil {
+			id := dieIDs[*roll.PoolDieID]
+			poolDieID = &id
+		}
+		if roll.ChallengeID != nil {
+			id := challengeIDs[*roll.ChallengeID]
+			challengeID = &id
+		}
+
+		var rollID int
+		err := tx.Get(&rollID, `
+			INSERT INTO roll_history (
+				character_id, pool_dice_id, d20_roll, action_type, success, outcome, notes,
+				challenge_id, skill_applied, other_modifiers, modified_d6, campaign_day,
+				character_version, consequence, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			RETURNING id
+		`, characterIDs[roll.CharacterID], poolDieID, roll.D20Roll, roll.ActionType, roll.Success,
+			roll.Outcome, roll.Notes, challengeID, roll.SkillApplied, roll.OtherModifiers,
+			roll.ModifiedD6, roll.CampaignDay, roll.CharacterVersion, roll.Consequence, roll.CreatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("error creating roll: %w", err)
+		}
+
+		for _, t := range roll.Traits {
+			// Traits deleted before export, or not in the bundle, stay unlinked
+			var traitID *int
+			if t.TraitID != nil {
+				if id, ok := traitIDs[*t.TraitID]; ok {
+					traitID = &id
+				}
+			}
+			_, err := tx.Exec(`
+				INSERT INTO roll_history_traits (roll_id, trait_id, name, kind, modifier)
+				VALUES ($1, $2, $3, $4, $5)
+			`, rollID, traitID, t.Name, t.Kind, t.Modifier)
+			if err != nil {
+				return 0, fmt.Errorf("error creating roll trait: %w", err)
+			}
+		}
+
+		for _, cond := range roll.Conditions {
+			var conditionID *int
+			if cond.ConditionID != nil {
+				if id, ok := conditionIDs[*cond.ConditionID]; ok {
+					conditionID = &id
+				}
+			}
+			_, err := tx.Exec(`
+				INSERT INTO roll_history_conditions (roll_id, condition_id, name, d6_modifier)
+				VALUES ($1, $2, $3, $4)
+			`, rollID, conditionID, cond.Name, cond.D6Modifier)
+			if err != nil {
+				return 0, fmt.Errorf("error creating roll condition: %w", err)
+			}
+		}
+
+		for _, item := range roll.Items {
+			var itemID *int
+			if item.ItemID != nil {
+				if id, ok := itemIDs[*item.ItemID]; ok {
+					itemID = &id
+				}
+			}
+			_, err := tx.Exec(`
+				INSERT INTO roll_history_items (roll_id, item_id, name, d6_modifier, consumed)
+				VALUES ($1, $2, $3, $4, $5)
+			`, rollID, itemID, item.Name, item.D6Modifier, item.Consumed)
+			if err != nil {
+				return 0, fmt.Errorf("error creating roll item: %w", err)
+			}
+		}
+		report.Created["rolls"]++
+	}
+
+	return campaignID, nil
+}
+
+// bundleTraits returns a character's traits, deriving them from the
+// single skill and weakness fields for bundles that predate traits
+func bundleTraits(c models.BundleCharacter) []models.BundleTrait {
+	if c.Traits != nil {
+		return c.Traits
+	}
+
+	var traits []models.BundleTrait
+	if c.SkillName != nil && *c.SkillName != "" {
+		traits = append(traits, models.BundleTrait{Name: *c.SkillName, Kind: models.TraitKindSkill, Modifier: c.SkillModifier})
+	}
+	if c.WeaknessName != nil && *c.WeaknessName != "" {
+		traits = append(traits, models.BundleTrait{Name: *c.WeaknessName, Kind: models.TraitKindWeakness, Modifier: c.WeaknessModifier})
+	}
+	return traits
+}
@@ -0,0 +1,80 @@
+package bundle
+
+import "github.com/SamPCunningham/sleeper-system/internal/models"
+
+// TemplateOptions selects what carries over when a campaign is cloned or templated
+type TemplateOptions struct {
+	IncludeChallenges bool
+	IncludeCharacters bool
+	IncludeSettings   bool
+}
+
+// Template strips a bundle down to a fresh starting point for a new group.
+// Members, dice pools and roll history are never kept, only unassigned
+// active characters survive, and the campaign restarts on day one.
+func Template(b *models.CampaignBundle, opts TemplateOptions) *models.CampaignBundle {
+	t := &models.CampaignBundle{
+		Format:     b.Format,
+		Version:    b.Version,
+		ExportedAt: b.ExportedAt,
+		Campaign:   b.Campaign,
+		Members:    []models.BundleMember{},
+		Characters: []models.BundleCharacter{},
+		DicePools:  []models.BundleDicePool{},
+		Challenges: []models.BundleChallenge{},
+		Rolls:      []models.BundleRoll{},
+	}
+	t.Campaign.CurrentDay = 1
+	t.Campaign.TimePhase = 0
+
+	if !opts.IncludeSettings {
+		t.Campaign.Settings = models.CampaignSettings{}
+	}
+
+	if opts.IncludeCharacters {
+		for _, c := range b.Characters {
+			if c.OwnerEmail == nil && c.Status != models.CharacterStatusRetired {
+				// Conditions belong to the old campaign's timeline
+				c.Conditions = nil
+				// Templates start characters fresh; their upgrades stay baked into the sheet
+				c.Experience = nil
+				c.Advancements = nil
+				c.Versions = nil
+				t.Characters = append(t.Characters, c)
+			}
+		}
+	}
+
+	if opts.IncludeChallenges {
+		kept := make(map[int]bool, len(t.Characters))
+		for _, c := range t.Characters {
+			kept[c.ID] = true
+		}
+		for _, ch := range b.Challenges {
+			ch.IsActive = true
+			ch.Status = models.ChallengeStatusActive
+			// Campaign days restart, so the old schedule no longer lines up
+			ch.StartsOnDay = nil
+			ch.EndsAfterDay = nil
+			// Targets only survive if their characters do
+			var targets []int
+			for _, id := range ch.TargetCharacterIDs {
+				if kept[id] {
+					targets = append(targets, id)
+				}
+			}
+			ch.TargetCharacterIDs = targets
+			if len(targets) == 0 {
+				ch.IsHidden = false
+			}
+			if ch.ProgressClock != nil {
+				clock := *ch.ProgressClock
+				clock.Tally(nil)
+				ch.ProgressClock = &clock
+			}
+			t.Challenges = append(t.Challenges, ch)
+		}
+	}
+
+	return t
+}
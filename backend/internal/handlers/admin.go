@@ -7,17 +7,19 @@ import (
 	"strconv"
 
 	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
 	"github.com/SamPCunningham/sleeper-system/internal/models"
 	"github.com/go-chi/chi/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AdminHandler struct {
-	db *database.Database
+	db        *database.Database
+	authCache *middleware.AuthCache
 }
 
-func NewAdminHandler(db *database.Database) *AdminHandler {
-	return &AdminHandler{db: db}
+func NewAdminHandler(db *database.Database, authCache *middleware.AuthCache) *AdminHandler {
+	return &AdminHandler{db: db, authCache: authCache}
 }
 
 // ListUsers returns all users in the system
@@ -135,15 +137,17 @@ func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Build update query dynamically based on provided fields
+	// Build update query dynamically based on provided fields. A role change
+	// bumps the token version so tokens carrying the old role stop working.
 	var user models.User
 	query := `
 		UPDATE users
 		SET username = COALESCE(NULLIF($1, ''), username),
 		    email = COALESCE(NULLIF($2, ''), email),
-		    system_role = COALESCE(NULLIF($3, ''), system_role)
+		    system_role = COALESCE(NULLIF($3, ''), system_role),
+		    token_version = token_version + CASE WHEN $3 <> '' AND $3 <> system_role THEN 1 ELSE 0 END
 		WHERE id = $4
-		RETURNING id, username, email, system_role, created_at
+		RETURNING id, username, email, system_role, token_version, created_at
 	`
 	err = h.db.QueryRowx(query, req.Username, req.Email, req.SystemRole, userID).StructScan(&user)
 	if err != nil {
@@ -151,6 +155,7 @@ func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error updating user", http.StatusInternalServerError)
 		return
 	}
+	h.authCache.InvalidateUser(userID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
@@ -195,6 +200,12 @@ func (h *AdminHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Whoever knew the old password shouldn't stay signed in
+	if _, err := revokeUserSessions(h.db, userID); err != nil {
+		log.Printf("Error revoking sessions after password change: %v", err)
+	}
+	h.authCache.InvalidateUser(userID)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Password updated successfully"})
 }
@@ -242,6 +253,9 @@ func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Their sessions went with them; make sure cached lookups don't outlive them
+	h.authCache.InvalidateUser(userID)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "User deleted successfully"})
 }
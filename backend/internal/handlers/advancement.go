@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+const advancementColumns = `
+	id, character_id, kind, status, trait_id, trait_name, trait_kind, trait_modifier, note,
+	requested_by_user_id, reviewed_by_user_id, review_note, created_at, reviewed_at
+`
+
+type AdvancementHandler struct {
+	db  *database.Database
+	hub *websocket.Hub
+}
+
+func NewAdvancementHandler(db *database.Database, hub *websocket.Hub) *AdvancementHandler {
+	return &AdvancementHandler{db: db, hub: hub}
+}
+
+// characterProgress totals a character's experience and works out how many
+// advancements they have unlocked but not yet spent
+func characterProgress(q sqlx.Queryer, characterID int) (*models.CharacterProgress, error) {
+	var settings models.CampaignSettings
+	err := sqlx.Get(q, &settings, `
+		SELECT ca.settings
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &models.CharacterProgress{
+		CharacterID:  characterID,
+		Thresholds:   settings.Thresholds(),
+		Experiences:  []models.ExperienceEntry{},
+		Advancements: []models.CharacterAdvancement{},
+	}
+
+	err = sqlx.Select(q, &progress.Experiences, `
+		SELECT e.id, e.character_id, e.amount, e.reason, e.is_milestone, e.campaign_day,
+		       e.awarded_by_user_id, u.username as awarded_by_name, e.created_at
+		FROM experience_entries e
+		LEFT JOIN users u ON e.awarded_by_user_id = u.id
+		WHERE e.character_id = $1
+		ORDER BY e.created_at DESC, e.id DESC
+	`, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sqlx.Select(q, &progress.Advancements, `
+		SELECT `+advancementColumns+`
+		FROM character_advancements
+		WHERE character_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range progress.Experiences {
+		progress.Experience += entry.Amount
+	}
+	for _, threshold := range progress.Thresholds {
+		if progress.Experience >= threshold {
+			progress.AdvancementsEarned++
+		} else if progress.NextThreshold == nil {
+			next := threshold
+			progress.NextThreshold = &next
+		}
+	}
+	for _, a := range progress.Advancements {
+		if a.Status != models.AdvancementRejected {
+			progress.AdvancementsUsed++
+		}
+	}
+	progress.Available = max(progress.AdvancementsEarned-progress.AdvancementsUsed, 0)
+
+	return progress, nil
+}
+
+func (h *AdvancementHandler) broadcast(characterID int, action string, payload map[string]any) {
+	payload["action"] = action
+	payload["character_id"] = characterID
+	broadcastForCharacter(h.db, h.hub, characterID, websocket.MessageTypeCharacterUpdate, payload)
+}
+
+// GetProgress returns a character's experience, unlocked advancements and full history
+func (h *AdvancementHandler) GetProgress(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
+
+	progress, err := characterProgress(h.db, characterID)
+	if err != nil {
+		log.Printf("Error fetching advancement: %v", err)
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// AwardExperience gives a character experience with a reason (GM only)
+func (h *AdvancementHandler) AwardExperience(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+
+	var info struct {
+		CampaignID int `db:"campaign_id"`
+		GMUserID   int `db:"gm_user_id"`
+		CurrentDay int `db:"current_day"`
+	}
+	err = h.db.Get(&info, `
+		SELECT ch.campaign_id, ca.gm_user_id, ca.current_day
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return
+	}
+	if info.GMUserID != userID {
+		http.Error(w, "Only the GM can award experience", http.StatusForbidden)
+		return
+	}
+
+	var req models.AwardExperienceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		http.Error(w, "A reason is required", http.StatusBadRequest)
+		return
+	}
+	if req.Amount == 0 || req.Amount < -100 || req.Amount > 100 {
+		http.Error(w, "amount must be between -100 and 100 and not zero", http.StatusBadRequest)
+		return
+	}
+
+	var entry models.ExperienceEntry
+	query := `
+		INSERT INTO experience_entries (character_id, amount, reason, is_milestone, campaign_day, awarded_by_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, character_id, amount, reason, is_milestone, campaign_day, awarded_by_user_id, created_at
+	`
+	err = h.db.QueryRowx(query, characterID, req.Amount, req.Reason, req.IsMilestone, info.CurrentDay, userID).StructScan(&entry)
+	if err != nil {
+		log.Printf("Error awarding experience: %v", err)
+		http.Error(w, "Error awarding experience", http.StatusInternalServerError)
+		return
+	}
+
+	progress, err := characterProgress(h.db, characterID)
+	if err != nil {
+		log.Printf("Error fetching advancement: %v", err)
+		http.Error(w, "Error fetching advancement", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcast(characterID, "experience_awarded", map[string]any{
+		"entry":      entry,
+		"experience": progress.Experience,
+		"available":  progress.Available,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(progress)
+}
+
+// RequestAdvancement spends an unlocked advancement on an upgrade for the GM to
+// approve. The character's owner or the GM may ask.
+func (h *AdvancementHandler) RequestAdvancement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+
+	var info struct {
+		CampaignID int  `db:"campaign_id"`
+		OwnerID    *int `db:"user_id"`
+		GMUserID   int  `db:"gm_user_id"`
+	}
+	err = h.db.Get(&info, `
+		SELECT ch.campaign_id, ch.user_id, ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return
+	}
+	isOwner := info.OwnerID != nil && *info.OwnerID == userID
+	if !isOwner && info.GMUserID != userID {
+		http.Error(w, "Only the character's owner or the GM can request advancements", http.StatusForbidden)
+		return
+	}
+
+	var req models.RequestAdvancementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Only the fields that matter for the chosen kind are kept
+	advancement := models.CharacterAdvancement{CharacterID: characterID, Kind: req.Kind, Note: req.Note}
+	switch req.Kind {
+	case models.AdvancementMaxDailyDice:
+	case models.AdvancementNewTrait:
+		if req.TraitName == nil || strings.TrimSpace(*req.TraitName) == "" {
+			http.Error(w, "trait_name is required for a new trait", http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSpace(*req.TraitName)
+		kind := models.TraitKindSkill
+		if req.TraitKind != nil {
+			kind = *req.TraitKind
+		}
+		if !validTraitKind(kind) {
+			http.Error(w, "trait_kind must be 'skill' or 'weakness'", http.StatusBadRequest)
+			return
+		}
+		modifier := 1
+		if req.TraitModifier != nil {
+			modifier = *req.TraitModifier
+		}
+		if modifier < -5 || modifier > 5 {
+			http.Error(w, "trait_modifier must be between -5 and 5", http.StatusBadRequest)
+			return
+		}
+		advancement.TraitName, advancement.TraitKind, advancement.TraitModifier = &name, &kind, &modifier
+	case models.AdvancementTraitModifier:
+		if req.TraitID == nil {
+			http.Error(w, "trait_id is required to improve a trait", http.StatusBadRequest)
+			return
+		}
+		var trait models.CharacterTrait
+		err := h.db.Get(&trait, traitSelect+` WHERE ct.id = $1`, *req.TraitID)
+		if err != nil || trait.CharacterID != characterID {
+			http.Error(w, "Trait does not belong to this character", http.StatusBadRequest)
+			return
+		}
+		if trait.Modifier >= 5 {
+			http.Error(w, "Trait modifier is already at its maximum", http.StatusConflict)
+			return
+		}
+		advancement.TraitID, advancement.TraitName, advancement.TraitKind = &trait.ID, &trait.Name, &trait.Kind
+	default:
+		http.Error(w, "kind must be 'max_daily_dice', 'new_trait' or 'trait_modifier'", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error requesting advancement", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Serialise requests for the same character so two can't spend one advancement
+	_, err = tx.Exec("SELECT id FROM characters WHERE id = $1 FOR UPDATE", characterID)
+	if err != nil {
+		http.Error(w, "Error requesting advancement", http.StatusInternalServerError)
+		return
+	}
+
+	progress, err := characterProgress(tx, characterID)
+	if err != nil {
+		log.Printf("Error fetching advancement: %v", err)
+		http.Error(w, "Error requesting advancement", http.StatusInternalServerError)
+		return
+	}
+	if progress.Available < 1 {
+		http.Error(w, "No advancements available; earn more experience first", http.StatusConflict)
+		return
+	}
+
+	query := `
+		INSERT INTO character_advancements (
+			character_id, kind, trait_id, trait_name, trait_kind, trait_modifier, note, requested_by_user_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + advancementColumns
+	err = tx.QueryRowx(query, characterID, advancement.Kind, advancement.TraitID, advancement.TraitName,
+		advancement.TraitKind, advancement.TraitModifier, advancement.Note, userID).StructScan(&advancement)
+	if err != nil {
+		log.Printf("Error requesting advancement: %v", err)
+		http.Error(w, "Error requesting advancement", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error requesting advancement", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcast(characterID, "advancement_requested", map[string]any{"advancement": advancement})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(advancement)
+}
+
+// loadPendingAdvancement locks a pending advancement and checks the user is its campaign's GM
+func (h *AdvancementHandler) loadPendingAdvancement(w http.ResponseWriter, r *http.Request, tx *sqlx.Tx, action string) (*models.CharacterAdvancement, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	advancementID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid advancement ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var advancement models.CharacterAdvancement
+	err = tx.Get(&advancement, "SELECT "+advancementColumns+" FROM character_advancements WHERE id = $1 FOR UPDATE", advancementID)
+	if err != nil {
+		http.Error(w, "Advancement not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	var gmUserID int
+	err = tx.Get(&gmUserID, `
+		SELECT ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, advancement.CharacterID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can "+action+" advancements", http.StatusForbidden)
+		return nil, false
+	}
+
+	if advancement.Status != models.AdvancementPending {
+		http.Error(w, "Advancement has already been "+advancement.Status, http.StatusConflict)
+		return nil, false
+	}
+
+	return &advancement, true
+}
+
+// Approve applies a requested upgrade to the character (GM only)
+func (h *AdvancementHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReviewAdvancementRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error approving advancement", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	advancement, ok := h.loadPendingAdvancement(w, r, tx, "approve")
+	if !ok {
+		return
+	}
+
+	switch advancement.Kind {
+	case models.AdvancementMaxDailyDice:
+		var maxDice int
+		err = tx.Get(&maxDice, "SELECT max_daily_dice FROM characters WHERE id = $1", advancement.CharacterID)
+		if err == nil && maxDice >= 20 {
+			http.Error(w, "Character already has the maximum daily dice", http.StatusConflict)
+			return
+		}
+		if err == nil {
+			_, err = tx.Exec("UPDATE characters SET max_daily_dice = max_daily_dice + 1 WHERE id = $1", advancement.CharacterID)
+		}
+	case models.AdvancementNewTrait:
+		var traitID int
+		err = tx.Get(&traitID, `
+			INSERT INTO character_traits (character_id, name, kind, modifier)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, advancement.CharacterID, advancement.TraitName, advancement.TraitKind, advancement.TraitModifier)
+		advancement.TraitID = &traitID
+	case models.AdvancementTraitModifier:
+		if advancement.TraitID == nil {
+			http.Error(w, "The trait to improve no longer exists", http.StatusConflict)
+			return
+		}
+		var modifier int
+		err = tx.Get(&modifier, `
+			UPDATE character_traits SET modifier = modifier + 1
+			WHERE id = $1 AND modifier < 5
+			RETURNING modifier
+		`, *advancement.TraitID)
+		if err != nil {
+			http.Error(w, "Trait modifier is already at its maximum", http.StatusConflict)
+			return
+		}
+		advancement.TraitModifier = &modifier
+	}
+	if err == nil {
+		_, err = recordCharacterVersion(tx, advancement.CharacterID, userID, models.CharacterChangeAdvancement)
+	}
+	if err != nil {
+		log.Printf("Error applying advancement: %v", err)
+		http.Error(w, "Error approving advancement", http.StatusInternalServerError)
+		return
+	}
+
+	query := `
+		UPDATE character_advancements
+		SET status = 'approved', trait_id = $1, trait_modifier = $2,
+		    reviewed_by_user_id = $3, review_note = $4, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING ` + advancementColumns
+	err = tx.QueryRowx(query, advancement.TraitID, advancement.TraitModifier, userID, req.Note, advancement.ID).StructScan(advancement)
+	if err != nil {
+		log.Printf("Error approving advancement: %v", err)
+		http.Error(w, "Error approving advancement", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error approving advancement", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcast(advancement.CharacterID, "advancement_approved", map[string]any{"advancement": advancement})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(advancement)
+}
+
+// Reject turns down a requested upgrade, freeing the advancement to be spent again (GM only)
+func (h *AdvancementHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ReviewAdvancementRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error rejecting advancement", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	advancement, ok := h.loadPendingAdvancement(w, r, tx, "reject")
+	if !ok {
+		return
+	}
+
+	query := `
+		UPDATE character_advancements
+		SET status = 'rejected', reviewed_by_user_id = $1, review_note = $2, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING ` + advancementColumns
+	err = tx.QueryRowx(query, userID, req.Note, advancement.ID).StructScan(advancement)
+	if err != nil {
+		log.Printf("Error rejecting advancement: %v", err)
+		http.Error(w, "Error rejecting advancement", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error rejecting advancement", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcast(advancement.CharacterID, "advancement_rejected", map[string]any{"advancement": advancement})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(advancement)
+}
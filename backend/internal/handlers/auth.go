@@ -7,17 +7,19 @@ import (
 	"time"
 
 	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
 	"github.com/SamPCunningham/sleeper-system/internal/models"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthHandler struct {
-	db *database.Database
+	db        *database.Database
+	authCache *middleware.AuthCache
 }
 
-func NewAuthHandler(db *database.Database) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(db *database.Database, authCache *middleware.AuthCache) *AuthHandler {
+	return &AuthHandler{db: db, authCache: authCache}
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -45,7 +47,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	query := `
 		INSERT INTO users (username, email, password_hash, system_role)
 		VALUES ($1, $2, $3, 'player')
-		RETURNING id, username, email, system_role, created_at
+		RETURNING id, username, email, system_role, token_version, created_at
 	`
 	err = h.db.QueryRowx(query, req.Username, req.Email, string(hashedPassword)).StructScan(&user)
 	if err != nil {
@@ -53,20 +55,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate JWT
-	token, err := generateToken(user.ID, user.SystemRole)
-	if err != nil {
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
-		return
-	}
-
-	response := models.AuthResponse{
-		Token: token,
-		User:  user,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	h.startSession(w, r, &user)
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -78,7 +67,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// Get user by email
 	var user models.User
-	query := `SELECT id, username, email, password_hash, system_role, created_at FROM users WHERE email = $1`
+	query := `SELECT id, username, email, password_hash, system_role, token_version, created_at FROM users WHERE email = $1`
 	err := h.db.Get(&user, query, req.Email)
 	if err != nil {
 		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
@@ -92,28 +81,21 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate JWT with role
-	token, err := generateToken(user.ID, user.SystemRole)
-	if err != nil {
-		http.Error(w, "Error generating token", http.StatusInternalServerError)
-		return
-	}
-
-	response := models.AuthResponse{
-		Token: token,
-		User:  user,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	h.startSession(w, r, &user)
 }
 
-func generateToken(userID int, role string) (string, error) {
+// generateToken issues a short-lived access token tied to a login session and
+// the user's current token version
+func generateToken(user *models.User, sessionID int) (string, time.Time, error) {
+	expiresAt := time.Now().Add(accessTokenTTL)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
+		"user_id": user.ID,
+		"role":    user.SystemRole,
+		"sid":     sessionID,
+		"ver":     user.TokenVersion,
+		"exp":     expiresAt.Unix(),
 	})
 
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	signed, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return signed, expiresAt, err
 }
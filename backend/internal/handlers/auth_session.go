@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// accessTokenTTL is how long an access token is accepted before it must be refreshed
+	accessTokenTTL = 15 * time.Minute
+	// refreshTokenTTL is how long a login lasts without being used; every refresh extends it
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// authSessionColumns lists the auth session fields in models.AuthSession order
+const authSessionColumns = "id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at"
+
+// newRefreshToken returns a random refresh token and the hash stored in its place
+func newRefreshToken() (token, hash string, err error) {
+	token, err = randomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// clientIP returns the request's remote address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// revokeUserSessions signs a user out everywhere, returning how many sessions were open
+func revokeUserSessions(q sqlx.Execer, userID int) (int64, error) {
+	result, err := q.Exec(`
+		UPDATE auth_sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// startSession opens a login session for the user and writes the token pair
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user *models.User) {
+	refreshToken, hash, err := newRefreshToken()
+	if err != nil {
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	// Expired sessions are of no further use, so clear out the user's old ones
+	if _, err := h.db.Exec("DELETE FROM auth_sessions WHERE user_id = $1 AND expires_at < CURRENT_TIMESTAMP", user.ID); err != nil {
+		log.Printf("Error pruning auth sessions: %v", err)
+	}
+
+	var userAgent *string
+	if ua := r.UserAgent(); ua != "" {
+		userAgent = &ua
+	}
+
+	var sessionID int
+	err = h.db.Get(&sessionID, `
+		INSERT INTO auth_sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + make_interval(secs => $5))
+		RETURNING id
+	`, user.ID, hash, userAgent, clientIP(r), refreshTokenTTL.Seconds())
+	if err != nil {
+		log.Printf("Error creating auth session: %v", err)
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	writeTokens(w, user, sessionID, refreshToken)
+}
+
+// writeTokens issues an access token for the session and writes it with the refresh token
+func writeTokens(w http.ResponseWriter, user *models.User, sessionID int, refreshToken string) {
+	token, expiresAt, err := generateToken(user, sessionID)
+	if err != nil {
+		http.Error(w, "Error generating token", http.StatusInternalServerError)
+		return
+	}
+
+	response := models.AuthResponse{
+		Token:          token,
+		TokenExpiresAt: expiresAt,
+		RefreshToken:   refreshToken,
+		User:           *user,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Refresh trades a refresh token for a new access token and refresh token.
+// Each refresh token works once; replaying an old one revokes the session.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		http.Error(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+	hash := hashRefreshToken(req.RefreshToken)
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var session struct {
+		ID      int  `db:"id"`
+		UserID  int  `db:"user_id"`
+		Current bool `db:"current"`
+		Active  bool `db:"active"`
+	}
+	err = tx.Get(&session, `
+		SELECT id, user_id,
+		       refresh_token_hash = $1 as current,
+		       revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP as active
+		FROM auth_sessions
+		WHERE refresh_token_hash = $1 OR previous_token_hash = $1
+		FOR UPDATE
+	`, hash)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching auth session: %v", err)
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+	if !session.Active {
+		http.Error(w, "Session has been revoked or has expired", http.StatusUnauthorized)
+		return
+	}
+
+	if !session.Current {
+		// A rotated-out token came back, so someone else holds a copy of it
+		_, err = tx.Exec("UPDATE auth_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1", session.ID)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("Error revoking reused auth session: %v", err)
+		}
+		h.authCache.InvalidateSession(session.ID)
+		http.Error(w, "Refresh token has already been used; the session has been revoked", http.StatusUnauthorized)
+		return
+	}
+
+	refreshToken, newHash, err := newRefreshToken()
+	if err != nil {
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+	_, err = tx.Exec(`
+		UPDATE auth_sessions
+		SET previous_token_hash = refresh_token_hash,
+		    refresh_token_hash = $1,
+		    last_used_at = CURRENT_TIMESTAMP,
+		    expires_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id = $3
+	`, newHash, refreshTokenTTL.Seconds(), session.ID)
+	if err != nil {
+		log.Printf("Error rotating refresh token: %v", err)
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+
+	// The new access token picks up any role change since the last one
+	var user models.User
+	err = tx.Get(&user, "SELECT id, username, email, system_role, token_version, created_at FROM users WHERE id = $1", session.UserID)
+	if err != nil {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error refreshing token", http.StatusInternalServerError)
+		return
+	}
+
+	writeTokens(w, &user, session.ID, refreshToken)
+}
+
+// Logout revokes the session the request was made with
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	sessionID, ok := middleware.GetSessionID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	_, err := h.db.Exec(`
+		UPDATE auth_sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		log.Printf("Error revoking auth session: %v", err)
+		http.Error(w, "Error logging out", http.StatusInternalServerError)
+		return
+	}
+	h.authCache.InvalidateSession(sessionID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out successfully"})
+}
+
+// LogoutAll revokes every session the user has open, including this one
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revoked, err := revokeUserSessions(h.db, userID)
+	if err != nil {
+		log.Printf("Error revoking auth sessions: %v", err)
+		http.Error(w, "Error logging out", http.StatusInternalServerError)
+		return
+	}
+	h.authCache.InvalidateUser(userID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":          "Logged out everywhere",
+		"revoked_sessions": revoked,
+	})
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	currentID, _ := middleware.GetSessionID(r.Context())
+
+	var sessions []models.AuthSession
+	err := h.db.Select(&sessions, `
+		SELECT `+authSessionColumns+`
+		FROM auth_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY last_used_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		log.Printf("Error fetching auth sessions: %v", err)
+		http.Error(w, "Error fetching sessions", http.StatusInternalServerError)
+		return
+	}
+
+	if sessions == nil {
+		sessions = []models.AuthSession{}
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession signs out one of the user's own sessions, e.g. a lost device
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var session models.AuthSession
+	err = h.db.QueryRowx(`
+		UPDATE auth_sessions SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+		RETURNING `+authSessionColumns, sessionID, userID).StructScan(&session)
+	if err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	h.authCache.InvalidateSession(session.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
+	"github.com/SamPCunningham/sleeper-system/internal/bundle"
 	"github.com/SamPCunningham/sleeper-system/internal/database"
 	"github.com/SamPCunningham/sleeper-system/internal/middleware"
 	"github.com/SamPCunningham/sleeper-system/internal/models"
@@ -50,7 +53,7 @@ func (h *CampaignHandler) Create(w http.ResponseWriter, r *http.Request) {
 	query := `
 		INSERT INTO campaigns (name, gm_user_id)
 		VALUES ($1, $2)
-		RETURNING id, name, gm_user_id, current_day, created_at
+		RETURNING id, name, gm_user_id, current_day, time_phase, settings, created_at
 	`
 	err := h.db.QueryRowx(query, req.Name, userID).StructScan(&campaign)
 	if err != nil {
@@ -84,7 +87,7 @@ func (h *CampaignHandler) List(w http.ResponseWriter, r *http.Request) {
 	if userRole == models.RoleAdmin {
 		// Admins see all campaigns
 		query := `
-			SELECT id, name, gm_user_id, current_day, created_at
+			SELECT id, name, gm_user_id, current_day, time_phase, settings, created_at
 			FROM campaigns
 			ORDER BY created_at DESC
 		`
@@ -92,7 +95,7 @@ func (h *CampaignHandler) List(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// Regular users see campaigns they're members of
 		query := `
-			SELECT DISTINCT c.id, c.name, c.gm_user_id, c.current_day, c.created_at
+			SELECT DISTINCT c.id, c.name, c.gm_user_id, c.current_day, c.time_phase, c.settings, c.created_at
 			FROM campaigns c
 			JOIN campaign_members cm ON c.id = cm.campaign_id
 			WHERE cm.user_id = $1
@@ -122,7 +125,7 @@ func (h *CampaignHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var campaign models.Campaign
-	query := `SELECT id, name, gm_user_id, current_day, created_at FROM campaigns WHERE id = $1`
+	query := `SELECT id, name, gm_user_id, current_day, time_phase, settings, created_at FROM campaigns WHERE id = $1`
 	err = h.db.Get(&campaign, query, campaignID)
 	if err != nil {
 		http.Error(w, "Campaign not found", http.StatusNotFound)
@@ -154,25 +157,16 @@ func (h *CampaignHandler) IncrementDay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var campaign models.Campaign
-	query := `
-		UPDATE campaigns 
-		SET current_day = current_day + 1
-		WHERE id = $1
-		RETURNING id, name, gm_user_id, current_day, created_at
-	`
-	err = h.db.QueryRowx(query, campaignID).StructScan(&campaign)
+	// A new day always starts at the first time-of-day phase
+	campaign, err := h.changeClock(campaignID, userID, models.ClockReasonIncrement, nil, func(day, phase, phaseCount int) (int, int) {
+		return day + 1, 0
+	})
 	if err != nil {
+		log.Printf("Error incrementing day: %v", err)
 		http.Error(w, "Error incrementing day", http.StatusInternalServerError)
 		return
 	}
 
-	// Broadcast day increment to all connected clients
-	h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeDayIncremented, map[string]any{
-		"campaign_id": campaignID,
-		"current_day": campaign.CurrentDay,
-	})
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(campaign)
 }
@@ -368,3 +362,87 @@ func (h *CampaignHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Member removed successfully"})
 }
+
+// Export downloads the whole campaign as a portable bundle (GM or admin only)
+func (h *CampaignHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	userRole, _ := middleware.GetUserRole(r.Context())
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	if gmUserID != userID && userRole != models.RoleAdmin {
+		http.Error(w, "Only the GM or an admin can export a campaign", http.StatusForbidden)
+		return
+	}
+
+	b, err := bundle.Export(h.db, campaignID)
+	if err != nil {
+		log.Printf("Error exporting campaign %d: %v", campaignID, err)
+		http.Error(w, "Error exporting campaign", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%d.json"`, campaignID))
+	json.NewEncoder(w).Encode(b)
+}
+
+// Import recreates a campaign from a bundle, with the caller as GM.
+// Pass ?dry_run=true to get the conflict report without creating anything.
+func (h *CampaignHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userRole, _ := middleware.GetUserRole(r.Context())
+	if userRole != models.RoleAdmin && userRole != models.RoleGameMaster {
+		http.Error(w, "Only admins and game masters can import campaigns", http.StatusForbidden)
+		return
+	}
+
+	var b models.CampaignBundle
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		http.Error(w, "Invalid bundle", http.StatusBadRequest)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	report, err := bundle.Import(h.db, &b, bundle.ImportOptions{
+		GMUserID: userID,
+		Name:     r.URL.Query().Get("name"),
+		DryRun:   dryRun,
+	})
+	if err != nil {
+		log.Printf("Error importing campaign: %v", err)
+		http.Error(w, "Error importing campaign", http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if len(report.Conflicts) > 0 {
+		status = http.StatusConflict
+	} else if !dryRun {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/SamPCunningham/sleeper-system/internal/bundle"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+)
+
+// UpdateSettings merges the provided settings into the campaign's settings (GM only)
+func (h *CampaignHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is GM
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can change campaign settings", http.StatusForbidden)
+		return
+	}
+
+	var req models.CampaignSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.DefaultMaxDailyDice != nil && (*req.DefaultMaxDailyDice < 1 || *req.DefaultMaxDailyDice > 20) {
+		http.Error(w, "default_max_daily_dice must be between 1 and 20", http.StatusBadRequest)
+		return
+	}
+	if req.MaxCharactersPerUser != nil && (*req.MaxCharactersPerUser < 1 || *req.MaxCharactersPerUser > 20) {
+		http.Error(w, "max_characters_per_user must be between 1 and 20", http.StatusBadRequest)
+		return
+	}
+	for i, threshold := range req.AdvancementThresholds {
+		if threshold < 1 || (i > 0 && threshold <= req.AdvancementThresholds[i-1]) {
+			http.Error(w, "advancement_thresholds must be positive and increasing", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Calendar != nil {
+		if msg := validateCalendar(req.Calendar); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// A new calendar may have fewer phases, so clamp the current one as UpdateCalendar does
+	var lastPhase *int
+	if req.Calendar != nil {
+		n := len(req.Calendar.PhaseNames()) - 1
+		lastPhase = &n
+	}
+
+	var campaign models.Campaign
+	query := `
+		UPDATE campaigns
+		SET settings = settings || $1::jsonb,
+		    time_phase = LEAST(time_phase, COALESCE($2::int, time_phase))
+		WHERE id = $3
+		RETURNING id, name, gm_user_id, current_day, time_phase, settings, created_at
+	`
+	err = h.db.QueryRowx(query, req, lastPhase, campaignID).StructScan(&campaign)
+	if err != nil {
+		log.Printf("Error updating settings: %v", err)
+		http.Error(w, "Error updating settings", http.StatusInternalServerError)
+		return
+	}
+
+	if req.Calendar != nil {
+		h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeClockChanged, map[string]any{
+			"campaign_id": campaignID,
+			"reason":      "calendar",
+			"clock":       campaignClock(&campaign),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(campaign)
+}
+
+// Clone copies a campaign into a new one run by the caller.
+// Roll history, dice pools and members are never copied.
+func (h *CampaignHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	userRole, _ := middleware.GetUserRole(r.Context())
+	if userRole != models.RoleAdmin && userRole != models.RoleGameMaster {
+		http.Error(w, "Only admins and game masters can create campaigns", http.StatusForbidden)
+		return
+	}
+
+	var source struct {
+		GMUserID int    `db:"gm_user_id"`
+		Name     string `db:"name"`
+	}
+	err = h.db.Get(&source, "SELECT gm_user_id, name FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	if source.GMUserID != userID && userRole != models.RoleAdmin {
+		http.Error(w, "Only the GM or an admin can clone a campaign", http.StatusForbidden)
+		return
+	}
+
+	var req models.CloneCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		req.Name = source.Name + " (copy)"
+	}
+
+	b, err := bundle.Export(h.db, campaignID)
+	if err != nil {
+		log.Printf("Error reading campaign %d for clone: %v", campaignID, err)
+		http.Error(w, "Error cloning campaign", http.StatusInternalServerError)
+		return
+	}
+
+	t := bundle.Template(b, bundle.TemplateOptions{
+		IncludeChallenges: req.IncludeChallenges,
+		IncludeCharacters: req.IncludeCharacters,
+		IncludeSettings:   req.IncludeSettings,
+	})
+
+	report, err := bundle.Import(h.db, t, bundle.ImportOptions{GMUserID: userID, Name: req.Name})
+	if err != nil {
+		log.Printf("Error cloning campaign %d: %v", campaignID, err)
+		http.Error(w, "Error cloning campaign", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeImportedCampaign(w, report)
+}
+
+// SaveTemplate stores a campaign as a reusable template for any GM on the instance
+func (h *CampaignHandler) SaveTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	userRole, _ := middleware.GetUserRole(r.Context())
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	if gmUserID != userID && userRole != models.RoleAdmin {
+		http.Error(w, "Only the GM or an admin can save a campaign as a template", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateCampaignTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Template name is required", http.StatusBadRequest)
+		return
+	}
+
+	b, err := bundle.Export(h.db, campaignID)
+	if err != nil {
+		log.Printf("Error reading campaign %d for template: %v", campaignID, err)
+		http.Error(w, "Error saving template", http.StatusInternalServerError)
+		return
+	}
+
+	t := bundle.Template(b, bundle.TemplateOptions{
+		IncludeChallenges: req.IncludeChallenges,
+		IncludeCharacters: req.IncludeCharacters,
+		IncludeSettings:   req.IncludeSettings,
+	})
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		http.Error(w, "Error saving template", http.StatusInternalServerError)
+		return
+	}
+
+	var template models.CampaignTemplate
+	query := `
+		INSERT INTO campaign_templates (name, description, created_by_user_id, source_campaign_id, bundle)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, name, description, created_by_user_id, source_campaign_id,
+		          jsonb_array_length(bundle->'challenges') as challenge_count,
+		          jsonb_array_length(bundle->'characters') as character_count,
+		          created_at
+	`
+	err = h.db.QueryRowx(query, req.Name, req.Description, userID, campaignID, string(data)).StructScan(&template)
+	if err != nil {
+		log.Printf("Error saving template: %v", err)
+		http.Error(w, "Error saving template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// ListTemplates returns every campaign template on the instance
+func (h *CampaignHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	userRole, _ := middleware.GetUserRole(r.Context())
+	if userRole != models.RoleAdmin && userRole != models.RoleGameMaster {
+		http.Error(w, "Only admins and game masters can use campaign templates", http.StatusForbidden)
+		return
+	}
+
+	query := `
+		SELECT id, name, description, created_by_user_id, source_campaign_id,
+		       jsonb_array_length(bundle->'challenges') as challenge_count,
+		       jsonb_array_length(bundle->'characters') as character_count,
+		       created_at
+		FROM campaign_templates
+		ORDER BY name ASC
+	`
+
+	var templates []models.CampaignTemplate
+	err := h.db.Select(&templates, query)
+	if err != nil {
+		log.Printf("Error fetching templates: %v", err)
+		http.Error(w, "Error fetching templates", http.StatusInternalServerError)
+		return
+	}
+
+	if templates == nil {
+		templates = []models.CampaignTemplate{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// InstantiateTemplate creates a new campaign from a template, with the caller as GM
+func (h *CampaignHandler) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userRole, _ := middleware.GetUserRole(r.Context())
+	if userRole != models.RoleAdmin && userRole != models.RoleGameMaster {
+		http.Error(w, "Only admins and game masters can create campaigns", http.StatusForbidden)
+		return
+	}
+
+	templateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.InstantiateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var data []byte
+	err = h.db.Get(&data, "SELECT bundle FROM campaign_templates WHERE id = $1", templateID)
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	var b models.CampaignBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		log.Printf("Error decoding template %d: %v", templateID, err)
+		http.Error(w, "Template is corrupt", http.StatusInternalServerError)
+		return
+	}
+
+	report, err := bundle.Import(h.db, &b, bundle.ImportOptions{GMUserID: userID, Name: req.Name})
+	if err != nil {
+		log.Printf("Error instantiating template %d: %v", templateID, err)
+		http.Error(w, "Error creating campaign from template", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeImportedCampaign(w, report)
+}
+
+// DeleteTemplate removes a template (its creator or an admin only)
+func (h *CampaignHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	templateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return
+	}
+
+	userRole, _ := middleware.GetUserRole(r.Context())
+	var createdBy *int
+	err = h.db.Get(&createdBy, "SELECT created_by_user_id FROM campaign_templates WHERE id = $1", templateID)
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	if (createdBy == nil || *createdBy != userID) && userRole != models.RoleAdmin {
+		http.Error(w, "Only the template's creator or an admin can delete it", http.StatusForbidden)
+		return
+	}
+
+	_, err = h.db.Exec("DELETE FROM campaign_templates WHERE id = $1", templateID)
+	if err != nil {
+		http.Error(w, "Error deleting template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Template deleted successfully"})
+}
+
+// writeImportedCampaign responds with the campaign an import created, or the conflicts that stopped it
+func (h *CampaignHandler) writeImportedCampaign(w http.ResponseWriter, report *models.BundleImportReport) {
+	if len(report.Conflicts) > 0 || report.CampaignID == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	var campaign models.Campaign
+	query := `SELECT id, name, gm_user_id, current_day, time_phase, settings, created_at FROM campaigns WHERE id = $1`
+	err := h.db.Get(&campaign, query, *report.CampaignID)
+	if err != nil {
+		http.Error(w, "Error fetching new campaign", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(campaign)
+}
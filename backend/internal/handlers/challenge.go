@@ -5,12 +5,14 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/SamPCunningham/sleeper-system/internal/database"
 	"github.com/SamPCunningham/sleeper-system/internal/middleware"
 	"github.com/SamPCunningham/sleeper-system/internal/models"
 	"github.com/SamPCunningham/sleeper-system/internal/websocket"
 	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
 )
 
 type ChallengeHandler struct {
@@ -18,10 +20,41 @@ type ChallengeHandler struct {
 	hub *websocket.Hub
 }
 
+// challengeColumns lists the challenge fields in models.Challenge order
+const challengeColumns = "id, campaign_id, created_by_user_id, description, difficulty_modifier, is_group_challenge, is_active, status, starts_on_day, ends_after_day, session_id, scene_id, progress_clock, template_id, max_attempts_per_character, is_hidden, success_text, neutral_text, failure_text, consequence_tables, created_at"
+
+// challengeColumnsCh is challengeColumns qualified with the ch alias, for queries that join other tables
+const challengeColumnsCh = `
+	ch.id, ch.campaign_id, ch.created_by_user_id, ch.description,
+	ch.difficulty_modifier, ch.is_group_challenge,
+	ch.is_active, ch.status, ch.starts_on_day, ch.ends_after_day,
+	ch.session_id, ch.scene_id, ch.progress_clock, ch.template_id,
+	ch.max_attempts_per_character, ch.is_hidden,
+	ch.success_text, ch.neutral_text, ch.failure_text, ch.consequence_tables, ch.created_at`
+
+// challengeStatsColumns aggregates the rolls joined as rh into models.ChallengeStats
+const challengeStatsColumns = `
+	COUNT(rh.id) as total_attempts,
+	COUNT(rh.id) FILTER (WHERE rh.outcome = 'success') as successful_attempts,
+	COUNT(rh.id) FILTER (WHERE rh.outcome = 'neutral') as neutral_attempts,
+	COUNT(rh.id) FILTER (WHERE rh.outcome = 'failure') as failed_attempts,
+	ROUND(AVG(rh.modified_d6), 2)::float8 as average_modified_d6,
+	ROUND(AVG(rh.skill_applied::int), 2)::float8 as skill_usage_rate`
+
+// challengeRollsJoin joins the rolls made against challenge ch that the user in $2 may see
+const challengeRollsJoin = `
+	LEFT JOIN roll_history rh ON rh.challenge_id = ch.id AND rh.character_id IN (
+		SELECT c.id FROM characters c JOIN campaigns ca ON c.campaign_id = ca.id
+		WHERE c.campaign_id = ch.campaign_id AND ` + visibleToUser + `
+	)`
+
 func NewChallengeHandler(db *database.Database, hub *websocket.Hub) *ChallengeHandler {
 	return &ChallengeHandler{db: db, hub: hub}
 }
 
+// ListByCampaign returns a campaign's challenges with their roll stats.
+// ?status=active (default), scheduled, completed, expired or all picks which
+// challenges are listed.
 func (h *ChallengeHandler) ListByCampaign(w http.ResponseWriter, r *http.Request) {
 	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
 	if err != nil {
@@ -29,23 +62,31 @@ func (h *ChallengeHandler) ListByCampaign(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ChallengeStatusActive
+	}
+	switch status {
+	case models.ChallengeStatusScheduled, models.ChallengeStatusActive, models.ChallengeStatusCompleted,
+		models.ChallengeStatusExpired, models.ChallengeStatusAll:
+	default:
+		http.Error(w, "status must be 'active', 'scheduled', 'completed', 'expired' or 'all'", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
 	query := `
-		SELECT 
-			ch.id, ch.campaign_id, ch.created_by_user_id, ch.description, 
-			ch.difficulty_modifier, ch.is_group_challenge,
-			ch.is_active, ch.created_at,
-			COUNT(rh.id) as total_attempts,
-			COUNT(CASE WHEN rh.success = true THEN 1 END) as successful_attempts,
-			COUNT(CASE WHEN rh.success = false THEN 1 END) as failed_attempts
-		FROM challenges ch
-		LEFT JOIN roll_history rh ON ch.id = rh.challenge_id
-		WHERE ch.campaign_id = $1 AND ch.is_active = true
+		SELECT ` + challengeColumnsCh + `,` + challengeStatsColumns + `
+		FROM challenges ch` + challengeRollsJoin + `
+		WHERE ch.campaign_id = $1
+		  AND ($3 = 'all' OR ch.status = $3)
+		  AND ` + challengeVisibleToUser + `
 		GROUP BY ch.id
 		ORDER BY ch.created_at DESC
 	`
 
 	var challenges []models.ChallengeWithStats
-	err = h.db.Select(&challenges, query, campaignID)
+	err = h.db.Select(&challenges, query, campaignID, userID, status)
 	if err != nil {
 		log.Printf("Error fetching challenges: %v", err)
 		http.Error(w, "Error fetching challenges", http.StatusInternalServerError)
@@ -56,10 +97,105 @@ func (h *ChallengeHandler) ListByCampaign(w http.ResponseWriter, r *http.Request
 		challenges = []models.ChallengeWithStats{}
 	}
 
+	ptrs := make([]*models.Challenge, len(challenges))
+	for i := range challenges {
+		ptrs[i] = &challenges[i].Challenge
+	}
+	if err := attachChallengeTargets(h.db, ptrs...); err != nil {
+		log.Printf("Error fetching challenge targets: %v", err)
+		http.Error(w, "Error fetching challenges", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(challenges)
 }
 
+// Get returns a challenge with its stats, a per-character breakdown and the timeline of attempts
+func (h *ChallengeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	challengeID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+		return
+	}
+
+	var detail models.ChallengeDetail
+	query := `
+		SELECT ` + challengeColumnsCh + `,` + challengeStatsColumns + `
+		FROM challenges ch` + challengeRollsJoin + `
+		WHERE ch.id = $1 AND ` + challengeVisibleToUser + `
+		GROUP BY ch.id
+	`
+	err = h.db.Get(&detail.ChallengeWithStats, query, challengeID, userID)
+	if err != nil {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	if err := attachChallengeTargets(h.db, &detail.Challenge); err != nil {
+		log.Printf("Error fetching challenge targets: %v", err)
+		http.Error(w, "Error fetching challenge", http.StatusInternalServerError)
+		return
+	}
+
+	characterQuery := `
+		SELECT rh.character_id, c.name as character_name,` + challengeStatsColumns + `
+		FROM roll_history rh
+		JOIN characters c ON rh.character_id = c.id
+		JOIN campaigns ca ON c.campaign_id = ca.id
+		WHERE rh.challenge_id = $1 AND ` + rollVisibleToUser + `
+		GROUP BY rh.character_id, c.name
+		ORDER BY c.name ASC, rh.character_id ASC
+	`
+	err = h.db.Select(&detail.Characters, characterQuery, challengeID, userID)
+	if err != nil {
+		log.Printf("Error fetching challenge character stats: %v", err)
+		http.Error(w, "Error fetching challenge stats", http.StatusInternalServerError)
+		return
+	}
+
+	attemptQuery := `
+		SELECT
+			rh.id, rh.character_id, rh.pool_dice_id, rh.d20_roll,
+			rh.action_type, rh.success, rh.notes, rh.created_at,
+			rh.challenge_id, rh.skill_applied, rh.other_modifiers, rh.modified_d6,
+			rh.outcome, rh.session_id, rh.scene_id, rh.campaign_day, rh.character_version, rh.consequence,
+			c.name as character_name
+		FROM roll_history rh
+		JOIN characters c ON rh.character_id = c.id
+		JOIN campaigns ca ON c.campaign_id = ca.id
+		WHERE rh.challenge_id = $1 AND ` + rollVisibleToUser + `
+		ORDER BY rh.created_at ASC, rh.id ASC
+	`
+	err = h.db.Select(&detail.Attempts, attemptQuery, challengeID, userID)
+	if err != nil {
+		log.Printf("Error fetching challenge attempts: %v", err)
+		http.Error(w, "Error fetching challenge attempts", http.StatusInternalServerError)
+		return
+	}
+	if err := attachRollDetails(h.db, detail.Attempts); err != nil {
+		log.Printf("Error fetching challenge attempt details: %v", err)
+		http.Error(w, "Error fetching challenge attempts", http.StatusInternalServerError)
+		return
+	}
+
+	if detail.Characters == nil {
+		detail.Characters = []models.ChallengeCharacterStats{}
+	}
+	if detail.Attempts == nil {
+		detail.Attempts = []models.RollHistoryWithCharacter{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
 func (h *ChallengeHandler) Create(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
@@ -81,23 +217,87 @@ func (h *ChallengeHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var challenge models.Challenge
-	query := `
-		INSERT INTO challenges (campaign_id, created_by_user_id, description, difficulty_modifier, is_group_challenge)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, campaign_id, created_by_user_id, description, difficulty_modifier, is_group_challenge, is_active, created_at
-	`
-	err = h.db.QueryRowx(query, req.CampaignID, userID, req.Description, req.DifficultyModifier, req.IsGroupChallenge).StructScan(&challenge)
+	var clock *models.ProgressClock
+	if req.ProgressClock != nil {
+		var msg string
+		if clock, msg = newProgressClock(req.ProgressClock); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.createChallenge(w, userID, &req, clock, nil)
+}
+
+// createChallenge validates a new challenge's targeting, creates it, tells
+// whoever can see it and writes the response
+func (h *ChallengeHandler) createChallenge(w http.ResponseWriter, userID int, req *models.CreateChallengeRequest, clock *models.ProgressClock, templateID *int) {
+	if msg := validateConsequenceTables(req.ConsequenceTables); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if msg := validateChallengeSchedule(&req.ChallengeSchedule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if req.SceneID != nil {
+		inCampaign, err := sceneInCampaign(h.db, *req.SceneID, req.CampaignID)
+		if err != nil {
+			log.Printf("Error checking scene: %v", err)
+			http.Error(w, "Error creating challenge", http.StatusInternalServerError)
+			return
+		}
+		if !inCampaign {
+			http.Error(w, "Scene not found in this campaign", http.StatusBadRequest)
+			return
+		}
+	}
+
+	msg, err := validateChallengeTargeting(h.db, req.CampaignID, &req.ChallengeTargeting)
 	if err != nil {
+		log.Printf("Error validating challenge targets: %v", err)
 		http.Error(w, "Error creating challenge", http.StatusInternalServerError)
 		return
 	}
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
 
-	// Broadcast new challenge to campaign
-	h.hub.BroadcastToCampaign(req.CampaignID, websocket.MessageTypeChallengeUpdate, map[string]any{
-		"action":    "created",
-		"challenge": challenge,
-	})
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating challenge", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	challenge, err := insertChallenge(tx, userID, req, clock, templateID)
+	if err == nil {
+		err = setChallengeTargeting(tx, challenge.ID, &req.ChallengeTargeting)
+	}
+	if err == nil {
+		err = tx.Get(challenge, "SELECT "+challengeColumns+" FROM challenges WHERE id = $1", challenge.ID)
+	}
+	if err == nil {
+		_, err = scheduleChallenge(tx, challenge)
+	}
+	if err != nil {
+		log.Printf("Error creating challenge: %v", err)
+		http.Error(w, "Error creating challenge", http.StatusInternalServerError)
+		return
+	}
+	if challenge.Status == models.ChallengeStatusExpired {
+		http.Error(w, "ends_after_day has already passed", http.StatusBadRequest)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating challenge", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast new challenge to whoever it's aimed at; only the GM hears about scheduled ones
+	broadcastChallenge(h.db, h.hub, challenge, "created")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -137,10 +337,9 @@ func (h *ChallengeHandler) Complete(w http.ResponseWriter, r *http.Request) {
 	var challenge models.Challenge
 	updateQuery := `
 		UPDATE challenges
-		SET is_active = false
+		SET status = 'completed'
 		WHERE id = $1
-		RETURNING id, campaign_id, created_by_user_id, description, difficulty_modifier, is_group_challenge, is_active, created_at
-	`
+		RETURNING ` + challengeColumns
 	err = h.db.QueryRowx(updateQuery, challengeID).StructScan(&challenge)
 	if err != nil {
 		http.Error(w, "Error completing challenge", http.StatusInternalServerError)
@@ -148,11 +347,362 @@ func (h *ChallengeHandler) Complete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast challenge completion
-	h.hub.BroadcastToCampaign(campaignInfo.CampaignID, websocket.MessageTypeChallengeUpdate, map[string]any{
-		"action":    "completed",
-		"challenge": challenge,
-	})
+	broadcastChallenge(h.db, h.hub, &challenge, "completed")
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(challenge)
 }
+
+// loadChallengeForGM fetches a challenge for a GM-only action, writing the
+// error response and returning false if it's missing or the user isn't the GM
+func (h *ChallengeHandler) loadChallengeForGM(w http.ResponseWriter, r *http.Request, action string) (*models.Challenge, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	challengeID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var challenge models.Challenge
+	err = h.db.Get(&challenge, "SELECT "+challengeColumns+" FROM challenges WHERE id = $1", challengeID)
+	if err != nil {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", challenge.CampaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can "+action+" challenges", http.StatusForbidden)
+		return nil, false
+	}
+
+	return &challenge, true
+}
+
+// insertChallenge creates a challenge, attaching it to the campaign's open
+// session and, unless a scene is given, its active scene if there are any
+func insertChallenge(q sqlx.Queryer, userID int, req *models.CreateChallengeRequest, clock *models.ProgressClock, templateID *int) (*models.Challenge, error) {
+	var challenge models.Challenge
+	query := `
+		INSERT INTO challenges (
+			campaign_id, created_by_user_id, description, difficulty_modifier, is_group_challenge,
+			progress_clock, template_id, success_text, neutral_text, failure_text, consequence_tables,
+			starts_on_day, ends_after_day, session_id, scene_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+		        (SELECT id FROM game_sessions WHERE campaign_id = $1 AND ended_at IS NULL),
+		        COALESCE($14, (SELECT id FROM scenes WHERE campaign_id = $1 AND is_active)))
+		RETURNING ` + challengeColumns
+	err := q.QueryRowx(query, req.CampaignID, userID, req.Description, req.DifficultyModifier, req.IsGroupChallenge,
+		clock, templateID, req.SuccessText, req.NeutralText, req.FailureText, req.ConsequenceTables,
+		req.StartsOnDay, req.EndsAfterDay, req.SceneID).StructScan(&challenge)
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// Update edits a challenge's description, difficulty, group flag, progress
+// clock, consequences, schedule or scene (GM only)
+func (h *ChallengeHandler) Update(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.loadChallengeForGM(w, r, "edit")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Description != nil {
+		trimmed := strings.TrimSpace(*req.Description)
+		if trimmed == "" {
+			http.Error(w, "Description cannot be empty", http.StatusBadRequest)
+			return
+		}
+		req.Description = &trimmed
+	}
+
+	if msg := validateConsequenceTables(req.ConsequenceTables); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	// Check the schedule as it will be once the given days are applied
+	schedule := challenge.ChallengeSchedule
+	if req.ClearSchedule {
+		schedule = models.ChallengeSchedule{}
+	}
+	if req.StartsOnDay != nil {
+		schedule.StartsOnDay = req.StartsOnDay
+	}
+	if req.EndsAfterDay != nil {
+		schedule.EndsAfterDay = req.EndsAfterDay
+	}
+	if msg := validateChallengeSchedule(&schedule); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if req.SceneID != nil && !req.RemoveScene {
+		inCampaign, err := sceneInCampaign(h.db, *req.SceneID, challenge.CampaignID)
+		if err != nil {
+			log.Printf("Error checking scene: %v", err)
+			http.Error(w, "Error updating challenge", http.StatusInternalServerError)
+			return
+		}
+		if !inCampaign {
+			http.Error(w, "Scene not found in this campaign", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var clock *models.ProgressClock
+	if req.ProgressClock != nil {
+		var msg string
+		if clock, msg = newProgressClock(req.ProgressClock); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error updating challenge", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE challenges
+		SET description = COALESCE($1, description),
+		    difficulty_modifier = COALESCE($2, difficulty_modifier),
+		    is_group_challenge = COALESCE($3, is_group_challenge),
+		    progress_clock = CASE WHEN $4 THEN NULL ELSE COALESCE($5, progress_clock) END,
+		    success_text = COALESCE($6, success_text),
+		    neutral_text = COALESCE($7, neutral_text),
+		    failure_text = COALESCE($8, failure_text),
+		    consequence_tables = COALESCE($9, consequence_tables),
+		    starts_on_day = $10,
+		    ends_after_day = $11,
+		    scene_id = CASE WHEN $12 THEN NULL ELSE COALESCE($13, scene_id) END
+		WHERE id = $14
+		RETURNING ` + challengeColumns
+	err = tx.QueryRowx(query, req.Description, req.DifficultyModifier, req.IsGroupChallenge,
+		req.RemoveProgressClock, clock, req.SuccessText, req.NeutralText, req.FailureText, req.ConsequenceTables,
+		schedule.StartsOnDay, schedule.EndsAfterDay, req.RemoveScene, req.SceneID, challenge.ID).StructScan(challenge)
+	if err != nil {
+		log.Printf("Error updating challenge: %v", err)
+		http.Error(w, "Error updating challenge", http.StatusInternalServerError)
+		return
+	}
+
+	// A new schedule may start, hide or expire the challenge on today's date
+	rescheduled, err := scheduleChallenge(tx, challenge)
+	if err != nil {
+		log.Printf("Error scheduling challenge: %v", err)
+		http.Error(w, "Error updating challenge", http.StatusInternalServerError)
+		return
+	}
+
+	// New targets may already have been reached by earlier rolls
+	action := "updated"
+	tallied, completed, err := tallyProgressClock(tx, challenge.ID)
+	if err != nil {
+		log.Printf("Error tallying progress clock: %v", err)
+		http.Error(w, "Error updating challenge", http.StatusInternalServerError)
+		return
+	}
+	if tallied != nil {
+		challenge = tallied
+	}
+	if completed {
+		action = "completed"
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating challenge", http.StatusInternalServerError)
+		return
+	}
+
+	if rescheduled && !completed {
+		broadcastRescheduled(h.db, h.hub, challenge)
+	} else {
+		broadcastChallenge(h.db, h.hub, challenge, action)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// Reopen makes a completed or expired challenge active again, or scheduled if
+// its start day is still to come (GM only)
+func (h *ChallengeHandler) Reopen(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.loadChallengeForGM(w, r, "reopen")
+	if !ok {
+		return
+	}
+
+	switch challenge.Status {
+	case models.ChallengeStatusActive:
+		http.Error(w, "Challenge is already active", http.StatusConflict)
+		return
+	case models.ChallengeStatusScheduled:
+		http.Error(w, "Challenge is scheduled and hasn't started yet", http.StatusConflict)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error reopening challenge", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE challenges
+		SET status = 'active'
+		WHERE id = $1
+		RETURNING ` + challengeColumns
+	err = tx.QueryRowx(query, challenge.ID).StructScan(challenge)
+	if err != nil {
+		log.Printf("Error reopening challenge: %v", err)
+		http.Error(w, "Error reopening challenge", http.StatusInternalServerError)
+		return
+	}
+
+	// A challenge whose end day has passed would expire again straight away
+	if _, err := scheduleChallenge(tx, challenge); err != nil {
+		log.Printf("Error scheduling challenge: %v", err)
+		http.Error(w, "Error reopening challenge", http.StatusInternalServerError)
+		return
+	}
+	if challenge.Status == models.ChallengeStatusExpired {
+		http.Error(w, "The challenge's end day has passed; move its ends_after_day before reopening", http.StatusConflict)
+		return
+	}
+
+	// A clock that has already reached a target would complete it again straight away
+	tallied, completed, err := tallyProgressClock(tx, challenge.ID)
+	if err != nil {
+		log.Printf("Error tallying progress clock: %v", err)
+		http.Error(w, "Error reopening challenge", http.StatusInternalServerError)
+		return
+	}
+	if completed {
+		http.Error(w, "The progress clock has already reached a target; raise its targets before reopening", http.StatusConflict)
+		return
+	}
+	if tallied != nil {
+		challenge = tallied
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error reopening challenge", http.StatusInternalServerError)
+		return
+	}
+
+	if challenge.Status == models.ChallengeStatusScheduled {
+		broadcastRescheduled(h.db, h.hub, challenge)
+	} else {
+		broadcastChallenge(h.db, h.hub, challenge, "reopened")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// Delete removes a challenge (GM only). Rolls made against it are kept in
+// the roll history, just no longer linked to a challenge. Unlinking would
+// show everyone the rolls against a hidden challenge, so those are deleted
+// along with it and need ?force=true.
+func (h *ChallengeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.loadChallengeForGM(w, r, "delete")
+	if !ok {
+		return
+	}
+
+	deleteRolls := challenge.IsHidden
+	if deleteRolls && r.URL.Query().Get("force") != "true" {
+		var hasRolls bool
+		err := h.db.Get(&hasRolls, "SELECT EXISTS(SELECT 1 FROM roll_history WHERE challenge_id = $1)", challenge.ID)
+		if err != nil {
+			http.Error(w, "Error checking roll history", http.StatusInternalServerError)
+			return
+		}
+		if hasRolls {
+			http.Error(w, "Hidden challenge has rolls against it; delete with force=true to delete them too", http.StatusConflict)
+			return
+		}
+	}
+
+	// Work out who to tell before the targets are deleted along with the challenge
+	if err := attachChallengeTargets(h.db, challenge); err != nil {
+		http.Error(w, "Error deleting challenge", http.StatusInternalServerError)
+		return
+	}
+	recipients, everyone, err := challengeRecipients(h.db, challenge)
+	if err != nil {
+		http.Error(w, "Error deleting challenge", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error deleting challenge", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	rollQuery := "UPDATE roll_history SET challenge_id = NULL WHERE challenge_id = $1"
+	if deleteRolls {
+		rollQuery = "DELETE FROM roll_history WHERE challenge_id = $1"
+	}
+	result, err := tx.Exec(rollQuery, challenge.ID)
+	if err != nil {
+		log.Printf("Error detaching challenge rolls: %v", err)
+		http.Error(w, "Error deleting challenge", http.StatusInternalServerError)
+		return
+	}
+	affected, _ := result.RowsAffected()
+
+	_, err = tx.Exec("DELETE FROM challenges WHERE id = $1", challenge.ID)
+	if err != nil {
+		log.Printf("Error deleting challenge: %v", err)
+		http.Error(w, "Error deleting challenge", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error deleting challenge", http.StatusInternalServerError)
+		return
+	}
+
+	payload := map[string]any{
+		"action":    "deleted",
+		"challenge": challenge,
+	}
+	if everyone {
+		h.hub.BroadcastToCampaign(challenge.CampaignID, websocket.MessageTypeChallengeUpdate, payload)
+	} else {
+		h.hub.BroadcastToUsers(challenge.CampaignID, recipients, websocket.MessageTypeChallengeUpdate, payload)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	rollsKey := "detached_rolls"
+	if deleteRolls {
+		rollsKey = "deleted_rolls"
+	}
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": "Challenge deleted successfully",
+		rollsKey:  affected,
+	})
+}
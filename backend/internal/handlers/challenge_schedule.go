@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/jmoiron/sqlx"
+)
+
+// challengeScheduledStatus is the status a scheduled, active or expired
+// challenge ch should have on the campaign day in $2
+const challengeScheduledStatus = `CASE
+	WHEN ch.ends_after_day < $2 THEN 'expired'
+	WHEN ch.starts_on_day > $2 THEN 'scheduled'
+	ELSE 'active'
+END`
+
+// validateChallengeSchedule returns a client-facing message if the schedule is unusable
+func validateChallengeSchedule(s *models.ChallengeSchedule) string {
+	if s.StartsOnDay != nil && *s.StartsOnDay < 1 {
+		return "starts_on_day must be at least 1"
+	}
+	if s.EndsAfterDay != nil && *s.EndsAfterDay < 1 {
+		return "ends_after_day must be at least 1"
+	}
+	if s.StartsOnDay != nil && s.EndsAfterDay != nil && *s.EndsAfterDay < *s.StartsOnDay {
+		return "ends_after_day can't be before starts_on_day"
+	}
+	return ""
+}
+
+// rescheduleChallenges moves a campaign's scheduled, active and expired
+// challenges to the status their schedule gives them on day. Completed
+// challenges are left alone. If challengeID is given only that challenge is
+// considered. It returns the challenges whose status changed.
+func rescheduleChallenges(tx *sqlx.Tx, campaignID, day int, challengeID *int) ([]models.Challenge, error) {
+	var changed []models.Challenge
+	err := tx.Select(&changed, `
+		UPDATE challenges ch
+		SET status = `+challengeScheduledStatus+`
+		WHERE ch.campaign_id = $1
+		  AND ($3::int IS NULL OR ch.id = $3)
+		  AND ch.status IN ('scheduled', 'active', 'expired')
+		  AND ch.status <> `+challengeScheduledStatus+`
+		RETURNING `+challengeColumns, campaignID, day, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("error rescheduling challenges: %w", err)
+	}
+	return changed, nil
+}
+
+// scheduleChallenge applies a challenge's schedule against its campaign's
+// current day, updating c in place. It returns true if the status changed.
+func scheduleChallenge(tx *sqlx.Tx, c *models.Challenge) (bool, error) {
+	var day int
+	if err := tx.Get(&day, "SELECT current_day FROM campaigns WHERE id = $1", c.CampaignID); err != nil {
+		return false, fmt.Errorf("error fetching campaign day: %w", err)
+	}
+	changed, err := rescheduleChallenges(tx, c.CampaignID, day, &c.ID)
+	if err != nil || len(changed) == 0 {
+		return false, err
+	}
+	*c = changed[0]
+	return true, nil
+}
+
+// broadcastRescheduled tells players about a challenge its schedule has just
+// moved. One that went back to scheduled disappears for players, so everyone
+// who could see it while it was active is told it was removed.
+func broadcastRescheduled(db *database.Database, hub *websocket.Hub, c *models.Challenge) {
+	switch c.Status {
+	case models.ChallengeStatusActive:
+		broadcastChallenge(db, hub, c, "started")
+	case models.ChallengeStatusExpired:
+		broadcastChallenge(db, hub, c, "expired")
+	case models.ChallengeStatusScheduled:
+		visible := *c
+		visible.Status = models.ChallengeStatusActive
+		if err := attachChallengeTargets(db, &visible); err != nil {
+			log.Printf("Error fetching challenge targets: %v", err)
+			return
+		}
+		previous, everyone, err := challengeRecipients(db, &visible)
+		if err != nil {
+			log.Printf("Error fetching challenge recipients: %v", err)
+			return
+		}
+		broadcastChallengeChange(db, hub, c, "scheduled", previous, everyone)
+	}
+}
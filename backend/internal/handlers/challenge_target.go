@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// challengeVisibleToUser filters challenges ch down to what the user in $2 may
+// see: scheduled challenges only show up for the GM, and hidden ones for the GM
+// and targeted characters' players
+const challengeVisibleToUser = `(
+	EXISTS(SELECT 1 FROM campaigns ca WHERE ca.id = ch.campaign_id AND ca.gm_user_id = $2)
+	OR (ch.status <> 'scheduled' AND (
+		NOT ch.is_hidden
+		OR EXISTS(
+			SELECT 1 FROM challenge_targets tgt
+			JOIN characters tc ON tgt.character_id = tc.id
+			WHERE tgt.challenge_id = ch.id AND tc.user_id = $2
+		)
+	))
+)`
+
+// rollVisibleToUser filters rolls rh by characters c in campaigns ca down to
+// what the user in $2 may see: both the character and any challenge the roll
+// was made against must be visible to them
+const rollVisibleToUser = `(` + visibleToUser + ` AND (
+	rh.challenge_id IS NULL
+	OR EXISTS(SELECT 1 FROM challenges ch WHERE ch.id = rh.challenge_id AND ` + challengeVisibleToUser + `)
+))`
+
+// loadChallengeTargets returns the targeted character IDs of each given challenge keyed by challenge ID
+func loadChallengeTargets(q sqlx.Queryer, challengeIDs []int) (map[int][]int, error) {
+	byChallenge := make(map[int][]int, len(challengeIDs))
+	if len(challengeIDs) == 0 {
+		return byChallenge, nil
+	}
+
+	var targets []struct {
+		ChallengeID int `db:"challenge_id"`
+		CharacterID int `db:"character_id"`
+	}
+	err := sqlx.Select(q, &targets, `
+		SELECT challenge_id, character_id
+		FROM challenge_targets
+		WHERE challenge_id = ANY($1)
+		ORDER BY challenge_id ASC, character_id ASC
+	`, pq.Array(challengeIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range targets {
+		byChallenge[t.ChallengeID] = append(byChallenge[t.ChallengeID], t.CharacterID)
+	}
+	return byChallenge, nil
+}
+
+// attachChallengeTargets fills in TargetCharacterIDs on each challenge
+func attachChallengeTargets(q sqlx.Queryer, challenges ...*models.Challenge) error {
+	ids := make([]int, len(challenges))
+	for i, c := range challenges {
+		ids[i] = c.ID
+	}
+	targets, err := loadChallengeTargets(q, ids)
+	if err != nil {
+		return err
+	}
+	for _, c := range challenges {
+		c.TargetCharacterIDs = targets[c.ID]
+		if c.TargetCharacterIDs == nil {
+			c.TargetCharacterIDs = []int{}
+		}
+	}
+	return nil
+}
+
+// validateChallengeTargeting returns a client-facing message if the targeting can't be used in the campaign
+func validateChallengeTargeting(q sqlx.Queryer, campaignID int, t *models.ChallengeTargeting) (string, error) {
+	if t.MaxAttemptsPerCharacter != nil && *t.MaxAttemptsPerCharacter < 1 {
+		return "max_attempts_per_character must be at least 1", nil
+	}
+	if t.IsHidden && len(t.TargetCharacterIDs) == 0 {
+		return "Only challenges with targets can be hidden", nil
+	}
+	if len(t.TargetCharacterIDs) == 0 {
+		return "", nil
+	}
+
+	ids := uniqueInts(t.TargetCharacterIDs)
+	if len(ids) != len(t.TargetCharacterIDs) {
+		return "Each character can only be targeted once", nil
+	}
+
+	var count int
+	err := sqlx.Get(q, &count, "SELECT COUNT(*) FROM characters WHERE id = ANY($1) AND campaign_id = $2",
+		pq.Array(ids), campaignID)
+	if err != nil {
+		return "", err
+	}
+	if count != len(ids) {
+		return "Targeted characters must belong to the campaign", nil
+	}
+	return "", nil
+}
+
+// setChallengeTargeting replaces a challenge's targets, attempt limit and hidden flag
+func setChallengeTargeting(tx *sqlx.Tx, challengeID int, t *models.ChallengeTargeting) error {
+	_, err := tx.Exec("UPDATE challenges SET max_attempts_per_character = $1, is_hidden = $2 WHERE id = $3",
+		t.MaxAttemptsPerCharacter, t.IsHidden, challengeID)
+	if err != nil {
+		return fmt.Errorf("error updating challenge targeting: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM challenge_targets WHERE challenge_id = $1", challengeID); err != nil {
+		return fmt.Errorf("error clearing challenge targets: %w", err)
+	}
+	if len(t.TargetCharacterIDs) > 0 {
+		_, err = tx.Exec(`
+			INSERT INTO challenge_targets (challenge_id, character_id)
+			SELECT $1, unnest($2::int[])
+		`, challengeID, pq.Array(t.TargetCharacterIDs))
+		if err != nil {
+			return fmt.Errorf("error adding challenge targets: %w", err)
+		}
+	}
+	return nil
+}
+
+// challengeRecipients returns the users who should hear about a targeted
+// challenge: the GM and the players of targeted characters, or only the GM
+// while it's scheduled. everyone is true when the challenge is open to the
+// whole campaign.
+func challengeRecipients(q sqlx.Queryer, c *models.Challenge) (userIDs []int, everyone bool, err error) {
+	if c.Status == models.ChallengeStatusScheduled {
+		err = sqlx.Select(q, &userIDs, "SELECT gm_user_id FROM campaigns WHERE id = $1", c.CampaignID)
+		return userIDs, false, err
+	}
+	if len(c.TargetCharacterIDs) == 0 {
+		return nil, true, nil
+	}
+
+	err = sqlx.Select(q, &userIDs, `
+		SELECT gm_user_id FROM campaigns WHERE id = $1
+		UNION
+		SELECT user_id FROM characters WHERE id = ANY($2) AND user_id IS NOT NULL
+	`, c.CampaignID, pq.Array(c.TargetCharacterIDs))
+	return userIDs, false, err
+}
+
+// broadcastChallenge sends a challenge_update to the campaign, or only to the
+// GM and targeted players when the challenge has targets
+func broadcastChallenge(db *database.Database, hub *websocket.Hub, c *models.Challenge, action string) {
+	if err := attachChallengeTargets(db, c); err != nil {
+		log.Printf("Error fetching challenge targets: %v", err)
+		return
+	}
+
+	payload := map[string]any{
+		"action":    action,
+		"challenge": c,
+	}
+
+	userIDs, everyone, err := challengeRecipients(db, c)
+	if err != nil {
+		log.Printf("Error fetching challenge recipients: %v", err)
+		return
+	}
+	if everyone {
+		hub.BroadcastToCampaign(c.CampaignID, websocket.MessageTypeChallengeUpdate, payload)
+		return
+	}
+	hub.BroadcastToUsers(c.CampaignID, userIDs, websocket.MessageTypeChallengeUpdate, payload)
+}
+
+// broadcastChallengeChange sends a challenge_update for a challenge whose
+// recipients may just have changed. Users who could see it before (previous,
+// or the whole campaign when wasOpen) but can't any more are only told it was
+// removed, so none of its text reaches them.
+func broadcastChallengeChange(db *database.Database, hub *websocket.Hub, c *models.Challenge, action string, previous []int, wasOpen bool) {
+	if err := attachChallengeTargets(db, c); err != nil {
+		log.Printf("Error fetching challenge targets: %v", err)
+		return
+	}
+	current, everyone, err := challengeRecipients(db, c)
+	if err != nil {
+		log.Printf("Error fetching challenge recipients: %v", err)
+		return
+	}
+
+	if !everyone {
+		if wasOpen {
+			err = db.Select(&previous, `
+				SELECT gm_user_id FROM campaigns WHERE id = $1
+				UNION
+				SELECT user_id FROM campaign_members WHERE campaign_id = $1
+			`, c.CampaignID)
+			if err != nil {
+				log.Printf("Error fetching campaign members: %v", err)
+				return
+			}
+		}
+		keeps := make(map[int]bool, len(current))
+		for _, id := range current {
+			keeps[id] = true
+		}
+		var lost []int
+		for _, id := range previous {
+			if !keeps[id] {
+				lost = append(lost, id)
+			}
+		}
+		if len(lost) > 0 {
+			hub.BroadcastToUsers(c.CampaignID, lost, websocket.MessageTypeChallengeUpdate, map[string]any{
+				"action": "removed",
+				"id":     c.ID,
+			})
+		}
+	}
+
+	broadcastChallenge(db, hub, c, action)
+}
+
+// checkChallengeEligibility locks a challenge and checks a character may roll
+// against it. It returns an HTTP status and client-facing message when not.
+func checkChallengeEligibility(tx *sqlx.Tx, challengeID, characterID, campaignID int) (int, string, error) {
+	var challenge struct {
+		CampaignID              int    `db:"campaign_id"`
+		Status                  string `db:"status"`
+		MaxAttemptsPerCharacter *int   `db:"max_attempts_per_character"`
+	}
+	err := tx.Get(&challenge, "SELECT campaign_id, status, max_attempts_per_character FROM challenges WHERE id = $1 FOR UPDATE", challengeID)
+	if err != nil || challenge.CampaignID != campaignID {
+		return http.StatusBadRequest, "Challenge not found in this campaign", nil
+	}
+	switch challenge.Status {
+	case models.ChallengeStatusScheduled:
+		return http.StatusConflict, "This challenge hasn't started yet", nil
+	case models.ChallengeStatusExpired:
+		return http.StatusConflict, "This challenge has expired", nil
+	}
+
+	var eligible bool
+	err = tx.Get(&eligible, `
+		SELECT NOT EXISTS(SELECT 1 FROM challenge_targets WHERE challenge_id = $1)
+		    OR EXISTS(SELECT 1 FROM challenge_targets WHERE challenge_id = $1 AND character_id = $2)
+	`, challengeID, characterID)
+	if err != nil {
+		return 0, "", fmt.Errorf("error checking challenge targets: %w", err)
+	}
+	if !eligible {
+		return http.StatusForbidden, "This character isn't targeted by the challenge", nil
+	}
+
+	if challenge.MaxAttemptsPerCharacter != nil {
+		var attempts int
+		err = tx.Get(&attempts, "SELECT COUNT(*) FROM roll_history WHERE challenge_id = $1 AND character_id = $2",
+			challengeID, characterID)
+		if err != nil {
+			return 0, "", fmt.Errorf("error counting challenge attempts: %w", err)
+		}
+		if attempts >= *challenge.MaxAttemptsPerCharacter {
+			return http.StatusConflict, "This character has no attempts left at the challenge", nil
+		}
+	}
+	return 0, "", nil
+}
+
+// SetTargeting replaces who a challenge is aimed at, how many attempts each
+// character gets and whether it's hidden from everyone else (GM only)
+func (h *ChallengeHandler) SetTargeting(w http.ResponseWriter, r *http.Request) {
+	challenge, ok := h.loadChallengeForGM(w, r, "target")
+	if !ok {
+		return
+	}
+
+	var req models.ChallengeTargeting
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	msg, err := validateChallengeTargeting(h.db, challenge.CampaignID, &req)
+	if err != nil {
+		log.Printf("Error validating challenge targets: %v", err)
+		http.Error(w, "Error updating challenge targets", http.StatusInternalServerError)
+		return
+	}
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	// Players who lose the challenge still need to hear that it's gone
+	if err := attachChallengeTargets(h.db, challenge); err != nil {
+		http.Error(w, "Error updating challenge targets", http.StatusInternalServerError)
+		return
+	}
+	previous, wasOpen, err := challengeRecipients(h.db, challenge)
+	if err != nil {
+		http.Error(w, "Error updating challenge targets", http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error updating challenge targets", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := setChallengeTargeting(tx, challenge.ID, &req); err != nil {
+		log.Printf("Error updating challenge targets: %v", err)
+		http.Error(w, "Error updating challenge targets", http.StatusInternalServerError)
+		return
+	}
+	err = tx.Get(challenge, "SELECT "+challengeColumns+" FROM challenges WHERE id = $1", challenge.ID)
+	if err != nil {
+		http.Error(w, "Error updating challenge targets", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating challenge targets", http.StatusInternalServerError)
+		return
+	}
+
+	broadcastChallengeChange(h.db, h.hub, challenge, "updated", previous, wasOpen)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(challenge)
+}
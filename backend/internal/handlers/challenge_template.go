@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// challengeTemplateColumns lists the template fields in models.ChallengeTemplate order
+const challengeTemplateColumns = `
+	id, owner_user_id,
+	CASE WHEN owner_user_id IS NULL THEN 'instance' ELSE 'personal' END as scope,
+	name, description, difficulty_modifier, is_group_challenge, progress_clock,
+	success_text, neutral_text, failure_text, consequence_tables, created_by_user_id, created_at, updated_at`
+
+// requireTemplateUser reports whether the user may use challenge templates, writing an error if not
+func requireTemplateUser(w http.ResponseWriter, r *http.Request) (userID int, role string, ok bool) {
+	userID, ok = middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, "", false
+	}
+
+	role, _ = middleware.GetUserRole(r.Context())
+	if role != models.RoleAdmin && role != models.RoleGameMaster {
+		http.Error(w, "Only admins and game masters can use challenge templates", http.StatusForbidden)
+		return 0, "", false
+	}
+	return userID, role, true
+}
+
+// loadChallengeTemplate fetches a template the user can see. Other GMs'
+// personal templates are reported as missing.
+func (h *ChallengeHandler) loadChallengeTemplate(w http.ResponseWriter, r *http.Request, userID int) (*models.ChallengeTemplate, bool) {
+	templateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid template ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var template models.ChallengeTemplate
+	err = h.db.Get(&template, "SELECT "+challengeTemplateColumns+" FROM challenge_templates WHERE id = $1", templateID)
+	if err != nil || (template.OwnerUserID != nil && *template.OwnerUserID != userID) {
+		http.Error(w, "Challenge template not found", http.StatusNotFound)
+		return nil, false
+	}
+	return &template, true
+}
+
+// canEditChallengeTemplate: owners edit their own templates, admins edit instance-wide ones
+func canEditChallengeTemplate(t *models.ChallengeTemplate, userID int, role string) bool {
+	if t.OwnerUserID == nil {
+		return role == models.RoleAdmin
+	}
+	return *t.OwnerUserID == userID
+}
+
+// ListTemplates searches the challenge templates available to the user.
+// Filters: ?q= (name or description), ?scope=personal|instance,
+// ?is_group_challenge=true|false, ?min_difficulty= and ?max_difficulty=.
+func (h *ChallengeHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := requireTemplateUser(w, r)
+	if !ok {
+		return
+	}
+
+	params := r.URL.Query()
+	scope := params.Get("scope")
+	if scope != "" && scope != models.ChallengeTemplateScopePersonal && scope != models.ChallengeTemplateScopeInstance {
+		http.Error(w, "scope must be 'personal' or 'instance'", http.StatusBadRequest)
+		return
+	}
+
+	var isGroup *bool
+	if v := params.Get("is_group_challenge"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid is_group_challenge filter", http.StatusBadRequest)
+			return
+		}
+		isGroup = &b
+	}
+
+	var minDifficulty, maxDifficulty *int
+	for name, dst := range map[string]**int{"min_difficulty": &minDifficulty, "max_difficulty": &maxDifficulty} {
+		if v := params.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "Invalid "+name+" filter", http.StatusBadRequest)
+				return
+			}
+			*dst = &n
+		}
+	}
+
+	query := `
+		SELECT ` + challengeTemplateColumns + `
+		FROM challenge_templates
+		WHERE (owner_user_id IS NULL OR owner_user_id = $1)
+		  AND ($2 = '' OR ($2 = 'instance') = (owner_user_id IS NULL))
+		  AND ($3 = '' OR strpos(lower(name), lower($3)) > 0 OR strpos(lower(description), lower($3)) > 0)
+		  AND ($4::boolean IS NULL OR is_group_challenge = $4)
+		  AND ($5::int IS NULL OR difficulty_modifier >= $5)
+		  AND ($6::int IS NULL OR difficulty_modifier <= $6)
+		ORDER BY name ASC, id ASC
+	`
+
+	var templates []models.ChallengeTemplate
+	err := h.db.Select(&templates, query, userID, scope, strings.TrimSpace(params.Get("q")), isGroup, minDifficulty, maxDifficulty)
+	if err != nil {
+		log.Printf("Error fetching challenge templates: %v", err)
+		http.Error(w, "Error fetching challenge templates", http.StatusInternalServerError)
+		return
+	}
+
+	if templates == nil {
+		templates = []models.ChallengeTemplate{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+// GetTemplate returns a single challenge template
+func (h *ChallengeHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := requireTemplateUser(w, r)
+	if !ok {
+		return
+	}
+
+	template, ok := h.loadChallengeTemplate(w, r, userID)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// CreateTemplate adds a challenge template to the GM's library, or to the
+// instance-wide library when an admin asks for scope "instance"
+func (h *ChallengeHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := requireTemplateUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateChallengeTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Name == "" || req.Description == "" {
+		http.Error(w, "Template name and description are required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Name) > 100 {
+		http.Error(w, "Template name must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	ownerID := &userID
+	switch req.Scope {
+	case "", models.ChallengeTemplateScopePersonal:
+	case models.ChallengeTemplateScopeInstance:
+		if role != models.RoleAdmin {
+			http.Error(w, "Only admins can add instance-wide templates", http.StatusForbidden)
+			return
+		}
+		ownerID = nil
+	default:
+		http.Error(w, "scope must be 'personal' or 'instance'", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateConsequenceTables(req.ConsequenceTables); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	var clock *models.ProgressClock
+	if req.ProgressClock != nil {
+		var msg string
+		if clock, msg = newProgressClock(req.ProgressClock); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var template models.ChallengeTemplate
+	query := `
+		INSERT INTO challenge_templates (
+			owner_user_id, name, description, difficulty_modifier, is_group_challenge,
+			progress_clock, success_text, neutral_text, failure_text, consequence_tables, created_by_user_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + challengeTemplateColumns
+	err := h.db.QueryRowx(query, ownerID, req.Name, req.Description, req.DifficultyModifier, req.IsGroupChallenge,
+		clock, req.SuccessText, req.NeutralText, req.FailureText, req.ConsequenceTables, userID).StructScan(&template)
+	if err != nil {
+		log.Printf("Error creating challenge template: %v", err)
+		http.Error(w, "Error creating challenge template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(template)
+}
+
+// UpdateTemplate edits a challenge template (its owner, or an admin for instance-wide ones)
+func (h *ChallengeHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := requireTemplateUser(w, r)
+	if !ok {
+		return
+	}
+
+	template, ok := h.loadChallengeTemplate(w, r, userID)
+	if !ok {
+		return
+	}
+	if !canEditChallengeTemplate(template, userID, role) {
+		http.Error(w, "Only admins can edit instance-wide templates", http.StatusForbidden)
+		return
+	}
+
+	var req models.UpdateChallengeTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	for _, field := range []*string{req.Name, req.Description} {
+		if field == nil {
+			continue
+		}
+		*field = strings.TrimSpace(*field)
+		if *field == "" {
+			http.Error(w, "Template name and description cannot be empty", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Name != nil && len(*req.Name) > 100 {
+		http.Error(w, "Template name must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateConsequenceTables(req.ConsequenceTables); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	var clock *models.ProgressClock
+	if req.ProgressClock != nil {
+		var msg string
+		if clock, msg = newProgressClock(req.ProgressClock); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	}
+
+	query := `
+		UPDATE challenge_templates
+		SET name = COALESCE($1, name),
+		    description = COALESCE($2, description),
+		    difficulty_modifier = COALESCE($3, difficulty_modifier),
+		    is_group_challenge = COALESCE($4, is_group_challenge),
+		    progress_clock = CASE WHEN $5 THEN NULL ELSE COALESCE($6, progress_clock) END,
+		    success_text = COALESCE($7, success_text),
+		    neutral_text = COALESCE($8, neutral_text),
+		    failure_text = COALESCE($9, failure_text),
+		    consequence_tables = COALESCE($10, consequence_tables),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $11
+		RETURNING ` + challengeTemplateColumns
+	err := h.db.QueryRowx(query, req.Name, req.Description, req.DifficultyModifier, req.IsGroupChallenge,
+		req.RemoveProgressClock, clock, req.SuccessText, req.NeutralText, req.FailureText, req.ConsequenceTables,
+		template.ID).StructScan(template)
+	if err != nil {
+		log.Printf("Error updating challenge template: %v", err)
+		http.Error(w, "Error updating challenge template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// DeleteTemplate removes a challenge template. Challenges made from it are kept.
+func (h *ChallengeHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, role, ok := requireTemplateUser(w, r)
+	if !ok {
+		return
+	}
+
+	template, ok := h.loadChallengeTemplate(w, r, userID)
+	if !ok {
+		return
+	}
+	if !canEditChallengeTemplate(template, userID, role) {
+		http.Error(w, "Only admins can delete instance-wide templates", http.StatusForbidden)
+		return
+	}
+
+	_, err := h.db.Exec("DELETE FROM challenge_templates WHERE id = $1", template.ID)
+	if err != nil {
+		log.Printf("Error deleting challenge template: %v", err)
+		http.Error(w, "Error deleting challenge template", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Challenge template deleted successfully"})
+}
+
+// InstantiateTemplate creates a challenge in one of the GM's campaigns from a template
+func (h *ChallengeHandler) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, _, ok := requireTemplateUser(w, r)
+	if !ok {
+		return
+	}
+
+	template, ok := h.loadChallengeTemplate(w, r, userID)
+	if !ok {
+		return
+	}
+
+	var req models.InstantiateChallengeTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var gmUserID int
+	err := h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", req.CampaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can create challenges", http.StatusForbidden)
+		return
+	}
+
+	create := models.CreateChallengeRequest{
+		CampaignID:         req.CampaignID,
+		Description:        template.Description,
+		DifficultyModifier: template.DifficultyModifier,
+		IsGroupChallenge:   template.IsGroupChallenge,
+		Consequences:       template.Consequences,
+		ChallengeTargeting: req.ChallengeTargeting,
+		ChallengeSchedule:  req.ChallengeSchedule,
+		SceneID:            req.SceneID,
+	}
+	if req.Description != nil && strings.TrimSpace(*req.Description) != "" {
+		create.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.DifficultyModifier != nil {
+		create.DifficultyModifier = *req.DifficultyModifier
+	}
+
+	h.createChallenge(w, userID, &create, template.ProgressClock, &template.ID)
+}
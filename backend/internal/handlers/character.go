@@ -2,21 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/SamPCunningham/sleeper-system/internal/database"
 	"github.com/SamPCunningham/sleeper-system/internal/middleware"
 	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
 	"github.com/go-chi/chi/v5"
 )
 
 type CharacterHandler struct {
-	db *database.Database
+	db  *database.Database
+	hub *websocket.Hub
 }
 
-func NewCharacterHandler(db *database.Database) *CharacterHandler {
-	return &CharacterHandler{db: db}
+const characterColumns = `
+	id, campaign_id, user_id, name, description, notes, skill_name, skill_modifier, weakness_name, weakness_modifier,
+	max_daily_dice, kind, is_revealed, stat_block, status, retired_at, retired_reason, created_at
+`
+
+func NewCharacterHandler(db *database.Database, hub *websocket.Hub) *CharacterHandler {
+	return &CharacterHandler{db: db, hub: hub}
 }
 
 func (h *CharacterHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -53,32 +61,81 @@ func (h *CharacterHandler) Create(w http.ResponseWriter, r *http.Request) {
 		assignedUserID = req.AssignedUserID
 	} else {
 		// Players can only create for themselves
-		// Check if they already have a character in this campaign
-		var count int
-		err = h.db.Get(&count, "SELECT COUNT(*) FROM characters WHERE campaign_id = $1 AND user_id = $2", req.CampaignID, userID)
+		assignedUserID = &userID
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating character", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if assignedUserID != nil {
+		full, err := characterLimitReached(tx, req.CampaignID, *assignedUserID, 0)
 		if err != nil {
 			http.Error(w, "Error checking existing characters", http.StatusInternalServerError)
 			return
 		}
-		if count > 0 {
-			http.Error(w, "You already have a character in this campaign", http.StatusConflict)
+		if full {
+			message := "That player already has the maximum number of characters in this campaign"
+			if !isGM {
+				message = "You already have the maximum number of characters in this campaign"
+			}
+			http.Error(w, message, http.StatusConflict)
 			return
 		}
-		assignedUserID = &userID
 	}
 
 	var character models.Character
 	query := `
-		INSERT INTO characters (campaign_id, user_id, name, skill_name, skill_modifier, weakness_name, weakness_modifier)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, campaign_id, user_id, name, skill_name, skill_modifier, weakness_name, weakness_modifier, max_daily_dice, created_at
-	`
-	err = h.db.QueryRowx(query, req.CampaignID, assignedUserID, req.Name, req.SkillName, req.SkillModifier, req.WeaknessName, req.WeaknessModifier).StructScan(&character)
+		INSERT INTO characters (campaign_id, user_id, name, description, skill_name, skill_modifier, weakness_name, weakness_modifier, max_daily_dice)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+		        COALESCE((SELECT (settings->>'default_max_daily_dice')::int FROM campaigns WHERE id = $1), 3))
+		RETURNING ` + characterColumns
+	err = tx.QueryRowx(query, req.CampaignID, assignedUserID, req.Name, req.Description, req.SkillName, req.SkillModifier, req.WeaknessName, req.WeaknessModifier).StructScan(&character)
 	if err != nil {
 		http.Error(w, "Error creating character", http.StatusInternalServerError)
 		return
 	}
 
+	// The single skill and weakness fields become the character's first traits
+	character.Traits = []models.CharacterTrait{}
+	for _, legacy := range []struct {
+		name     *string
+		kind     string
+		modifier int
+	}{
+		{req.SkillName, models.TraitKindSkill, req.SkillModifier},
+		{req.WeaknessName, models.TraitKindWeakness, req.WeaknessModifier},
+	} {
+		if legacy.name == nil || *legacy.name == "" {
+			continue
+		}
+		var trait models.CharacterTrait
+		err = tx.QueryRowx(`
+			INSERT INTO character_traits (character_id, name, kind, modifier)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, character_id, name, kind, modifier, description, uses_per_day, created_at
+		`, character.ID, *legacy.name, legacy.kind, legacy.modifier).StructScan(&trait)
+		if err != nil {
+			http.Error(w, "Error creating character", http.StatusInternalServerError)
+			return
+		}
+		character.Traits = append(character.Traits, trait)
+	}
+
+	if _, err := recordCharacterVersion(tx, character.ID, userID, models.CharacterChangeCreated); err != nil {
+		log.Printf("Error recording character version: %v", err)
+		http.Error(w, "Error creating character", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating character", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(character)
@@ -91,15 +148,20 @@ func (h *CharacterHandler) ListByCampaign(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Retired characters are hidden unless asked for
+	includeRetired := r.URL.Query().Get("include_retired") == "true"
+
+	// NPCs only show up here once revealed; the GM's full roster is ListNPCs
 	query := `
-		SELECT id, campaign_id, user_id, name, skill_name, skill_modifier, weakness_name, weakness_modifier, max_daily_dice, created_at
+		SELECT ` + characterColumns + `
 		FROM characters
-		WHERE campaign_id = $1
+		WHERE campaign_id = $1 AND ($2::boolean OR status = 'active')
+		  AND (kind = 'pc' OR is_revealed)
 		ORDER BY created_at ASC
 	`
 
 	var characters []models.Character
-	err = h.db.Select(&characters, query, campaignID)
+	err = h.db.Select(&characters, query, campaignID, includeRetired)
 	if err != nil {
 		http.Error(w, "Error fetching characters", http.StatusInternalServerError)
 		return
@@ -110,6 +172,20 @@ func (h *CharacterHandler) ListByCampaign(w http.ResponseWriter, r *http.Request
 		characters = []models.Character{}
 	}
 
+	ids := make([]int, len(characters))
+	for i, c := range characters {
+		ids[i] = c.ID
+	}
+	traits, err := loadCharacterTraits(h.db, ids)
+	if err != nil {
+		http.Error(w, "Error fetching characters", http.StatusInternalServerError)
+		return
+	}
+	for i := range characters {
+		characters[i].Traits = traits[characters[i].ID]
+		characters[i].StatBlock = nil
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(characters)
 }
@@ -123,7 +199,7 @@ func (h *CharacterHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	var character models.Character
 	query := `
-		SELECT id, campaign_id, user_id, name, skill_name, skill_modifier, weakness_name, weakness_modifier, max_daily_dice, created_at
+		SELECT ` + characterColumns + `
 		FROM characters
 		WHERE id = $1
 	`
@@ -133,10 +209,31 @@ func (h *CharacterHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Hidden NPCs and stat blocks are for the GM only
+	userID, _ := middleware.GetUserID(r.Context())
+	var gmUserID int
+	h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", character.CampaignID)
+	if gmUserID != userID {
+		if character.Kind == models.CharacterKindNPC && !character.IsRevealed {
+			http.Error(w, "Character not found", http.StatusNotFound)
+			return
+		}
+		character.StatBlock = nil
+	}
+
+	traits, err := loadCharacterTraits(h.db, []int{characterID})
+	if err != nil {
+		http.Error(w, "Error fetching character traits", http.StatusInternalServerError)
+		return
+	}
+	character.Traits = traits[characterID]
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(character)
 }
 
+// Update changes the descriptive fields of a character (owner or GM).
+// Modifiers and dice are mechanics and go through UpdateMechanics.
 func (h *CharacterHandler) Update(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
@@ -150,33 +247,202 @@ func (h *CharacterHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user owns this character
-	var ownerID int
-	err = h.db.Get(&ownerID, "SELECT user_id FROM characters WHERE id = $1", characterID)
-	if err != nil || ownerID != userID {
+	// Owners can edit their own sheet and the GM can fix anyone's
+	var access struct {
+		OwnerID  *int `db:"user_id"`
+		GMUserID int  `db:"gm_user_id"`
+	}
+	err = h.db.Get(&access, `
+		SELECT ch.user_id, ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	isOwner := err == nil && access.OwnerID != nil && *access.OwnerID == userID
+	if err != nil || (!isOwner && access.GMUserID != userID) {
 		http.Error(w, "You don't have permission to update this character", http.StatusForbidden)
 		return
 	}
 
-	var req models.CreateCharacterRequest
+	var req models.UpdateCharacterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	if req.Name != nil && *req.Name == "" {
+		http.Error(w, "Character name is required", http.StatusBadRequest)
+		return
+	}
+
 	var character models.Character
 	query := `
 		UPDATE characters
-		SET name = $1, skill_name = $2, skill_modifier = $3, weakness_name = $4, weakness_modifier = $5
+		SET name = COALESCE($1, name),
+		    description = COALESCE($2, description),
+		    notes = COALESCE($3, notes),
+		    skill_name = COALESCE($4, skill_name),
+		    weakness_name = COALESCE($5, weakness_name)
 		WHERE id = $6
-		RETURNING id, campaign_id, user_id, name, skill_name, skill_modifier, weakness_name, weakness_modifier, max_daily_dice, created_at
-	`
-	err = h.db.QueryRowx(query, req.Name, req.SkillName, req.SkillModifier, req.WeaknessName, req.WeaknessModifier, characterID).StructScan(&character)
+		RETURNING ` + characterColumns
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error updating character", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowx(query, req.Name, req.Description, req.Notes, req.SkillName, req.WeaknessName, characterID).StructScan(&character)
 	if err != nil {
 		http.Error(w, "Error updating character", http.StatusInternalServerError)
 		return
 	}
 
+	// Rolls and sheets show trait names, so the legacy names are renamed on the
+	// character's first skill and weakness, the traits they were moved into
+	for _, legacy := range []struct {
+		kind string
+		name *string
+	}{
+		{models.TraitKindSkill, req.SkillName},
+		{models.TraitKindWeakness, req.WeaknessName},
+	} {
+		if legacy.name == nil || *legacy.name == "" {
+			continue
+		}
+		_, err := tx.Exec(`
+			UPDATE character_traits SET name = $1
+			WHERE id = (
+				SELECT id FROM character_traits
+				WHERE character_id = $2 AND kind = $3
+				ORDER BY id ASC LIMIT 1
+			)
+		`, *legacy.name, character.ID, legacy.kind)
+		if err != nil {
+			log.Printf("Error renaming character trait: %v", err)
+			http.Error(w, "Error updating character", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if _, err := recordCharacterVersion(tx, character.ID, userID, models.CharacterChangeUpdated); err != nil {
+		http.Error(w, "Error updating character", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating character", http.StatusInternalServerError)
+		return
+	}
+
+	if req.SkillName != nil || req.WeaknessName != nil {
+		traits, err := loadCharacterTraits(h.db, []int{character.ID})
+		if err != nil {
+			log.Printf("Error fetching character traits: %v", err)
+		}
+		character.Traits = traits[character.ID]
+	}
+
+	h.broadcastCharacter(&character, "updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(character)
+}
+
+// UpdateMechanics changes a character's modifiers and daily dice (GM only)
+func (h *CharacterHandler) UpdateMechanics(w http.ResponseWriter, r *http.Request) {
+	character, ok := h.loadCharacterForGM(w, r, "change the mechanics of")
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(r.Context())
+
+	var req models.UpdateCharacterMechanicsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.MaxDailyDice != nil && (*req.MaxDailyDice < 1 || *req.MaxDailyDice > 20) {
+		http.Error(w, "max_daily_dice must be between 1 and 20", http.StatusBadRequest)
+		return
+	}
+	for _, mod := range []*int{req.SkillModifier, req.WeaknessModifier} {
+		if mod != nil && (*mod < -5 || *mod > 5) {
+			http.Error(w, "Modifiers must be between -5 and 5", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Dice pools already rolled keep their size; the new value applies from the next roll
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error updating character", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Rolls only read traits, so the legacy modifiers are applied to the
+	// character's first skill and weakness, the traits they were moved into
+	for _, legacy := range []struct {
+		kind     string
+		modifier *int
+	}{
+		{models.TraitKindSkill, req.SkillModifier},
+		{models.TraitKindWeakness, req.WeaknessModifier},
+	} {
+		if legacy.modifier == nil {
+			continue
+		}
+		result, err := tx.Exec(`
+			UPDATE character_traits SET modifier = $1
+			WHERE id = (
+				SELECT id FROM character_traits
+				WHERE character_id = $2 AND kind = $3
+				ORDER BY id ASC LIMIT 1
+			)
+		`, *legacy.modifier, character.ID, legacy.kind)
+		if err != nil {
+			log.Printf("Error updating character trait: %v", err)
+			http.Error(w, "Error updating character", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			http.Error(w, "Character has no "+legacy.kind+" trait to modify; add one through the traits endpoint", http.StatusBadRequest)
+			return
+		}
+	}
+
+	query := `
+		UPDATE characters
+		SET skill_modifier = COALESCE($1, skill_modifier),
+		    weakness_modifier = COALESCE($2, weakness_modifier),
+		    max_daily_dice = COALESCE($3, max_daily_dice)
+		WHERE id = $4
+		RETURNING ` + characterColumns
+	err = tx.QueryRowx(query, req.SkillModifier, req.WeaknessModifier, req.MaxDailyDice, character.ID).StructScan(character)
+	if err == nil {
+		_, err = recordCharacterVersion(tx, character.ID, userID, models.CharacterChangeMechanics)
+	}
+	if err != nil {
+		log.Printf("Error updating character mechanics: %v", err)
+		http.Error(w, "Error updating character", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating character", http.StatusInternalServerError)
+		return
+	}
+
+	traits, err := loadCharacterTraits(h.db, []int{character.ID})
+	if err != nil {
+		log.Printf("Error fetching character traits: %v", err)
+	}
+	character.Traits = traits[character.ID]
+
+	h.broadcastCharacter(character, "mechanics_updated")
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(character)
 }
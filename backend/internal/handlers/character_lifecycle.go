@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// characterLimitReached reports whether a player already has as many active
+// characters as the campaign allows. excludeCharacterID is left out of the
+// count so a character can be checked against its own new owner. The campaign
+// row stays locked until tx ends, so concurrent creates can't both pass.
+func characterLimitReached(tx *sqlx.Tx, campaignID, userID, excludeCharacterID int) (bool, error) {
+	var settings models.CampaignSettings
+	err := tx.Get(&settings, "SELECT settings FROM campaigns WHERE id = $1 FOR UPDATE", campaignID)
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	err = tx.Get(&count, `
+		SELECT COUNT(*) FROM characters
+		WHERE campaign_id = $1 AND user_id = $2 AND status = 'active' AND id <> $3
+	`, campaignID, userID, excludeCharacterID)
+	if err != nil {
+		return false, err
+	}
+
+	return count >= settings.CharacterLimit(), nil
+}
+
+// loadCharacterForGM fetches a character and checks the user is its campaign's GM
+func (h *CharacterHandler) loadCharacterForGM(w http.ResponseWriter, r *http.Request, action string) (*models.Character, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	characterID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var character models.Character
+	query := `
+		SELECT ` + characterColumns + `
+		FROM characters
+		WHERE id = $1
+	`
+	err = h.db.Get(&character, query, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", character.CampaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can "+action+" characters", http.StatusForbidden)
+		return nil, false
+	}
+
+	return &character, true
+}
+
+// broadcastCharacter tells the campaign about a character change. Stat blocks
+// are never sent, and changes to hidden NPCs only go to the GM. Visibility is
+// taken from character itself, so this works after the row has been deleted.
+func (h *CharacterHandler) broadcastCharacter(character *models.Character, action string) {
+	public := *character
+	public.StatBlock = nil
+	payload := map[string]any{
+		"action":    action,
+		"character": public,
+	}
+	if public.Kind == models.CharacterKindNPC && !public.IsRevealed && action != "hidden" {
+		var gmUserID int
+		if err := h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", character.CampaignID); err != nil {
+			log.Printf("Error fetching campaign GM: %v", err)
+			return
+		}
+		h.hub.BroadcastToUsers(character.CampaignID, []int{gmUserID}, websocket.MessageTypeCharacterUpdate, payload)
+		return
+	}
+	h.hub.BroadcastToCampaign(character.CampaignID, websocket.MessageTypeCharacterUpdate, payload)
+}
+
+// Retire marks a character as retired, e.g. when they die (GM only).
+// Their roll history is kept but they can no longer roll new pools.
+func (h *CharacterHandler) Retire(w http.ResponseWriter, r *http.Request) {
+	character, ok := h.loadCharacterForGM(w, r, "retire")
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(r.Context())
+
+	if character.Status == models.CharacterStatusRetired {
+		http.Error(w, "Character is already retired", http.StatusConflict)
+		return
+	}
+
+	var req models.RetireCharacterRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	query := `
+		UPDATE characters
+		SET status = 'retired', retired_at = CURRENT_TIMESTAMP, retired_reason = $1
+		WHERE id = $2
+		RETURNING ` + characterColumns
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error retiring character", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowx(query, req.Reason, character.ID).StructScan(character)
+	if err == nil {
+		_, err = recordCharacterVersion(tx, character.ID, userID, models.CharacterChangeRetired)
+	}
+	if err != nil {
+		log.Printf("Error retiring character: %v", err)
+		http.Error(w, "Error retiring character", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error retiring character", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastCharacter(character, "retired")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(character)
+}
+
+// Reactivate brings a retired character back into play (GM only)
+func (h *CharacterHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	character, ok := h.loadCharacterForGM(w, r, "reactivate")
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(r.Context())
+
+	if character.Status == models.CharacterStatusActive {
+		http.Error(w, "Character is already active", http.StatusConflict)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error reactivating character", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if character.UserID != nil {
+		full, err := characterLimitReached(tx, character.CampaignID, *character.UserID, character.ID)
+		if err != nil {
+			http.Error(w, "Error checking existing characters", http.StatusInternalServerError)
+			return
+		}
+		if full {
+			http.Error(w, "That player already has the maximum number of characters in this campaign", http.StatusConflict)
+			return
+		}
+	}
+
+	query := `
+		UPDATE characters
+		SET status = 'active', retired_at = NULL, retired_reason = NULL
+		WHERE id = $1
+		RETURNING ` + characterColumns
+	err = tx.QueryRowx(query, character.ID).StructScan(character)
+	if err == nil {
+		_, err = recordCharacterVersion(tx, character.ID, userID, models.CharacterChangeReactivated)
+	}
+	if err != nil {
+		log.Printf("Error reactivating character: %v", err)
+		http.Error(w, "Error reactivating character", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error reactivating character", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastCharacter(character, "reactivated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(character)
+}
+
+// Reassign hands a character to another campaign member, or unassigns it (GM only)
+func (h *CharacterHandler) Reassign(w http.ResponseWriter, r *http.Request) {
+	character, ok := h.loadCharacterForGM(w, r, "reassign")
+	if !ok {
+		return
+	}
+	userID, _ := middleware.GetUserID(r.Context())
+
+	var req models.ReassignCharacterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.UserID != nil {
+		var isMember bool
+		err := h.db.Get(&isMember, `
+			SELECT EXISTS(
+				SELECT 1 FROM campaign_members WHERE campaign_id = $1 AND user_id = $2
+				UNION
+				SELECT 1 FROM campaigns WHERE id = $1 AND gm_user_id = $2
+			)
+		`, character.CampaignID, *req.UserID)
+		if err != nil {
+			http.Error(w, "Error checking campaign membership", http.StatusInternalServerError)
+			return
+		}
+		if !isMember {
+			http.Error(w, "Characters can only be assigned to campaign members", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error reassigning character", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Retired characters don't count towards the limit
+	if req.UserID != nil && character.Status == models.CharacterStatusActive {
+		full, err := characterLimitReached(tx, character.CampaignID, *req.UserID, character.ID)
+		if err != nil {
+			http.Error(w, "Error checking existing characters", http.StatusInternalServerError)
+			return
+		}
+		if full {
+			http.Error(w, "That player already has the maximum number of characters in this campaign", http.StatusConflict)
+			return
+		}
+	}
+
+	previousUserID := character.UserID
+
+	// Handing an NPC to a player turns it into a player character
+	query := `
+		UPDATE characters
+		SET user_id = $1,
+		    kind = CASE WHEN $1::integer IS NULL THEN kind ELSE 'pc' END
+		WHERE id = $2
+		RETURNING ` + characterColumns
+	err = tx.QueryRowx(query, req.UserID, character.ID).StructScan(character)
+	if err == nil {
+		_, err = recordCharacterVersion(tx, character.ID, userID, models.CharacterChangeReassigned)
+	}
+	if err != nil {
+		log.Printf("Error reassigning character: %v", err)
+		http.Error(w, "Error reassigning character", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error reassigning character", http.StatusInternalServerError)
+		return
+	}
+
+	public := *character
+	public.StatBlock = nil
+	broadcastForCharacter(h.db, h.hub, character.ID, websocket.MessageTypeCharacterUpdate, map[string]any{
+		"action":           "reassigned",
+		"character":        public,
+		"previous_user_id": previousUserID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(character)
+}
+
+// Delete permanently removes a character along with its dice pools and rolls (GM only).
+// Characters with roll history need ?force=true; retiring them is usually what you want.
+func (h *CharacterHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	character, ok := h.loadCharacterForGM(w, r, "delete")
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("force") != "true" {
+		var hasRolls bool
+		err := h.db.Get(&hasRolls, "SELECT EXISTS(SELECT 1 FROM roll_history WHERE character_id = $1)", character.ID)
+		if err != nil {
+			http.Error(w, "Error checking roll history", http.StatusInternalServerError)
+			return
+		}
+		if hasRolls {
+			http.Error(w, "Character has roll history; retire them instead or delete with force=true", http.StatusConflict)
+			return
+		}
+	}
+
+	_, err := h.db.Exec("DELETE FROM characters WHERE id = $1", character.ID)
+	if err != nil {
+		log.Printf("Error deleting character: %v", err)
+		http.Error(w, "Error deleting character", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastCharacter(character, "deleted")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Character deleted successfully"})
+}
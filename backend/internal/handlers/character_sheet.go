@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/sheet"
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+// writeSheetErrors responds with every validation error found in a sheet
+func writeSheetErrors(w http.ResponseWriter, status int, errs []models.SheetError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.SheetValidationResponse{Errors: errs})
+}
+
+// decodeSheetJSON decodes a request body, reporting type mismatches against the field they occurred in
+func decodeSheetJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		writeSheetErrors(w, http.StatusBadRequest, []models.SheetError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be a %s, not %s", typeErr.Type.Kind(), typeErr.Value),
+		}})
+		return false
+	}
+	http.Error(w, "Invalid request body", http.StatusBadRequest)
+	return false
+}
+
+// SheetSchema serves the JSON Schema for character sheets
+func (h *CharacterHandler) SheetSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(sheet.Schema)
+}
+
+// ExportSheet downloads a character as a portable sheet (owner or GM)
+func (h *CharacterHandler) ExportSheet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	characterID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+
+	var access struct {
+		OwnerID  *int `db:"user_id"`
+		GMUserID int  `db:"gm_user_id"`
+	}
+	err = h.db.Get(&access, `
+		SELECT ch.user_id, ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return
+	}
+	isGM := access.GMUserID == userID
+	isOwner := access.OwnerID != nil && *access.OwnerID == userID
+	if !isGM && !isOwner {
+		http.Error(w, "Only the character's owner or the GM can export it", http.StatusForbidden)
+		return
+	}
+
+	s, err := sheet.Export(h.db, characterID)
+	if err != nil {
+		log.Printf("Error exporting character %d: %v", characterID, err)
+		http.Error(w, "Error exporting character", http.StatusInternalServerError)
+		return
+	}
+	if !isGM {
+		s.StatBlock = nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="character-%d.json"`, characterID))
+	json.NewEncoder(w).Encode(s)
+}
+
+// ImportSheet creates a character in a campaign from a sheet (GM only).
+// ?user_id=N assigns it to a player; ?dry_run=true only validates.
+func (h *CharacterHandler) ImportSheet(w http.ResponseWriter, r *http.Request) {
+	var s models.CharacterSheet
+	if !decodeSheetJSON(w, r, &s) {
+		return
+	}
+
+	entry := models.PartyImportEntry{Sheet: s}
+	if u := r.URL.Query().Get("user_id"); u != "" {
+		id, err := strconv.Atoi(u)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		entry.UserID = &id
+	}
+
+	h.importSheets(w, r, []models.PartyImportEntry{entry}, func(int) string { return "" })
+}
+
+// ImportParty creates several characters at once from sheets (GM only).
+// Either every character is created or none are.
+func (h *CharacterHandler) ImportParty(w http.ResponseWriter, r *http.Request) {
+	var req models.PartyImportRequest
+	if !decodeSheetJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Characters) == 0 || len(req.Characters) > 50 {
+		http.Error(w, "Between 1 and 50 characters can be imported at once", http.StatusBadRequest)
+		return
+	}
+
+	h.importSheets(w, r, req.Characters, func(i int) string { return fmt.Sprintf("characters[%d]", i) })
+}
+
+// importSheets validates and creates characters from sheets in one transaction.
+// pathFor gives the field path prefix for each entry's errors.
+func (h *CharacterHandler) importSheets(w http.ResponseWriter, r *http.Request, entries []models.PartyImportEntry, pathFor func(int) string) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+	if gmUserID != userID {
+		http.Error(w, "Only the GM can import characters", http.StatusForbidden)
+		return
+	}
+
+	// Players must belong to the campaign
+	var assigned []int
+	for _, e := range entries {
+		if e.UserID != nil {
+			assigned = append(assigned, *e.UserID)
+		}
+	}
+	var memberIDs []int
+	err = h.db.Select(&memberIDs, `
+		SELECT user_id FROM campaign_members WHERE campaign_id = $1 AND user_id = ANY($2)
+		UNION
+		SELECT gm_user_id FROM campaigns WHERE id = $1 AND gm_user_id = ANY($2)
+	`, campaignID, pq.Array(assigned))
+	if err != nil {
+		log.Printf("Error checking campaign membership: %v", err)
+		http.Error(w, "Error checking campaign membership", http.StatusInternalServerError)
+		return
+	}
+	isMember := make(map[int]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		isMember[id] = true
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error importing characters", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Count each player's active characters so the whole batch respects the
+	// limit, holding the campaign row so concurrent creates wait their turn
+	var settings models.CampaignSettings
+	if err := tx.Get(&settings, "SELECT settings FROM campaigns WHERE id = $1 FOR UPDATE", campaignID); err != nil {
+		http.Error(w, "Error checking existing characters", http.StatusInternalServerError)
+		return
+	}
+	var counts []struct {
+		UserID int `db:"user_id"`
+		Count  int `db:"count"`
+	}
+	err = tx.Select(&counts, `
+		SELECT user_id, COUNT(*) as count FROM characters
+		WHERE campaign_id = $1 AND user_id = ANY($2) AND status = 'active'
+		GROUP BY user_id
+	`, campaignID, pq.Array(assigned))
+	if err != nil {
+		http.Error(w, "Error checking existing characters", http.StatusInternalServerError)
+		return
+	}
+	owned := make(map[int]int, len(counts))
+	for _, c := range counts {
+		owned[c.UserID] = c.Count
+	}
+
+	errs := []models.SheetError{}
+	for i, e := range entries {
+		path := pathFor(i)
+		sheetPath := path
+		if path != "" {
+			sheetPath = path + ".sheet"
+		}
+		errs = append(errs, sheet.Validate(&e.Sheet, sheetPath)...)
+
+		if e.UserID == nil {
+			continue
+		}
+		userField := "user_id"
+		if path != "" {
+			userField = path + ".user_id"
+		}
+		if e.Sheet.Kind == models.CharacterKindNPC {
+			errs = append(errs, models.SheetError{Field: userField, Message: "NPCs can't be assigned to players"})
+			continue
+		}
+		if !isMember[*e.UserID] {
+			errs = append(errs, models.SheetError{Field: userField, Message: "must be a member of the campaign"})
+			continue
+		}
+		if owned[*e.UserID] >= settings.CharacterLimit() {
+			errs = append(errs, models.SheetError{Field: userField, Message: "player would have more than the maximum number of characters in this campaign"})
+			continue
+		}
+		owned[*e.UserID]++
+	}
+	if len(errs) > 0 {
+		writeSheetErrors(w, http.StatusUnprocessableEntity, errs)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	if dryRun {
+		writeSheetErrors(w, http.StatusOK, errs)
+		return
+	}
+
+	ids := make([]int, 0, len(entries))
+	for i := range entries {
+		id, err := sheet.Create(tx, campaignID, entries[i].UserID, userID, &entries[i].Sheet)
+		if err == nil {
+			_, err = recordCharacterVersion(tx, id, userID, models.CharacterChangeImported)
+		}
+		if err != nil {
+			log.Printf("Error importing character: %v", err)
+			http.Error(w, "Error importing characters", http.StatusInternalServerError)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error importing characters", http.StatusInternalServerError)
+		return
+	}
+
+	var characters []models.Character
+	err = h.db.Select(&characters, "SELECT "+characterColumns+" FROM characters WHERE id = ANY($1) ORDER BY id ASC", pq.Array(ids))
+	if err != nil {
+		log.Printf("Error fetching imported characters: %v", err)
+		http.Error(w, "Error fetching imported characters", http.StatusInternalServerError)
+		return
+	}
+	traits, err := loadCharacterTraits(h.db, ids)
+	if err != nil {
+		http.Error(w, "Error fetching imported characters", http.StatusInternalServerError)
+		return
+	}
+	for i := range characters {
+		characters[i].Traits = traits[characters[i].ID]
+		h.broadcastCharacter(&characters[i], "created")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(characters)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+const characterVersionSelect = `
+	SELECT cv.id, cv.character_id, cv.version, cv.change, cv.snapshot,
+	       cv.changed_by_user_id, u.username as changed_by_name, cv.created_at
+	FROM character_versions cv
+	LEFT JOIN users u ON cv.changed_by_user_id = u.id
+`
+
+// recordCharacterVersion snapshots a character as it is now and bumps its
+// current version. Call it after every change, inside the change's transaction.
+func recordCharacterVersion(q sqlx.Ext, characterID, userID int, change string) (int, error) {
+	// CharacterSnapshot scans itself from JSONB, so read the columns through a plain copy
+	type snapshotRow models.CharacterSnapshot
+	var row snapshotRow
+	err := sqlx.Get(q, &row, `
+		SELECT name, description, user_id, status, skill_name, skill_modifier,
+		       weakness_name, weakness_modifier, max_daily_dice
+		FROM characters
+		WHERE id = $1
+	`, characterID)
+	if err != nil {
+		return 0, err
+	}
+
+	snapshot := models.CharacterSnapshot(row)
+	snapshot.Traits = []models.SnapshotTrait{}
+	err = sqlx.Select(q, &snapshot.Traits, `
+		SELECT id, name, kind, modifier, uses_per_day
+		FROM character_traits
+		WHERE character_id = $1
+		ORDER BY kind ASC, id ASC
+	`, characterID)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = sqlx.Get(q, &version, `
+		UPDATE characters SET current_version = current_version + 1
+		WHERE id = $1
+		RETURNING current_version
+	`, characterID)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO character_versions (character_id, version, change, snapshot, changed_by_user_id)
+		VALUES ($1, $2, $3, $4, $5)
+	`, characterID, version, change, snapshot, userID)
+	return version, err
+}
+
+// ListVersions returns a character's version history, newest first
+func (h *CharacterHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
+
+	var versions []models.CharacterVersion
+	err = h.db.Select(&versions, characterVersionSelect+`
+		WHERE cv.character_id = $1
+		ORDER BY cv.version DESC
+	`, characterID)
+	if err != nil {
+		log.Printf("Error fetching character versions: %v", err)
+		http.Error(w, "Error fetching character versions", http.StatusInternalServerError)
+		return
+	}
+
+	if versions == nil {
+		versions = []models.CharacterVersion{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// GetVersion returns a character as it was at one version
+func (h *CharacterHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
+
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		http.Error(w, "Invalid version", http.StatusBadRequest)
+		return
+	}
+
+	var v models.CharacterVersion
+	err = h.db.Get(&v, characterVersionSelect+`WHERE cv.character_id = $1 AND cv.version = $2`, characterID, version)
+	if err != nil {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// DiffVersions lists what changed between two versions: ?from=N&to=M.
+// to defaults to the current version.
+func (h *CharacterHandler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "from must be a version number", http.StatusBadRequest)
+		return
+	}
+
+	var to int
+	if t := r.URL.Query().Get("to"); t != "" {
+		to, err = strconv.Atoi(t)
+		if err != nil {
+			http.Error(w, "to must be a version number", http.StatusBadRequest)
+			return
+		}
+	} else {
+		err = h.db.Get(&to, "SELECT current_version FROM characters WHERE id = $1", characterID)
+		if err != nil {
+			http.Error(w, "Character not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	var versions []models.CharacterVersion
+	err = h.db.Select(&versions, characterVersionSelect+`
+		WHERE cv.character_id = $1 AND cv.version IN ($2, $3)
+	`, characterID, from, to)
+	if err != nil {
+		log.Printf("Error fetching character versions: %v", err)
+		http.Error(w, "Error fetching character versions", http.StatusInternalServerError)
+		return
+	}
+
+	byVersion := make(map[int]models.CharacterSnapshot, len(versions))
+	for _, v := range versions {
+		byVersion[v.Version] = v.Snapshot
+	}
+	before, okFrom := byVersion[from]
+	after, okTo := byVersion[to]
+	if !okFrom || !okTo {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.CharacterVersionDiff{
+		CharacterID: characterID,
+		From:        from,
+		To:          to,
+		Changes:     before.Diff(after),
+	})
+}
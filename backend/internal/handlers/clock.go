@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+)
+
+var errCampaignNotFound = errors.New("campaign not found")
+
+// clockMove computes the new day and phase from the current ones.
+// phaseCount is the number of time-of-day phases in the campaign calendar.
+type clockMove func(day, phase, phaseCount int) (int, int)
+
+// changeClock moves a campaign's clock inside a transaction, logs the change
+// and broadcasts it. Every path that changes current_day must go through here.
+func (h *CampaignHandler) changeClock(campaignID, userID int, reason string, note *string, move clockMove) (*models.Campaign, error) {
+	tx, err := h.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var campaign models.Campaign
+	err = tx.Get(&campaign, `
+		SELECT id, name, gm_user_id, current_day, time_phase, settings, created_at
+		FROM campaigns WHERE id = $1 FOR UPDATE
+	`, campaignID)
+	if err == sql.ErrNoRows {
+		return nil, errCampaignNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	previousDay, previousPhase := campaign.CurrentDay, campaign.TimePhase
+	phaseCount := len(campaign.Settings.Calendar.PhaseNames())
+	newDay, newPhase := move(previousDay, previousPhase, phaseCount)
+	if newDay < 1 {
+		newDay = 1
+	}
+
+	err = tx.QueryRowx(`
+		UPDATE campaigns SET current_day = $1, time_phase = $2
+		WHERE id = $3
+		RETURNING id, name, gm_user_id, current_day, time_phase, settings, created_at
+	`, newDay, newPhase, campaignID).StructScan(&campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	// Anything tied to the in-game day changes in the same transaction
+	var expired []models.CharacterCondition
+	if newDay > previousDay {
+		expired, err = expireConditions(tx, campaignID, newDay)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Rewinding can bring challenges back as well as advancing can end them
+	var rescheduled []models.Challenge
+	if newDay != previousDay {
+		rescheduled, err = rescheduleChallenges(tx, campaignID, newDay, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO campaign_clock_log
+			(campaign_id, changed_by_user_id, reason, previous_day, previous_phase, new_day, new_phase, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, campaignID, userID, reason, previousDay, previousPhase, newDay, newPhase, note)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	clock := campaignClock(&campaign)
+	h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeClockChanged, map[string]any{
+		"campaign_id":    campaignID,
+		"reason":         reason,
+		"previous_day":   previousDay,
+		"previous_phase": previousPhase,
+		"clock":          clock,
+	})
+
+	broadcastConditions(h.db, h.hub, campaignID, models.ConditionEndedExpired, expired)
+	for i := range rescheduled {
+		broadcastRescheduled(h.db, h.hub, &rescheduled[i])
+	}
+
+	// Older clients only listen for day increments
+	if newDay > previousDay {
+		h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeDayIncremented, map[string]any{
+			"campaign_id": campaignID,
+			"current_day": campaign.CurrentDay,
+		})
+	}
+
+	return &campaign, nil
+}
+
+// campaignClock resolves a campaign's day and phase against its calendar
+func campaignClock(c *models.Campaign) models.CampaignClock {
+	calendar := c.Settings.Calendar
+	phases := calendar.PhaseNames()
+	phaseName := ""
+	if c.TimePhase >= 0 && c.TimePhase < len(phases) {
+		phaseName = phases[c.TimePhase]
+	}
+
+	return models.CampaignClock{
+		CampaignID: c.ID,
+		CurrentDay: c.CurrentDay,
+		TimePhase:  c.TimePhase,
+		PhaseName:  phaseName,
+		Date:       calendar.DateFor(c.CurrentDay),
+	}
+}
+
+// validateCalendar returns a client-facing message if the calendar is unusable
+func validateCalendar(c *models.CalendarSettings) string {
+	for _, m := range c.Months {
+		if strings.TrimSpace(m.Name) == "" || m.Days < 1 {
+			return "Each month needs a name and at least one day"
+		}
+	}
+	for _, name := range append(append([]string{}, c.WeekDays...), c.Phases...) {
+		if strings.TrimSpace(name) == "" {
+			return "Week day and phase names cannot be empty"
+		}
+	}
+	return ""
+}
+
+// requireGM reports whether the user runs the campaign, writing an error if not
+func (h *CampaignHandler) requireGM(w http.ResponseWriter, campaignID, userID int, message string) bool {
+	var gmUserID int
+	err := h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return false
+	}
+	if gmUserID != userID {
+		http.Error(w, message, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (h *CampaignHandler) writeClockResult(w http.ResponseWriter, campaign *models.Campaign, err error, action string) {
+	if err == errCampaignNotFound {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error %s: %v", action, err)
+		http.Error(w, "Error "+action, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(campaignClock(campaign))
+}
+
+// GetClock returns the campaign's current in-game date and time of day
+func (h *CampaignHandler) GetClock(w http.ResponseWriter, r *http.Request) {
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var campaign models.Campaign
+	query := `SELECT id, name, gm_user_id, current_day, time_phase, settings, created_at FROM campaigns WHERE id = $1`
+	err = h.db.Get(&campaign, query, campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(campaignClock(&campaign))
+}
+
+// UpdateCalendar replaces the campaign's calendar configuration (GM only)
+func (h *CampaignHandler) UpdateCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	if !h.requireGM(w, campaignID, userID, "Only the GM can change the calendar") {
+		return
+	}
+
+	var req models.CalendarSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateCalendar(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Clamp the current phase in case the calendar now has fewer phases
+	var campaign models.Campaign
+	query := `
+		UPDATE campaigns
+		SET settings = jsonb_set(settings, '{calendar}', $1::jsonb),
+		    time_phase = LEAST(time_phase, $2)
+		WHERE id = $3
+		RETURNING id, name, gm_user_id, current_day, time_phase, settings, created_at
+	`
+	err = h.db.QueryRowx(query, string(data), len(req.PhaseNames())-1, campaignID).StructScan(&campaign)
+	if err != nil {
+		log.Printf("Error updating calendar: %v", err)
+		http.Error(w, "Error updating calendar", http.StatusInternalServerError)
+		return
+	}
+
+	clock := campaignClock(&campaign)
+	h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeClockChanged, map[string]any{
+		"campaign_id": campaignID,
+		"reason":      "calendar",
+		"clock":       clock,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clock)
+}
+
+// AdvanceClock moves the clock forward by any number of days and phases (GM only)
+func (h *CampaignHandler) AdvanceClock(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	if !h.requireGM(w, campaignID, userID, "Only the GM can advance the clock") {
+		return
+	}
+
+	var req models.AdvanceClockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Days < 0 || req.Phases < 0 || req.Days+req.Phases == 0 {
+		http.Error(w, "Advance by a positive number of days or phases", http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := h.changeClock(campaignID, userID, models.ClockReasonAdvance, req.Note, func(day, phase, phaseCount int) (int, int) {
+		total := phase + req.Phases
+		return day + req.Days + total/phaseCount, total % phaseCount
+	})
+	h.writeClockResult(w, campaign, err, "advancing clock")
+}
+
+// RewindClock moves the clock back by whole days, never before day 1 (GM only)
+func (h *CampaignHandler) RewindClock(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	if !h.requireGM(w, campaignID, userID, "Only the GM can rewind the clock") {
+		return
+	}
+
+	var req models.RewindClockRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Days == 0 {
+		req.Days = 1
+	}
+	if req.Days < 0 {
+		http.Error(w, "days must be positive", http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := h.changeClock(campaignID, userID, models.ClockReasonRewind, req.Note, func(day, phase, phaseCount int) (int, int) {
+		return day - req.Days, phase
+	})
+	h.writeClockResult(w, campaign, err, "rewinding clock")
+}
+
+// ListClockLog returns every clock change for a campaign, newest first
+func (h *CampaignHandler) ListClockLog(w http.ResponseWriter, r *http.Request) {
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	query := `
+		SELECT
+			l.id, l.campaign_id, l.changed_by_user_id, u.username as changed_by_name,
+			l.reason, l.previous_day, l.previous_phase, l.new_day, l.new_phase,
+			l.note, l.created_at
+		FROM campaign_clock_log l
+		LEFT JOIN users u ON l.changed_by_user_id = u.id
+		WHERE l.campaign_id = $1
+		ORDER BY l.created_at DESC, l.id DESC
+	`
+
+	var entries []models.CampaignClockLogEntry
+	err = h.db.Select(&entries, query, campaignID)
+	if err != nil {
+		log.Printf("Error fetching clock log: %v", err)
+		http.Error(w, "Error fetching clock log", http.StatusInternalServerError)
+		return
+	}
+
+	if entries == nil {
+		entries = []models.CampaignClockLogEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
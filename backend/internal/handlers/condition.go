@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const conditionColumns = `
+	cc.id, cc.character_id, cc.name, cc.description, cc.d6_modifier, cc.dice_modifier,
+	cc.started_on_day, cc.expires_after_day, cc.rolls_remaining, cc.created_by_user_id,
+	cc.created_at, cc.ended_at, cc.ended_reason
+`
+
+// activeConditionsQuery loads a character's conditions that apply right now.
+// The day check covers conditions whose expiry hasn't been processed yet.
+const activeConditionsQuery = `
+	SELECT ` + conditionColumns + `
+	FROM character_conditions cc
+	JOIN characters ch ON cc.character_id = ch.id
+	JOIN campaigns ca ON ch.campaign_id = ca.id
+	WHERE cc.character_id = $1
+	  AND cc.ended_at IS NULL
+	  AND (cc.expires_after_day IS NULL OR cc.expires_after_day >= ca.current_day)
+	ORDER BY cc.id ASC
+`
+
+type ConditionHandler struct {
+	db  *database.Database
+	hub *websocket.Hub
+}
+
+func NewConditionHandler(db *database.Database, hub *websocket.Hub) *ConditionHandler {
+	return &ConditionHandler{db: db, hub: hub}
+}
+
+// activeConditions returns the conditions currently affecting a character
+func activeConditions(q sqlx.Queryer, characterID int) ([]models.CharacterCondition, error) {
+	var conditions []models.CharacterCondition
+	err := sqlx.Select(q, &conditions, activeConditionsQuery, characterID)
+	return conditions, err
+}
+
+// expireConditions ends every condition in a campaign whose last day is before day
+func expireConditions(tx *sqlx.Tx, campaignID, day int) ([]models.CharacterCondition, error) {
+	var expired []models.CharacterCondition
+	err := tx.Select(&expired, `
+		UPDATE character_conditions cc
+		SET ended_at = CURRENT_TIMESTAMP, ended_reason = 'expired'
+		FROM characters ch
+		WHERE cc.character_id = ch.id
+		  AND ch.campaign_id = $1
+		  AND cc.ended_at IS NULL
+		  AND cc.expires_after_day < $2
+		RETURNING `+conditionColumns, campaignID, day)
+	return expired, err
+}
+
+// useConditionRolls counts a roll against each roll-limited condition,
+// ending the ones that run out. It returns the conditions that ended.
+func useConditionRolls(tx *sqlx.Tx, conditionIDs []int) ([]models.CharacterCondition, error) {
+	if len(conditionIDs) == 0 {
+		return nil, nil
+	}
+
+	var usedUp []models.CharacterCondition
+	err := tx.Select(&usedUp, `
+		UPDATE character_conditions cc
+		SET rolls_remaining = cc.rolls_remaining - 1,
+		    ended_at = CASE WHEN cc.rolls_remaining <= 1 THEN CURRENT_TIMESTAMP END,
+		    ended_reason = CASE WHEN cc.rolls_remaining <= 1 THEN 'used_up' END
+		WHERE cc.id = ANY($1) AND cc.rolls_remaining IS NOT NULL
+		RETURNING `+conditionColumns, pq.Array(conditionIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	ended := usedUp[:0]
+	for _, c := range usedUp {
+		if c.EndedAt != nil {
+			ended = append(ended, c)
+		}
+	}
+	return ended, nil
+}
+
+// loadRollConditions returns the conditions applied to each given roll keyed by roll ID
+func loadRollConditions(db *database.Database, rollIDs []int) (map[int][]models.RollCondition, error) {
+	byRoll := make(map[int][]models.RollCondition, len(rollIDs))
+	if len(rollIDs) == 0 {
+		return byRoll, nil
+	}
+
+	var conditions []models.RollCondition
+	query := `
+		SELECT roll_id, condition_id, name, d6_modifier
+		FROM roll_history_conditions
+		WHERE roll_id = ANY($1)
+		ORDER BY roll_id ASC, name ASC
+	`
+	if err := db.Select(&conditions, query, pq.Array(rollIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, c := range conditions {
+		byRoll[c.RollID] = append(byRoll[c.RollID], c)
+	}
+	return byRoll, nil
+}
+
+// loadPoolConditions returns the conditions that changed a dice pool's size
+func loadPoolConditions(db *database.Database, poolID int) ([]models.PoolCondition, error) {
+	conditions := []models.PoolCondition{}
+	err := db.Select(&conditions, `
+		SELECT pool_id, condition_id, name, dice_modifier
+		FROM dice_pool_conditions
+		WHERE pool_id = $1
+		ORDER BY name ASC
+	`, poolID)
+	return conditions, err
+}
+
+// broadcastConditions tells the campaign that conditions started or ended.
+// Conditions on hidden NPCs only go to the GM.
+func broadcastConditions(db *database.Database, hub *websocket.Hub, campaignID int, action string, conditions []models.CharacterCondition) {
+	if len(conditions) == 0 {
+		return
+	}
+
+	characterIDs := make([]int, len(conditions))
+	for i, c := range conditions {
+		characterIDs[i] = c.CharacterID
+	}
+	var info struct {
+		GMUserID int           `db:"gm_user_id"`
+		Hidden   pq.Int64Array `db:"hidden"`
+	}
+	err := db.Get(&info, `
+		SELECT ca.gm_user_id,
+		       ARRAY(
+		           SELECT id FROM characters
+		           WHERE id = ANY($2) AND kind = 'npc' AND NOT is_revealed
+		       ) as hidden
+		FROM campaigns ca
+		WHERE ca.id = $1
+	`, campaignID, pq.Array(uniqueInts(characterIDs)))
+	if err != nil {
+		log.Printf("Error checking character visibility: %v", err)
+		return
+	}
+	hidden := make(map[int]bool, len(info.Hidden))
+	for _, id := range info.Hidden {
+		hidden[int(id)] = true
+	}
+
+	var public, secret []models.CharacterCondition
+	for _, c := range conditions {
+		if hidden[c.CharacterID] {
+			secret = append(secret, c)
+		} else {
+			public = append(public, c)
+		}
+	}
+	if len(public) > 0 {
+		hub.BroadcastToCampaign(campaignID, websocket.MessageTypeConditionUpdate, map[string]any{
+			"action":     action,
+			"conditions": public,
+		})
+	}
+	if len(secret) > 0 {
+		hub.BroadcastToUsers(campaignID, []int{info.GMUserID}, websocket.MessageTypeConditionUpdate, map[string]any{
+			"action":     action,
+			"conditions": secret,
+		})
+	}
+}
+
+// ListByCharacter returns a character's active conditions, or all of them with ?include_ended=true
+func (h *ConditionHandler) ListByCharacter(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
+
+	var conditions []models.CharacterCondition
+	if r.URL.Query().Get("include_ended") == "true" {
+		err = h.db.Select(&conditions, `
+			SELECT `+conditionColumns+`
+			FROM character_conditions cc
+			WHERE cc.character_id = $1
+			ORDER BY cc.created_at DESC, cc.id DESC
+		`, characterID)
+	} else {
+		conditions, err = activeConditions(h.db, characterID)
+	}
+	if err != nil {
+		log.Printf("Error fetching conditions: %v", err)
+		http.Error(w, "Error fetching conditions", http.StatusInternalServerError)
+		return
+	}
+
+	if conditions == nil {
+		conditions = []models.CharacterCondition{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conditions)
+}
+
+// Create puts a new condition on a character (GM only)
+func (h *ConditionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+
+	var info struct {
+		CampaignID int `db:"campaign_id"`
+		GMUserID   int `db:"gm_user_id"`
+		CurrentDay int `db:"current_day"`
+	}
+	err = h.db.Get(&info, `
+		SELECT ch.campaign_id, ca.gm_user_id, ca.current_day
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return
+	}
+	if info.GMUserID != userID {
+		http.Error(w, "Only the GM can add conditions", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateConditionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "Condition name is required", http.StatusBadRequest)
+		return
+	}
+	if req.D6Modifier < -5 || req.D6Modifier > 5 {
+		http.Error(w, "d6_modifier must be between -5 and 5", http.StatusBadRequest)
+		return
+	}
+	if req.DiceModifier < -20 || req.DiceModifier > 20 {
+		http.Error(w, "dice_modifier must be between -20 and 20", http.StatusBadRequest)
+		return
+	}
+	if req.DurationDays != nil && *req.DurationDays < 1 {
+		http.Error(w, "duration_days must be at least 1", http.StatusBadRequest)
+		return
+	}
+	if req.DurationRolls != nil && *req.DurationRolls < 1 {
+		http.Error(w, "duration_rolls must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	var expiresAfterDay *int
+	if req.DurationDays != nil {
+		day := info.CurrentDay + *req.DurationDays - 1
+		expiresAfterDay = &day
+	}
+
+	var condition models.CharacterCondition
+	query := `
+		INSERT INTO character_conditions AS cc (
+			character_id, name, description, d6_modifier, dice_modifier,
+			started_on_day, expires_after_day, rolls_remaining, created_by_user_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + conditionColumns
+	err = h.db.QueryRowx(query, characterID, req.Name, req.Description, req.D6Modifier, req.DiceModifier,
+		info.CurrentDay, expiresAfterDay, req.DurationRolls, userID).StructScan(&condition)
+	if err != nil {
+		log.Printf("Error creating condition: %v", err)
+		http.Error(w, "Error creating condition", http.StatusInternalServerError)
+		return
+	}
+
+	broadcastConditions(h.db, h.hub, info.CampaignID, "created", []models.CharacterCondition{condition})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(condition)
+}
+
+// Remove ends a condition early (GM only). Rolls it affected keep their record of it.
+func (h *ConditionHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conditionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid condition ID", http.StatusBadRequest)
+		return
+	}
+
+	var info struct {
+		CampaignID int  `db:"campaign_id"`
+		GMUserID   int  `db:"gm_user_id"`
+		Ended      bool `db:"ended"`
+	}
+	err = h.db.Get(&info, `
+		SELECT ch.campaign_id, ca.gm_user_id, cc.ended_at IS NOT NULL as ended
+		FROM character_conditions cc
+		JOIN characters ch ON cc.character_id = ch.id
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE cc.id = $1
+	`, conditionID)
+	if err != nil {
+		http.Error(w, "Condition not found", http.StatusNotFound)
+		return
+	}
+	if info.GMUserID != userID {
+		http.Error(w, "Only the GM can remove conditions", http.StatusForbidden)
+		return
+	}
+	if info.Ended {
+		http.Error(w, "Condition has already ended", http.StatusConflict)
+		return
+	}
+
+	var condition models.CharacterCondition
+	query := `
+		UPDATE character_conditions cc
+		SET ended_at = CURRENT_TIMESTAMP, ended_reason = 'removed'
+		WHERE cc.id = $1
+		RETURNING ` + conditionColumns
+	err = h.db.QueryRowx(query, conditionID).StructScan(&condition)
+	if err != nil {
+		log.Printf("Error removing condition: %v", err)
+		http.Error(w, "Error removing condition", http.StatusInternalServerError)
+		return
+	}
+
+	broadcastConditions(h.db, h.hub, info.CampaignID, "removed", []models.CharacterCondition{condition})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(condition)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// maxConsequenceTableSize keeps consequence tables short enough to roll on at the table
+	maxConsequenceTableSize = 20
+
+	maxConsequenceEntryLength = 500
+)
+
+// validateConsequenceTables trims every entry and returns a client-facing message if a table is unusable
+func validateConsequenceTables(t *models.ConsequenceTables) string {
+	if t == nil {
+		return ""
+	}
+	for name, table := range map[string][]string{"success": t.Success, "neutral": t.Neutral, "failure": t.Failure} {
+		if len(table) > maxConsequenceTableSize {
+			return fmt.Sprintf("The %s consequence table can have at most %d entries", name, maxConsequenceTableSize)
+		}
+		for i := range table {
+			table[i] = strings.TrimSpace(table[i])
+			if table[i] == "" {
+				return fmt.Sprintf("The %s consequence table has an empty entry", name)
+			}
+			if len(table[i]) > maxConsequenceEntryLength {
+				return fmt.Sprintf("Consequence table entries must be at most %d characters", maxConsequenceEntryLength)
+			}
+		}
+	}
+	return ""
+}
+
+// resolveConsequence works out what a roll against a challenge led to, rolling
+// on the outcome's table if there is one. It returns nil when the challenge
+// has nothing to say about the outcome.
+func resolveConsequence(q sqlx.Queryer, challengeID int, outcome string) (*models.RollConsequence, error) {
+	var c models.Consequences
+	err := sqlx.Get(q, &c, `
+		SELECT success_text, neutral_text, failure_text, consequence_tables
+		FROM challenges
+		WHERE id = $1
+	`, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching challenge consequences: %w", err)
+	}
+
+	consequence := &models.RollConsequence{Outcome: outcome, Text: c.TextForOutcome(outcome)}
+	if c.ConsequenceTables != nil {
+		if table := c.ConsequenceTables.ForOutcome(outcome); len(table) > 0 {
+			roll, size := rand.Intn(len(table))+1, len(table)
+			consequence.TableRoll = &roll
+			consequence.TableSize = &size
+			consequence.TableEntry = &table[roll-1]
+		}
+	}
+
+	if consequence.Text == nil && consequence.TableEntry == nil {
+		return nil, nil
+	}
+	return consequence, nil
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
@@ -9,9 +10,12 @@ import (
 	"time"
 
 	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
 	"github.com/SamPCunningham/sleeper-system/internal/models"
 	"github.com/SamPCunningham/sleeper-system/internal/websocket"
 	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type DiceHandler struct {
@@ -33,30 +37,60 @@ func (h *DiceHandler) RollNewPool(w http.ResponseWriter, r *http.Request) {
 
 	// Get character's max_daily_dice and campaign_id
 	var charInfo struct {
-		MaxDice    int `db:"max_daily_dice"`
-		CampaignID int `db:"campaign_id"`
+		MaxDice    int    `db:"max_daily_dice"`
+		CampaignID int    `db:"campaign_id"`
+		Status     string `db:"status"`
 	}
-	err = h.db.Get(&charInfo, "SELECT max_daily_dice, campaign_id FROM characters WHERE id = $1", characterID)
+	err = h.db.Get(&charInfo, "SELECT max_daily_dice, campaign_id, status FROM characters WHERE id = $1", characterID)
 	if err != nil {
 		http.Error(w, "Character not found", http.StatusNotFound)
 		return
 	}
 
+	if charInfo.Status == models.CharacterStatusRetired {
+		http.Error(w, "Retired characters cannot roll new dice pools", http.StatusConflict)
+		return
+	}
+	if !checkNPCRoller(w, r, h.db, characterID) {
+		return
+	}
+
+	conditions, err := activeConditions(h.db, characterID)
+	if err != nil {
+		log.Printf("Error fetching conditions: %v", err)
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+	poolSize, poolConditions := models.PoolSize(charInfo.MaxDice, conditions)
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
 	// Create new dice pool
 	var pool models.DicePool
 	poolQuery := `
-		INSERT INTO dice_pools (character_id)
-		VALUES ($1)
-		RETURNING id, character_id, rolled_at
+		INSERT INTO dice_pools (character_id, session_id)
+		VALUES ($1, (SELECT id FROM game_sessions WHERE campaign_id = $2 AND ended_at IS NULL))
+		RETURNING id, character_id, session_id, rolled_at
 	`
-	err = h.db.QueryRowx(poolQuery, characterID).StructScan(&pool)
+	err = tx.QueryRowx(poolQuery, characterID, charInfo.CampaignID).StructScan(&pool)
 	if err != nil {
 		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
 		return
 	}
 
+	if err := recordPoolConditions(tx, pool.ID, poolConditions); err != nil {
+		log.Printf("Error recording pool conditions: %v", err)
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+
 	// Roll and insert dice
-	dice := make([]models.PoolDie, charInfo.MaxDice)
+	dice := make([]models.PoolDie, poolSize)
 	diceQuery := `
 		INSERT INTO pool_dice (pool_id, die_result, position)
 		VALUES ($1, $2, $3)
@@ -64,22 +98,28 @@ func (h *DiceHandler) RollNewPool(w http.ResponseWriter, r *http.Request) {
 	`
 
 	rand.Seed(time.Now().UnixNano())
-	for i := 0; i < charInfo.MaxDice; i++ {
+	for i := 0; i < poolSize; i++ {
 		result := rand.Intn(6) + 1 // 1-6
-		err = h.db.QueryRowx(diceQuery, pool.ID, result, i+1).StructScan(&dice[i])
+		err = tx.QueryRowx(diceQuery, pool.ID, result, i+1).StructScan(&dice[i])
 		if err != nil {
 			http.Error(w, "Error creating dice", http.StatusInternalServerError)
 			return
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+
 	response := models.DicePoolWithDice{
-		DicePool: pool,
-		Dice:     dice,
+		DicePool:   pool,
+		Dice:       dice,
+		Conditions: poolConditions,
 	}
 
 	// Broadcast dice pool update
-	h.hub.BroadcastToCampaign(charInfo.CampaignID, websocket.MessageTypeDicePoolUpdated, map[string]any{
+	broadcastForCharacter(h.db, h.hub, characterID, websocket.MessageTypeDicePoolUpdated, map[string]any{
 		"character_id": characterID,
 		"pool":         response,
 	})
@@ -89,6 +129,21 @@ func (h *DiceHandler) RollNewPool(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// recordPoolConditions stores the conditions that changed the size of a new pool
+func recordPoolConditions(tx *sqlx.Tx, poolID int, conditions []models.PoolCondition) error {
+	for i := range conditions {
+		conditions[i].PoolID = poolID
+		_, err := tx.Exec(`
+			INSERT INTO dice_pool_conditions (pool_id, condition_id, name, dice_modifier)
+			VALUES ($1, $2, $3, $4)
+		`, poolID, conditions[i].ConditionID, conditions[i].Name, conditions[i].DiceModifier)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetCurrentPool gets the most recent dice pool for a character
 func (h *DiceHandler) GetCurrentPool(w http.ResponseWriter, r *http.Request) {
 	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
@@ -96,11 +151,14 @@ func (h *DiceHandler) GetCurrentPool(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid character ID", http.StatusBadRequest)
 		return
 	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
 
 	// Get most recent pool
 	var pool models.DicePool
 	poolQuery := `
-		SELECT id, character_id, rolled_at
+		SELECT id, character_id, session_id, rolled_at
 		FROM dice_pools
 		WHERE character_id = $1
 		ORDER BY rolled_at DESC
@@ -126,9 +184,16 @@ func (h *DiceHandler) GetCurrentPool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	conditions, err := loadPoolConditions(h.db, pool.ID)
+	if err != nil {
+		http.Error(w, "Error fetching dice", http.StatusInternalServerError)
+		return
+	}
+
 	response := models.DicePoolWithDice{
-		DicePool: pool,
-		Dice:     dice,
+		DicePool:   pool,
+		Dice:       dice,
+		Conditions: conditions,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -197,11 +262,12 @@ func (h *DiceHandler) RecordRoll(w http.ResponseWriter, r *http.Request) {
 
 	// Get the d6 result from the pool die and character info
 	var dieInfo struct {
-		DieResult  int `db:"die_result"`
-		CampaignID int `db:"campaign_id"`
+		DieResult   int `db:"die_result"`
+		CharacterID int `db:"character_id"`
+		CampaignID  int `db:"campaign_id"`
 	}
 	err := h.db.Get(&dieInfo, `
-		SELECT pd.die_result, c.campaign_id 
+		SELECT pd.die_result, c.id as character_id, c.campaign_id 
 		FROM pool_dice pd
 		JOIN dice_pools dp ON pd.pool_id = dp.id
 		JOIN characters c ON dp.character_id = c.id
@@ -211,20 +277,96 @@ func (h *DiceHandler) RecordRoll(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Pool die not found", http.StatusNotFound)
 		return
 	}
+	// The roll belongs to whoever owns the die; everything below is checked against them
+	if req.CharacterID != dieInfo.CharacterID {
+		http.Error(w, "character_id doesn't match the pool die's character", http.StatusBadRequest)
+		return
+	}
+	if !checkNPCRoller(w, r, h.db, dieInfo.CharacterID) {
+		return
+	}
+
+	// Work out which traits apply to this roll
+	var applied []models.CharacterTrait
+	if len(req.TraitIDs) > 0 {
+		err = h.db.Select(&applied, traitSelect+` WHERE ct.id = ANY($1) AND ct.character_id = $2`,
+			pq.Array(req.TraitIDs), dieInfo.CharacterID)
+		if err != nil {
+			log.Printf("Error fetching traits: %v", err)
+			http.Error(w, "Error fetching traits", http.StatusInternalServerError)
+			return
+		}
+		if len(applied) != len(uniqueInts(req.TraitIDs)) {
+			http.Error(w, "Traits must belong to the rolling character", http.StatusBadRequest)
+			return
+		}
+	} else if req.SkillApplied {
+		// Older clients only send skill_applied; use the character's first skill
+		err = h.db.Select(&applied, traitSelect+` WHERE ct.character_id = $1 AND ct.kind = 'skill' ORDER BY ct.id ASC LIMIT 1`,
+			dieInfo.CharacterID)
+		if err != nil {
+			log.Printf("Error fetching skill trait: %v", err)
+		}
+	}
+
+	// Declared items must be carried by the rolling character
+	var items []models.CharacterItem
+	if len(req.Items) > 0 {
+		itemIDs := make([]int, len(req.Items))
+		for i, it := range req.Items {
+			itemIDs[i] = it.ItemID
+		}
+		if len(uniqueInts(itemIDs)) != len(itemIDs) {
+			http.Error(w, "Each item can only be declared once per roll", http.StatusBadRequest)
+			return
+		}
+		err = h.db.Select(&items, "SELECT "+itemColumns+" FROM character_items WHERE id = ANY($1) AND character_id = $2",
+			pq.Array(itemIDs), dieInfo.CharacterID)
+		if err != nil {
+			log.Printf("Error fetching items: %v", err)
+			http.Error(w, "Error fetching items", http.StatusInternalServerError)
+			return
+		}
+		if len(items) != len(itemIDs) {
+			http.Error(w, "Items must be carried by the rolling character", http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Calculate modified d6
 	modifiedD6 := dieInfo.DieResult
-	if req.SkillApplied {
-		// Get character's skill modifier
-		var skillMod int
-		err = h.db.Get(&skillMod, "SELECT skill_modifier FROM characters WHERE id = $1", req.CharacterID)
-		if err != nil {
-			log.Printf("Error fetching skill modifier: %v", err)
-		} else {
-			log.Printf("Applying skill modifier: %d to base d6: %d", skillMod, modifiedD6)
-			modifiedD6 += skillMod
+	skillApplied := false
+	for _, t := range applied {
+		if t.UsesPerDay != nil && t.UsesToday >= *t.UsesPerDay {
+			http.Error(w, fmt.Sprintf("%s has no uses left today", t.Name), http.StatusConflict)
+			return
+		}
+		if t.Kind == models.TraitKindSkill {
+			skillApplied = true
 		}
+		log.Printf("Applying %s %q modifier: %d to base d6: %d", t.Kind, t.Name, t.Modifier, modifiedD6)
+		modifiedD6 += t.Modifier
+	}
+
+	// Active conditions always apply
+	conditions, err := activeConditions(h.db, dieInfo.CharacterID)
+	if err != nil {
+		log.Printf("Error fetching conditions: %v", err)
+		http.Error(w, "Error recording roll", http.StatusInternalServerError)
+		return
+	}
+	conditionD6 := 0
+	for _, c := range conditions {
+		conditionD6 += c.D6Modifier
 	}
+	modifiedD6 += conditionD6
+
+	itemD6 := 0
+	for _, it := range items {
+		itemD6 += it.D6Modifier
+	}
+	modifiedD6 += itemD6
+
 	modifiedD6 += req.OtherModifiers
 	// Cap at 1-6
 	if modifiedD6 < 1 {
@@ -234,8 +376,8 @@ func (h *DiceHandler) RecordRoll(w http.ResponseWriter, r *http.Request) {
 		modifiedD6 = 6
 	}
 
-	log.Printf("Final modified d6: %d (base: %d, skill: %v, other: %d)",
-		modifiedD6, dieInfo.DieResult, req.SkillApplied, req.OtherModifiers)
+	log.Printf("Final modified d6: %d (base: %d, traits: %d, conditions: %d, items: %d, other: %d)",
+		modifiedD6, dieInfo.DieResult, len(applied), conditionD6, itemD6, req.OtherModifiers)
 
 	// Calculate outcome based on modified d6 and d20
 	outcome := calculateOutcome(modifiedD6, req.D20Roll)
@@ -251,19 +393,61 @@ func (h *DiceHandler) RecordRoll(w http.ResponseWriter, r *http.Request) {
 	}
 	// neutral = nil
 
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error recording roll", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Targeted challenges only take rolls from their targets, up to the attempt limit
+	if req.ChallengeID != nil {
+		status, msg, err := checkChallengeEligibility(tx, *req.ChallengeID, dieInfo.CharacterID, dieInfo.CampaignID)
+		if err != nil {
+			log.Printf("Error checking challenge eligibility: %v", err)
+			http.Error(w, "Error recording roll", http.StatusInternalServerError)
+			return
+		}
+		if msg != "" {
+			http.Error(w, msg, status)
+			return
+		}
+	}
+
+	// Attach the challenge's consequence for this outcome so the GM doesn't have to narrate it out-of-band
+	var consequence *models.RollConsequence
+	if req.ChallengeID != nil {
+		consequence, err = resolveConsequence(tx, *req.ChallengeID, outcome)
+		if err != nil {
+			log.Printf("Error resolving consequence: %v", err)
+			http.Error(w, "Error recording roll", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	var rollHistory models.RollHistory
 	query := `
 		INSERT INTO roll_history (
 			character_id, pool_dice_id, d20_roll, action_type, success, outcome, notes,
-			challenge_id, skill_applied, other_modifiers, modified_d6
+			challenge_id, skill_applied, other_modifiers, modified_d6, session_id, scene_id, campaign_day,
+			character_version, consequence
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+		        (SELECT id FROM game_sessions WHERE campaign_id = $12 AND ended_at IS NULL),
+		        COALESCE(
+		            (SELECT scene_id FROM challenges WHERE id = $8),
+		            (SELECT id FROM scenes WHERE campaign_id = $12 AND is_active)
+		        ),
+		        (SELECT current_day FROM campaigns WHERE id = $12),
+		        (SELECT current_version FROM characters WHERE id = $1),
+		        $13)
 		RETURNING id, character_id, pool_dice_id, d20_roll, action_type, success, outcome, notes,
-		          challenge_id, skill_applied, other_modifiers, modified_d6, created_at
+		          challenge_id, skill_applied, other_modifiers, modified_d6, session_id, scene_id, campaign_day,
+		          character_version, consequence, created_at
 	`
-	err = h.db.QueryRowx(query,
-		req.CharacterID, req.PoolDiceID, req.D20Roll, req.ActionType, success, outcome, req.Notes,
-		req.ChallengeID, req.SkillApplied, req.OtherModifiers, modifiedD6,
+	err = tx.QueryRowx(query,
+		dieInfo.CharacterID, req.PoolDiceID, req.D20Roll, req.ActionType, success, outcome, req.Notes,
+		req.ChallengeID, skillApplied, req.OtherModifiers, modifiedD6, dieInfo.CampaignID, consequence,
 	).StructScan(&rollHistory)
 	if err != nil {
 		log.Printf("Error recording roll: %v", err)
@@ -271,22 +455,137 @@ func (h *DiceHandler) RecordRoll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rollHistory.Traits = make([]models.RollTrait, 0, len(applied))
+	for _, t := range applied {
+		traitID := t.ID
+		_, err = tx.Exec(`
+			INSERT INTO roll_history_traits (roll_id, trait_id, name, kind, modifier)
+			VALUES ($1, $2, $3, $4, $5)
+		`, rollHistory.ID, traitID, t.Name, t.Kind, t.Modifier)
+		if err != nil {
+			log.Printf("Error recording roll traits: %v", err)
+			http.Error(w, "Error recording roll", http.StatusInternalServerError)
+			return
+		}
+		rollHistory.Traits = append(rollHistory.Traits, models.RollTrait{
+			RollID: rollHistory.ID, TraitID: &traitID, Name: t.Name, Kind: t.Kind, Modifier: t.Modifier,
+		})
+	}
+
+	rollHistory.Conditions = make([]models.RollCondition, 0, len(conditions))
+	conditionIDs := make([]int, 0, len(conditions))
+	for _, c := range conditions {
+		conditionID := c.ID
+		_, err = tx.Exec(`
+			INSERT INTO roll_history_conditions (roll_id, condition_id, name, d6_modifier)
+			VALUES ($1, $2, $3, $4)
+		`, rollHistory.ID, conditionID, c.Name, c.D6Modifier)
+		if err != nil {
+			log.Printf("Error recording roll conditions: %v", err)
+			http.Error(w, "Error recording roll", http.StatusInternalServerError)
+			return
+		}
+		rollHistory.Conditions = append(rollHistory.Conditions, models.RollCondition{
+			RollID: rollHistory.ID, ConditionID: &conditionID, Name: c.Name, D6Modifier: c.D6Modifier,
+		})
+		conditionIDs = append(conditionIDs, conditionID)
+	}
+
+	usedUp, err := useConditionRolls(tx, conditionIDs)
+	if err != nil {
+		log.Printf("Error updating condition durations: %v", err)
+		http.Error(w, "Error recording roll", http.StatusInternalServerError)
+		return
+	}
+
+	// Consumable items are always used up; others only when the player says so
+	consume := make(map[int]bool, len(req.Items))
+	for _, it := range req.Items {
+		consume[it.ItemID] = it.Consume
+	}
+	rollHistory.Items = make([]models.RollItem, 0, len(items))
+	var consumedIDs []int
+	var consumedItems []models.CharacterItem
+	for _, it := range items {
+		itemID := it.ID
+		consumed := it.Consumable || consume[it.ID]
+		_, err = tx.Exec(`
+			INSERT INTO roll_history_items (roll_id, item_id, name, d6_modifier, consumed)
+			VALUES ($1, $2, $3, $4, $5)
+		`, rollHistory.ID, itemID, it.Name, it.D6Modifier, consumed)
+		if err != nil {
+			log.Printf("Error recording roll items: %v", err)
+			http.Error(w, "Error recording roll", http.StatusInternalServerError)
+			return
+		}
+		rollHistory.Items = append(rollHistory.Items, models.RollItem{
+			RollID: rollHistory.ID, ItemID: &itemID, Name: it.Name, D6Modifier: it.D6Modifier, Consumed: consumed,
+		})
+		if consumed {
+			consumedIDs = append(consumedIDs, it.ID)
+			it.Quantity--
+			consumedItems = append(consumedItems, it)
+		}
+	}
+
+	if err := consumeItems(tx, consumedIDs); err != nil {
+		log.Printf("Error consuming items: %v", err)
+		http.Error(w, "Error recording roll", http.StatusInternalServerError)
+		return
+	}
+
 	// Mark the die as used
-	_, err = h.db.Exec("UPDATE pool_dice SET is_used = true WHERE id = $1", req.PoolDiceID)
+	_, err = tx.Exec("UPDATE pool_dice SET is_used = true WHERE id = $1", req.PoolDiceID)
 	if err != nil {
 		http.Error(w, "Error marking die as used", http.StatusInternalServerError)
 		return
 	}
 
+	// Move the challenge's progress clock along, if it has one
+	var clockChallenge *models.Challenge
+	var clockCompleted bool
+	if req.ChallengeID != nil {
+		clockChallenge, clockCompleted, err = tallyProgressClock(tx, *req.ChallengeID)
+		if err != nil {
+			log.Printf("Error tallying progress clock: %v", err)
+			http.Error(w, "Error recording roll", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing roll: %v", err)
+		http.Error(w, "Error recording roll", http.StatusInternalServerError)
+		return
+	}
+
+	broadcastConditions(h.db, h.hub, dieInfo.CampaignID, models.ConditionEndedUsedUp, usedUp)
+	for _, it := range consumedItems {
+		broadcastForCharacter(h.db, h.hub, it.CharacterID, websocket.MessageTypeInventoryUpdate, map[string]any{
+			"action":       "consumed",
+			"character_id": it.CharacterID,
+			"item":         it,
+		})
+	}
+
+	if clockChallenge != nil {
+		action := "progress"
+		if clockCompleted {
+			action = "completed"
+		}
+		broadcastChallenge(h.db, h.hub, clockChallenge, action)
+	}
+
 	// Get character name for the broadcast
 	var charName string
-	h.db.Get(&charName, "SELECT name FROM characters WHERE id = $1", req.CharacterID)
+	h.db.Get(&charName, "SELECT name FROM characters WHERE id = $1", dieInfo.CharacterID)
 
-	// Broadcast roll completion to campaign
-	h.hub.BroadcastToCampaign(dieInfo.CampaignID, websocket.MessageTypeRollComplete, map[string]any{
+	// Broadcast roll completion to everyone who may see it
+	broadcastRoll(h.db, h.hub, &rollHistory, map[string]any{
 		"roll":           rollHistory,
 		"character_name": charName,
-		"character_id":   req.CharacterID,
+		"character_id":   dieInfo.CharacterID,
+		"consequence":    rollHistory.Consequence,
 	})
 
 	w.Header().Set("Content-Type", "application/json")
@@ -294,10 +593,47 @@ func (h *DiceHandler) RecordRoll(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(rollHistory)
 }
 
-// GetRollHistory gets roll history for a character or campaign
+// broadcastRoll sends a roll_complete to the campaign. Hidden NPCs roll behind
+// the GM's screen, and rolls against a hidden challenge only go to the GM and
+// the challenge's targeted players, matching rollVisibleToUser.
+func broadcastRoll(db *database.Database, hub *websocket.Hub, roll *models.RollHistory, payload map[string]any) {
+	if roll.ChallengeID != nil {
+		var c models.Challenge
+		err := db.Get(&c, "SELECT "+challengeColumns+" FROM challenges WHERE id = $1", *roll.ChallengeID)
+		if err == nil && c.IsHidden {
+			err = attachChallengeTargets(db, &c)
+		}
+		if err != nil {
+			log.Printf("Error fetching roll challenge: %v", err)
+			return
+		}
+		if c.IsHidden {
+			v, err := loadCharacterVisibility(db, roll.CharacterID)
+			if err != nil {
+				log.Printf("Error checking character visibility: %v", err)
+				return
+			}
+			userIDs := []int{v.GMUserID}
+			if !v.Hidden {
+				userIDs, _, err = challengeRecipients(db, &c)
+				if err != nil {
+					log.Printf("Error fetching challenge recipients: %v", err)
+					return
+				}
+			}
+			hub.BroadcastToUsers(c.CampaignID, userIDs, websocket.MessageTypeRollComplete, payload)
+			return
+		}
+	}
+	broadcastForCharacter(db, hub, roll.CharacterID, websocket.MessageTypeRollComplete, payload)
+}
+
+// GetRollHistory gets roll history for a character, campaign or play session
 func (h *DiceHandler) GetRollHistory(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r.Context())
 	characterID := r.URL.Query().Get("character_id")
 	campaignID := r.URL.Query().Get("campaign_id")
+	sessionID := r.URL.Query().Get("session_id")
 
 	var rolls []models.RollHistoryWithCharacter
 	var err error
@@ -309,15 +645,16 @@ func (h *DiceHandler) GetRollHistory(w http.ResponseWriter, r *http.Request) {
 					rh.id, rh.character_id, rh.pool_dice_id, rh.d20_roll, 
 					rh.action_type, rh.success, rh.notes, rh.created_at,
 					rh.challenge_id, rh.skill_applied, rh.other_modifiers, rh.modified_d6,
-					rh.outcome,
+					rh.outcome, rh.session_id, rh.scene_id, rh.campaign_day, rh.character_version, rh.consequence,
 					c.name as character_name
 				FROM roll_history rh
 				JOIN characters c ON rh.character_id = c.id
-				WHERE rh.character_id = $1
+				JOIN campaigns ca ON c.campaign_id = ca.id
+				WHERE rh.character_id = $1 AND ` + rollVisibleToUser + `
 				ORDER BY rh.created_at DESC
 				LIMIT 50
 			`
-		err = h.db.Select(&rolls, query, characterID)
+		err = h.db.Select(&rolls, query, characterID, userID)
 	} else if campaignID != "" {
 		// Get rolls for entire campaign
 		query := `
@@ -325,17 +662,34 @@ func (h *DiceHandler) GetRollHistory(w http.ResponseWriter, r *http.Request) {
 					rh.id, rh.character_id, rh.pool_dice_id, rh.d20_roll, 
 					rh.action_type, rh.success, rh.notes, rh.created_at,
 					rh.challenge_id, rh.skill_applied, rh.other_modifiers, rh.modified_d6,
-					rh.outcome,
+					rh.outcome, rh.session_id, rh.scene_id, rh.campaign_day, rh.character_version, rh.consequence,
 					c.name as character_name
 				FROM roll_history rh
 				JOIN characters c ON rh.character_id = c.id
-				WHERE c.campaign_id = $1
+				JOIN campaigns ca ON c.campaign_id = ca.id
+				WHERE c.campaign_id = $1 AND ` + rollVisibleToUser + `
 				ORDER BY rh.created_at DESC
 				LIMIT 100
 			`
-		err = h.db.Select(&rolls, query, campaignID)
+		err = h.db.Select(&rolls, query, campaignID, userID)
+	} else if sessionID != "" {
+		// Get every roll made during a play session
+		query := `
+				SELECT 
+					rh.id, rh.character_id, rh.pool_dice_id, rh.d20_roll, 
+					rh.action_type, rh.success, rh.notes, rh.created_at,
+					rh.challenge_id, rh.skill_applied, rh.other_modifiers, rh.modified_d6,
+					rh.outcome, rh.session_id, rh.scene_id, rh.campaign_day, rh.character_version, rh.consequence,
+					c.name as character_name
+				FROM roll_history rh
+				JOIN characters c ON rh.character_id = c.id
+				JOIN campaigns ca ON c.campaign_id = ca.id
+				WHERE rh.session_id = $1 AND ` + rollVisibleToUser + `
+				ORDER BY rh.created_at DESC
+			`
+		err = h.db.Select(&rolls, query, sessionID, userID)
 	} else {
-		http.Error(w, "character_id or campaign_id query parameter required", http.StatusBadRequest)
+		http.Error(w, "character_id, campaign_id or session_id query parameter required", http.StatusBadRequest)
 		return
 	}
 
@@ -346,6 +700,12 @@ func (h *DiceHandler) GetRollHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := attachRollDetails(h.db, rolls); err != nil {
+		log.Printf("Error fetching roll traits: %v", err)
+		http.Error(w, "Error fetching roll history", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(rolls)
 }
@@ -378,27 +738,64 @@ func (h *DiceHandler) ManualRollPool(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get campaign ID for broadcast
-	var campaignID int
-	err = h.db.Get(&campaignID, "SELECT campaign_id FROM characters WHERE id = $1", characterID)
+	var charInfo struct {
+		MaxDice    int    `db:"max_daily_dice"`
+		CampaignID int    `db:"campaign_id"`
+		Status     string `db:"status"`
+	}
+	err = h.db.Get(&charInfo, "SELECT max_daily_dice, campaign_id, status FROM characters WHERE id = $1", characterID)
 	if err != nil {
 		http.Error(w, "Character not found", http.StatusNotFound)
 		return
 	}
 
+	if charInfo.Status == models.CharacterStatusRetired {
+		http.Error(w, "Retired characters cannot roll new dice pools", http.StatusConflict)
+		return
+	}
+	if !checkNPCRoller(w, r, h.db, characterID) {
+		return
+	}
+
+	// Players roll manual pools themselves, but conditions still set how many dice they roll
+	conditions, err := activeConditions(h.db, characterID)
+	if err != nil {
+		log.Printf("Error fetching conditions: %v", err)
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+	poolSize, poolConditions := models.PoolSize(charInfo.MaxDice, conditions)
+	if len(poolConditions) > 0 && len(req.DiceResults) != poolSize {
+		http.Error(w, fmt.Sprintf("Active conditions make this a %d dice pool; enter %d results", poolSize, poolSize), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
 	// Create new dice pool
 	var pool models.DicePool
 	poolQuery := `
-		INSERT INTO dice_pools (character_id)
-		VALUES ($1)
-		RETURNING id, character_id, rolled_at
+		INSERT INTO dice_pools (character_id, session_id)
+		VALUES ($1, (SELECT id FROM game_sessions WHERE campaign_id = $2 AND ended_at IS NULL))
+		RETURNING id, character_id, session_id, rolled_at
 	`
-	err = h.db.QueryRowx(poolQuery, characterID).StructScan(&pool)
+	err = tx.QueryRowx(poolQuery, characterID, charInfo.CampaignID).StructScan(&pool)
 	if err != nil {
 		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
 		return
 	}
 
+	if err := recordPoolConditions(tx, pool.ID, poolConditions); err != nil {
+		log.Printf("Error recording pool conditions: %v", err)
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+
 	// Insert manually entered dice
 	dice := make([]models.PoolDie, len(req.DiceResults))
 	diceQuery := `
@@ -408,20 +805,26 @@ func (h *DiceHandler) ManualRollPool(w http.ResponseWriter, r *http.Request) {
 	`
 
 	for i, result := range req.DiceResults {
-		err = h.db.QueryRowx(diceQuery, pool.ID, result, i+1).StructScan(&dice[i])
+		err = tx.QueryRowx(diceQuery, pool.ID, result, i+1).StructScan(&dice[i])
 		if err != nil {
 			http.Error(w, "Error creating dice", http.StatusInternalServerError)
 			return
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating dice pool", http.StatusInternalServerError)
+		return
+	}
+
 	response := models.DicePoolWithDice{
-		DicePool: pool,
-		Dice:     dice,
+		DicePool:   pool,
+		Dice:       dice,
+		Conditions: poolConditions,
 	}
 
 	// Broadcast dice pool update
-	h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeDicePoolUpdated, map[string]any{
+	broadcastForCharacter(h.db, h.hub, characterID, websocket.MessageTypeDicePoolUpdated, map[string]any{
 		"character_id": characterID,
 		"pool":         response,
 	})
@@ -488,7 +891,7 @@ func (h *DiceHandler) UpdatePoolDie(w http.ResponseWriter, r *http.Request) {
 
 	// Get the full updated pool to broadcast
 	var pool models.DicePool
-	poolQuery := `SELECT id, character_id, rolled_at FROM dice_pools WHERE id = $1`
+	poolQuery := `SELECT id, character_id, session_id, rolled_at FROM dice_pools WHERE id = $1`
 	err = h.db.Get(&pool, poolQuery, info.PoolID)
 	if err != nil {
 		http.Error(w, "Error fetching pool", http.StatusInternalServerError)
@@ -508,13 +911,20 @@ func (h *DiceHandler) UpdatePoolDie(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	conditions, err := loadPoolConditions(h.db, info.PoolID)
+	if err != nil {
+		http.Error(w, "Error fetching dice", http.StatusInternalServerError)
+		return
+	}
+
 	response := models.DicePoolWithDice{
-		DicePool: pool,
-		Dice:     dice,
+		DicePool:   pool,
+		Dice:       dice,
+		Conditions: conditions,
 	}
 
 	// Broadcast dice pool update
-	h.hub.BroadcastToCampaign(info.CampaignID, websocket.MessageTypeDicePoolUpdated, map[string]any{
+	broadcastForCharacter(h.db, h.hub, info.CharacterID, websocket.MessageTypeDicePoolUpdated, map[string]any{
 		"character_id": info.CharacterID,
 		"pool":         response,
 	})
@@ -0,0 +1,476 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/storage"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+)
+
+// Longest edge of generated thumbnails, in pixels
+const thumbnailMaxDim = 320
+
+// allowedUploadTypes maps sniffed content types to the extension we store them with
+var allowedUploadTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+const imageColumns = `
+	ci.id, ci.campaign_id, ci.url, ci.thumbnail_url, ci.description, ci.scene_id, ci.storage_key,
+	ci.thumbnail_key, ci.original_filename, ci.content_type, ci.size_bytes, ci.width, ci.height,
+	ci.uploaded_by_user_id, ci.uploaded_at, ci.shown_at
+`
+
+// imageVisibleToUser filters images ci down to those the user in $2 may see.
+// Handouts stay with the GM until they are first shown.
+const imageVisibleToUser = `(ci.shown_at IS NOT NULL OR EXISTS (
+	SELECT 1 FROM campaigns ica WHERE ica.id = ci.campaign_id AND ica.gm_user_id = $2
+))`
+
+type ImageHandler struct {
+	db             *database.Database
+	hub            *websocket.Hub
+	store          storage.BlobStore
+	maxUploadBytes int64
+}
+
+func NewImageHandler(db *database.Database, hub *websocket.Hub, store storage.BlobStore, maxUploadBytes int64) *ImageHandler {
+	return &ImageHandler{db: db, hub: hub, store: store, maxUploadBytes: maxUploadBytes}
+}
+
+// Upload stores an image or handout for a campaign (GM only)
+func (h *ImageHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is GM
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can upload images", http.StatusForbidden)
+		return
+	}
+
+	// Leave some headroom over the file limit for the other form fields
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(h.maxUploadBytes); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("File exceeds the %d byte upload limit", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+
+	var sceneID *int
+	if v := r.FormValue("scene_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid scene ID", http.StatusBadRequest)
+			return
+		}
+		inCampaign, err := sceneInCampaign(h.db, id, campaignID)
+		if err != nil || !inCampaign {
+			http.Error(w, "Scene not found in this campaign", http.StatusBadRequest)
+			return
+		}
+		sceneID = &id
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "A file field is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadBytes+1))
+	if err != nil {
+		http.Error(w, "Error reading upload", http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > h.maxUploadBytes {
+		http.Error(w, fmt.Sprintf("File exceeds the %d byte upload limit", h.maxUploadBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "Uploaded file is empty", http.StatusBadRequest)
+		return
+	}
+
+	// Trust the bytes, not the client's Content-Type header
+	contentType := http.DetectContentType(data)
+	ext, allowed := allowedUploadTypes[contentType]
+	if !allowed {
+		http.Error(w, "Unsupported file type: "+contentType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var width, height *int
+	if storage.ThumbnailContentTypes[contentType] {
+		if imgW, imgH, err := storage.ImageSize(data); err == nil {
+			if int64(imgW)*int64(imgH) > storage.MaxImagePixels {
+				http.Error(w, fmt.Sprintf("Image exceeds the %d pixel limit", storage.MaxImagePixels), http.StatusRequestEntityTooLarge)
+				return
+			}
+			width, height = &imgW, &imgH
+		}
+	}
+
+	name, err := randomHex(16)
+	if err != nil {
+		http.Error(w, "Error generating file name", http.StatusInternalServerError)
+		return
+	}
+	key := fmt.Sprintf("campaigns/%d/%s%s", campaignID, name, ext)
+
+	if err := h.store.Put(key, bytes.NewReader(data), contentType); err != nil {
+		log.Printf("Error storing upload: %v", err)
+		http.Error(w, "Error storing file", http.StatusInternalServerError)
+		return
+	}
+
+	var thumbnailKey, thumbnailURL *string
+	if storage.ThumbnailContentTypes[contentType] {
+		thumb, err := storage.Thumbnail(data, thumbnailMaxDim)
+		if err != nil {
+			// The original is still useful without a preview
+			log.Printf("Error generating thumbnail for %s: %v", key, err)
+		} else {
+			tk := fmt.Sprintf("campaigns/%d/%s_thumb.png", campaignID, name)
+			if err := h.store.Put(tk, bytes.NewReader(thumb), "image/png"); err != nil {
+				log.Printf("Error storing thumbnail for %s: %v", key, err)
+			} else {
+				tu := h.store.URL(tk)
+				thumbnailKey, thumbnailURL = &tk, &tu
+			}
+		}
+	}
+
+	var description *string
+	if d := r.FormValue("description"); d != "" {
+		description = &d
+	}
+	filename := header.Filename
+	size := int64(len(data))
+
+	var image models.CampaignImage
+	query := `
+		INSERT INTO campaign_images AS ci (
+			campaign_id, url, thumbnail_url, description, scene_id, storage_key, thumbnail_key,
+			original_filename, content_type, size_bytes, width, height, uploaded_by_user_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING ` + imageColumns
+	err = h.db.QueryRowx(query,
+		campaignID, h.store.URL(key), thumbnailURL, description, sceneID, key, thumbnailKey,
+		filename, contentType, size, width, height, userID,
+	).StructScan(&image)
+	if err != nil {
+		log.Printf("Error saving image record: %v", err)
+		h.deleteBlobs(&key, thumbnailKey)
+		http.Error(w, "Error saving image", http.StatusInternalServerError)
+		return
+	}
+
+	signImageURLs(h.store, &image)
+
+	// Players first learn of a handout when the GM shows it
+	h.hub.BroadcastToUsers(campaignID, []int{userID}, websocket.MessageTypeImageUpdate, map[string]any{
+		"action": "uploaded",
+		"image":  image,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(image)
+}
+
+// ListByCampaign returns the campaign's images the caller may see; players only get shown ones
+func (h *ImageHandler) ListByCampaign(w http.ResponseWriter, r *http.Request) {
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	query := `
+		SELECT ` + imageColumns + `
+		FROM campaign_images ci
+		WHERE ci.campaign_id = $1 AND ` + imageVisibleToUser + `
+		ORDER BY ci.uploaded_at DESC
+	`
+
+	var images []models.CampaignImage
+	err = h.db.Select(&images, query, campaignID, userID)
+	if err != nil {
+		log.Printf("Error fetching images: %v", err)
+		http.Error(w, "Error fetching images", http.StatusInternalServerError)
+		return
+	}
+
+	if images == nil {
+		images = []models.CampaignImage{}
+	}
+	for i := range images {
+		signImageURLs(h.store, &images[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(images)
+}
+
+// Delete removes an image and its stored files (GM only)
+func (h *ImageHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	imageID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid image ID", http.StatusBadRequest)
+		return
+	}
+
+	var info struct {
+		GMUserID     int        `db:"gm_user_id"`
+		CampaignID   int        `db:"campaign_id"`
+		StorageKey   *string    `db:"storage_key"`
+		ThumbnailKey *string    `db:"thumbnail_key"`
+		ShownAt      *time.Time `db:"shown_at"`
+	}
+	query := `
+		SELECT c.gm_user_id, ci.campaign_id, ci.storage_key, ci.thumbnail_key, ci.shown_at
+		FROM campaign_images ci
+		JOIN campaigns c ON ci.campaign_id = c.id
+		WHERE ci.id = $1
+	`
+	err = h.db.Get(&info, query, imageID)
+	if err != nil {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+	if info.GMUserID != userID {
+		http.Error(w, "Only the GM can delete images", http.StatusForbidden)
+		return
+	}
+
+	_, err = h.db.Exec("DELETE FROM campaign_images WHERE id = $1", imageID)
+	if err != nil {
+		http.Error(w, "Error deleting image", http.StatusInternalServerError)
+		return
+	}
+
+	h.deleteBlobs(info.StorageKey, info.ThumbnailKey)
+
+	h.broadcastImage(info.CampaignID, info.GMUserID, info.ShownAt != nil, map[string]any{
+		"action":   "deleted",
+		"image_id": imageID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Image deleted successfully"})
+}
+
+// Show pushes an image to every client connected to the campaign (GM only)
+func (h *ImageHandler) Show(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	imageID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid image ID", http.StatusBadRequest)
+		return
+	}
+
+	var gmUserID int
+	query := `
+		SELECT c.gm_user_id
+		FROM campaign_images ci
+		JOIN campaigns c ON ci.campaign_id = c.id
+		WHERE ci.id = $1
+	`
+	err = h.db.Get(&gmUserID, query, imageID)
+	if err != nil {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+	if gmUserID != userID {
+		http.Error(w, "Only the GM can show images", http.StatusForbidden)
+		return
+	}
+
+	// Showing an image releases it to the players for good
+	var image models.CampaignImage
+	updateQuery := `
+		UPDATE campaign_images ci SET shown_at = COALESCE(ci.shown_at, CURRENT_TIMESTAMP)
+		WHERE ci.id = $1
+		RETURNING ` + imageColumns
+	err = h.db.QueryRowx(updateQuery, imageID).StructScan(&image)
+	if err != nil {
+		log.Printf("Error marking image shown: %v", err)
+		http.Error(w, "Error showing image", http.StatusInternalServerError)
+		return
+	}
+	signImageURLs(h.store, &image)
+
+	h.hub.BroadcastToCampaign(image.CampaignID, websocket.MessageTypeImageShown, map[string]any{
+		"image": image,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(image)
+}
+
+// SetScene moves an image into one of the campaign's scenes, or out of any scene (GM only)
+func (h *ImageHandler) SetScene(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	imageID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid image ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetSceneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var info struct {
+		GMUserID   int `db:"gm_user_id"`
+		CampaignID int `db:"campaign_id"`
+	}
+	query := `
+		SELECT c.gm_user_id, ci.campaign_id
+		FROM campaign_images ci
+		JOIN campaigns c ON ci.campaign_id = c.id
+		WHERE ci.id = $1
+	`
+	err = h.db.Get(&info, query, imageID)
+	if err != nil {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+	if info.GMUserID != userID {
+		http.Error(w, "Only the GM can move images between scenes", http.StatusForbidden)
+		return
+	}
+
+	if req.SceneID != nil {
+		inCampaign, err := sceneInCampaign(h.db, *req.SceneID, info.CampaignID)
+		if err != nil {
+			log.Printf("Error checking scene: %v", err)
+			http.Error(w, "Error updating image", http.StatusInternalServerError)
+			return
+		}
+		if !inCampaign {
+			http.Error(w, "Scene not found in this campaign", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var image models.CampaignImage
+	updateQuery := `
+		UPDATE campaign_images ci SET scene_id = $1
+		WHERE ci.id = $2
+		RETURNING ` + imageColumns
+	err = h.db.QueryRowx(updateQuery, req.SceneID, imageID).StructScan(&image)
+	if err != nil {
+		log.Printf("Error updating image scene: %v", err)
+		http.Error(w, "Error updating image", http.StatusInternalServerError)
+		return
+	}
+
+	signImageURLs(h.store, &image)
+
+	h.broadcastImage(info.CampaignID, info.GMUserID, image.ShownAt != nil, map[string]any{
+		"action": "updated",
+		"image":  image,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(image)
+}
+
+// broadcastImage sends an image update to the campaign, or only to the GM
+// while the image hasn't been shown
+func (h *ImageHandler) broadcastImage(campaignID, gmUserID int, shown bool, payload map[string]any) {
+	if shown {
+		h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeImageUpdate, payload)
+		return
+	}
+	h.hub.BroadcastToUsers(campaignID, []int{gmUserID}, websocket.MessageTypeImageUpdate, payload)
+}
+
+// signImageURLs points an image at signed, expiring URLs for its stored files.
+// Images with no stored files keep the URL they were created with.
+func signImageURLs(store storage.BlobStore, image *models.CampaignImage) {
+	if image.StorageKey != nil {
+		image.URL = store.SignedURL(*image.StorageKey)
+	}
+	if image.ThumbnailKey != nil {
+		url := store.SignedURL(*image.ThumbnailKey)
+		image.ThumbnailURL = &url
+	}
+}
+
+// deleteBlobs removes stored files, logging rather than failing on errors
+func (h *ImageHandler) deleteBlobs(keys ...*string) {
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+		if err := h.store.Delete(*key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Error deleting blob %s: %v", *key, err)
+		}
+	}
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const itemColumns = `
+	id, character_id, name, description, quantity, d6_modifier, consumable,
+	granted_by_user_id, created_at, updated_at
+`
+
+type ItemHandler struct {
+	db  *database.Database
+	hub *websocket.Hub
+}
+
+func NewItemHandler(db *database.Database, hub *websocket.Hub) *ItemHandler {
+	return &ItemHandler{db: db, hub: hub}
+}
+
+// consumeItems uses up one of each given item, removing stacks that run out
+func consumeItems(tx *sqlx.Tx, itemIDs []int) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec("DELETE FROM character_items WHERE id = ANY($1) AND quantity <= 1", pq.Array(itemIDs))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE character_items
+		SET quantity = quantity - 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ANY($1)
+	`, pq.Array(itemIDs))
+	return err
+}
+
+// loadRollItems returns the items declared on each given roll keyed by roll ID
+func loadRollItems(db *database.Database, rollIDs []int) (map[int][]models.RollItem, error) {
+	byRoll := make(map[int][]models.RollItem, len(rollIDs))
+	if len(rollIDs) == 0 {
+		return byRoll, nil
+	}
+
+	var items []models.RollItem
+	query := `
+		SELECT roll_id, item_id, name, d6_modifier, consumed
+		FROM roll_history_items
+		WHERE roll_id = ANY($1)
+		ORDER BY roll_id ASC, name ASC
+	`
+	if err := db.Select(&items, query, pq.Array(rollIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		byRoll[item.RollID] = append(byRoll[item.RollID], item)
+	}
+	return byRoll, nil
+}
+
+func (h *ItemHandler) broadcast(characterID int, action string, item any) {
+	broadcastForCharacter(h.db, h.hub, characterID, websocket.MessageTypeInventoryUpdate, map[string]any{
+		"action":       action,
+		"character_id": characterID,
+		"item":         item,
+	})
+}
+
+// loadItemForGM fetches an item and checks the user is its campaign's GM
+func (h *ItemHandler) loadItemForGM(w http.ResponseWriter, r *http.Request, action string) (*models.CharacterItem, int, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, 0, false
+	}
+
+	itemID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return nil, 0, false
+	}
+
+	var item models.CharacterItem
+	err = h.db.Get(&item, "SELECT "+itemColumns+" FROM character_items WHERE id = $1", itemID)
+	if err != nil {
+		http.Error(w, "Item not found", http.StatusNotFound)
+		return nil, 0, false
+	}
+
+	var info struct {
+		CampaignID int `db:"campaign_id"`
+		GMUserID   int `db:"gm_user_id"`
+	}
+	err = h.db.Get(&info, `
+		SELECT ch.campaign_id, ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, item.CharacterID)
+	if err != nil || info.GMUserID != userID {
+		http.Error(w, "Only the GM can "+action+" items", http.StatusForbidden)
+		return nil, 0, false
+	}
+
+	return &item, info.CampaignID, true
+}
+
+// ListByCharacter returns everything a character is carrying
+func (h *ItemHandler) ListByCharacter(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
+
+	var items []models.CharacterItem
+	query := "SELECT " + itemColumns + " FROM character_items WHERE character_id = $1 ORDER BY name ASC, id ASC"
+	err = h.db.Select(&items, query, characterID)
+	if err != nil {
+		log.Printf("Error fetching items: %v", err)
+		http.Error(w, "Error fetching items", http.StatusInternalServerError)
+		return
+	}
+
+	if items == nil {
+		items = []models.CharacterItem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// Grant gives a character a new item (GM only)
+func (h *ItemHandler) Grant(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+
+	var info struct {
+		CampaignID int `db:"campaign_id"`
+		GMUserID   int `db:"gm_user_id"`
+	}
+	err = h.db.Get(&info, `
+		SELECT ch.campaign_id, ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return
+	}
+	if info.GMUserID != userID {
+		http.Error(w, "Only the GM can grant items", http.StatusForbidden)
+		return
+	}
+
+	var req models.GrantItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "Item name is required", http.StatusBadRequest)
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	if req.Quantity < 1 {
+		http.Error(w, "quantity must be at least 1", http.StatusBadRequest)
+		return
+	}
+	if req.D6Modifier < -5 || req.D6Modifier > 5 {
+		http.Error(w, "d6_modifier must be between -5 and 5", http.StatusBadRequest)
+		return
+	}
+
+	var item models.CharacterItem
+	query := `
+		INSERT INTO character_items (character_id, name, description, quantity, d6_modifier, consumable, granted_by_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + itemColumns
+	err = h.db.QueryRowx(query, characterID, req.Name, req.Description, req.Quantity, req.D6Modifier, req.Consumable, userID).StructScan(&item)
+	if err != nil {
+		log.Printf("Error granting item: %v", err)
+		http.Error(w, "Error granting item", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcast(characterID, "granted", item)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// Remove takes an item away (GM only). ?quantity=N removes part of a stack.
+func (h *ItemHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	item, _, ok := h.loadItemForGM(w, r, "remove")
+	if !ok {
+		return
+	}
+
+	quantity := item.Quantity
+	if q := r.URL.Query().Get("quantity"); q != "" {
+		n, err := strconv.Atoi(q)
+		if err != nil || n < 1 {
+			http.Error(w, "quantity must be a positive number", http.StatusBadRequest)
+			return
+		}
+		quantity = n
+	}
+	if quantity > item.Quantity {
+		http.Error(w, "Character doesn't have that many", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if quantity == item.Quantity {
+		_, err = h.db.Exec("DELETE FROM character_items WHERE id = $1", item.ID)
+		item.Quantity = 0
+	} else {
+		err = h.db.QueryRowx(`
+			UPDATE character_items
+			SET quantity = quantity - $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+			RETURNING `+itemColumns, quantity, item.ID).StructScan(item)
+	}
+	if err != nil {
+		log.Printf("Error removing item: %v", err)
+		http.Error(w, "Error removing item", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcast(item.CharacterID, "removed", item)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// Transfer moves some or all of a stack to another character in the same campaign (GM only)
+func (h *ItemHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	item, campaignID, ok := h.loadItemForGM(w, r, "transfer")
+	if !ok {
+		return
+	}
+
+	var req models.TransferItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Quantity == 0 {
+		req.Quantity = item.Quantity
+	}
+	if req.Quantity < 1 || req.Quantity > item.Quantity {
+		http.Error(w, "quantity must be between 1 and the number carried", http.StatusBadRequest)
+		return
+	}
+	if req.ToCharacterID == item.CharacterID {
+		http.Error(w, "Item already belongs to that character", http.StatusBadRequest)
+		return
+	}
+
+	var targetCampaignID int
+	err := h.db.Get(&targetCampaignID, "SELECT campaign_id FROM characters WHERE id = $1", req.ToCharacterID)
+	if err != nil || targetCampaignID != campaignID {
+		http.Error(w, "Items can only be transferred within the same campaign", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error transferring item", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	fromCharacterID := item.CharacterID
+	var moved models.CharacterItem
+	if req.Quantity == item.Quantity {
+		err = tx.QueryRowx(`
+			UPDATE character_items
+			SET character_id = $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+			RETURNING `+itemColumns, req.ToCharacterID, item.ID).StructScan(&moved)
+		item.Quantity = 0
+	} else {
+		// Split the stack; the remainder keeps the original row
+		err = tx.QueryRowx(`
+			UPDATE character_items
+			SET quantity = quantity - $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+			RETURNING `+itemColumns, req.Quantity, item.ID).StructScan(item)
+		if err == nil {
+			err = tx.QueryRowx(`
+				INSERT INTO character_items (character_id, name, description, quantity, d6_modifier, consumable, granted_by_user_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				RETURNING `+itemColumns,
+				req.ToCharacterID, item.Name, item.Description, req.Quantity, item.D6Modifier, item.Consumable, item.GrantedByUserID,
+			).StructScan(&moved)
+		}
+	}
+	if err != nil {
+		log.Printf("Error transferring item: %v", err)
+		http.Error(w, "Error transferring item", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error transferring item", http.StatusInternalServerError)
+		return
+	}
+
+	payload := map[string]any{
+		"action":            "transferred",
+		"from_character_id": fromCharacterID,
+		"character_id":      req.ToCharacterID,
+		"item":              moved,
+		"remaining":         item.Quantity,
+	}
+	// A hidden NPC on either side keeps the whole transfer for the GM's eyes
+	from, err := loadCharacterVisibility(h.db, fromCharacterID)
+	var to characterVisibility
+	if err == nil {
+		to, err = loadCharacterVisibility(h.db, req.ToCharacterID)
+	}
+	switch {
+	case err != nil:
+		log.Printf("Error checking character visibility: %v", err)
+	case from.Hidden || to.Hidden:
+		h.hub.BroadcastToUsers(campaignID, []int{from.GMUserID}, websocket.MessageTypeInventoryUpdate, payload)
+	default:
+		h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeInventoryUpdate, payload)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(moved)
+}
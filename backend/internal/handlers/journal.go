@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+// journalEntrySelect loads entries with their author and recipient list
+const journalEntrySelect = `
+	SELECT
+		je.id, je.campaign_id, je.author_user_id, u.username as author_name,
+		je.title, je.body, je.campaign_day, je.visibility, je.revision,
+		je.created_at, je.updated_at,
+		COALESCE(
+			(SELECT array_agg(jr.user_id ORDER BY jr.user_id) FROM journal_entry_recipients jr WHERE jr.entry_id = je.id),
+			'{}'
+		) as recipient_user_ids
+	FROM journal_entries je
+	LEFT JOIN users u ON je.author_user_id = u.id
+`
+
+var (
+	// Elements whose contents should never survive, not just their tags
+	dangerousBlockPattern = regexp.MustCompile(`(?is)<(script|style|iframe|object|embed)\b.*?</(script|style|iframe|object|embed)\s*>`)
+	htmlCommentPattern    = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlTagPattern        = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	unsafeLinkPattern     = regexp.MustCompile(`(?i)\]\(\s*(javascript|vbscript|data):(?:[^()\s]|\([^()]*\))*\)`)
+)
+
+// sanitizeMarkdown strips raw HTML and script links so entries are safe to render
+func sanitizeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = dangerousBlockPattern.ReplaceAllString(s, "")
+	s = htmlCommentPattern.ReplaceAllString(s, "")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = unsafeLinkPattern.ReplaceAllString(s, "](#)")
+	return strings.TrimSpace(s)
+}
+
+func validJournalVisibility(v string) bool {
+	return v == models.JournalVisibilityGM || v == models.JournalVisibilityMembers || v == models.JournalVisibilityPlayers
+}
+
+// canReadJournalEntry applies an entry's visibility rules to a reader
+func canReadJournalEntry(entry *models.JournalEntry, userID int, isGM bool) bool {
+	if isGM || (entry.AuthorUserID != nil && *entry.AuthorUserID == userID) {
+		return true
+	}
+	switch entry.Visibility {
+	case models.JournalVisibilityMembers:
+		return true
+	case models.JournalVisibilityPlayers:
+		for _, id := range entry.RecipientUserIDs {
+			if int(id) == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type JournalHandler struct {
+	db  *database.Database
+	hub *websocket.Hub
+}
+
+func NewJournalHandler(db *database.Database, hub *websocket.Hub) *JournalHandler {
+	return &JournalHandler{db: db, hub: hub}
+}
+
+// campaignAccess reports whether the user is the campaign's GM (admins count as GM
+// for reading) and whether they belong to the campaign at all
+func (h *JournalHandler) campaignAccess(r *http.Request, campaignID, userID int) (gmUserID int, isGM bool, isMember bool, err error) {
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		return 0, false, false, err
+	}
+
+	userRole, _ := middleware.GetUserRole(r.Context())
+	isGM = gmUserID == userID || userRole == models.RoleAdmin
+	if isGM {
+		return gmUserID, true, true, nil
+	}
+
+	err = h.db.Get(&isMember, "SELECT EXISTS(SELECT 1 FROM campaign_members WHERE campaign_id = $1 AND user_id = $2)", campaignID, userID)
+	return gmUserID, false, isMember, err
+}
+
+// validateRecipients checks every recipient is a member of the campaign
+func (h *JournalHandler) validateRecipients(campaignID int, userIDs []int) bool {
+	unique := make(map[int]bool, len(userIDs))
+	for _, id := range userIDs {
+		unique[id] = true
+	}
+
+	var count int
+	err := h.db.Get(&count, "SELECT COUNT(*) FROM campaign_members WHERE campaign_id = $1 AND user_id = ANY($2)", campaignID, pq.Array(userIDs))
+	return err == nil && count == len(unique)
+}
+
+// notify tells everyone who can see an entry that it changed. GM-only entries aren't broadcast.
+func (h *JournalHandler) notify(entry *models.JournalEntry, gmUserID int, action string) {
+	payload := map[string]any{
+		"action": action,
+		"entry":  entry,
+	}
+
+	switch entry.Visibility {
+	case models.JournalVisibilityMembers:
+		h.hub.BroadcastToCampaign(entry.CampaignID, websocket.MessageTypeJournalUpdate, payload)
+	case models.JournalVisibilityPlayers:
+		userIDs := []int{gmUserID}
+		if entry.AuthorUserID != nil {
+			userIDs = append(userIDs, *entry.AuthorUserID)
+		}
+		for _, id := range entry.RecipientUserIDs {
+			userIDs = append(userIDs, int(id))
+		}
+		h.hub.BroadcastToUsers(entry.CampaignID, userIDs, websocket.MessageTypeJournalUpdate, payload)
+	}
+}
+
+// Create adds a journal entry. Any member can write shared entries; only the GM can write GM-only ones.
+func (h *JournalHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateJournalEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	title := strings.TrimSpace(htmlTagPattern.ReplaceAllString(req.Title, ""))
+	body := sanitizeMarkdown(req.Body)
+	if title == "" || body == "" {
+		http.Error(w, "Title and body are required", http.StatusBadRequest)
+		return
+	}
+
+	if req.Visibility == "" {
+		req.Visibility = models.JournalVisibilityMembers
+	}
+	if !validJournalVisibility(req.Visibility) {
+		http.Error(w, "Invalid visibility. Must be: gm, members, or players", http.StatusBadRequest)
+		return
+	}
+
+	gmUserID, _, isMember, err := h.campaignAccess(r, campaignID, userID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+	if !isMember {
+		http.Error(w, "You are not a member of this campaign", http.StatusForbidden)
+		return
+	}
+	if req.Visibility == models.JournalVisibilityGM && gmUserID != userID {
+		http.Error(w, "Only the GM can write GM-only entries", http.StatusForbidden)
+		return
+	}
+
+	if req.Visibility == models.JournalVisibilityPlayers {
+		if len(req.RecipientUserIDs) == 0 {
+			http.Error(w, "At least one recipient is required for players visibility", http.StatusBadRequest)
+			return
+		}
+		if !h.validateRecipients(campaignID, req.RecipientUserIDs) {
+			http.Error(w, "All recipients must be members of this campaign", http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.RecipientUserIDs = nil
+	}
+
+	var day int
+	if req.CampaignDay != nil {
+		day = *req.CampaignDay
+	} else if err := h.db.Get(&day, "SELECT current_day FROM campaigns WHERE id = $1", campaignID); err != nil {
+		http.Error(w, "Error fetching campaign day", http.StatusInternalServerError)
+		return
+	}
+	if day < 1 {
+		http.Error(w, "Campaign day must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating journal entry", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var entryID int
+	err = tx.Get(&entryID, `
+		INSERT INTO journal_entries (campaign_id, author_user_id, title, body, campaign_day, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, campaignID, userID, title, body, day, req.Visibility)
+	if err != nil {
+		log.Printf("Error creating journal entry: %v", err)
+		http.Error(w, "Error creating journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	for _, recipientID := range req.RecipientUserIDs {
+		_, err = tx.Exec(`
+			INSERT INTO journal_entry_recipients (entry_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, entryID, recipientID)
+		if err != nil {
+			log.Printf("Error adding journal recipient: %v", err)
+			http.Error(w, "Error creating journal entry", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO journal_entry_revisions (entry_id, revision, title, body, campaign_day, visibility, edited_by_user_id)
+		VALUES ($1, 1, $2, $3, $4, $5, $6)
+	`, entryID, title, body, day, req.Visibility, userID)
+	if err != nil {
+		log.Printf("Error recording journal revision: %v", err)
+		http.Error(w, "Error creating journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	var entry models.JournalEntry
+	err = h.db.Get(&entry, journalEntrySelect+" WHERE je.id = $1", entryID)
+	if err != nil {
+		http.Error(w, "Error fetching journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	h.notify(&entry, gmUserID, "created")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// ListByCampaign returns the entries the caller is allowed to see, optionally for one day
+func (h *JournalHandler) ListByCampaign(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var day *int
+	if d := r.URL.Query().Get("day"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			http.Error(w, "Invalid day", http.StatusBadRequest)
+			return
+		}
+		day = &n
+	}
+
+	_, isGM, isMember, err := h.campaignAccess(r, campaignID, userID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+	if !isMember {
+		http.Error(w, "You are not a member of this campaign", http.StatusForbidden)
+		return
+	}
+
+	query := journalEntrySelect + `
+		WHERE je.campaign_id = $1
+		  AND ($2::int IS NULL OR je.campaign_day = $2)
+		  AND (
+			$3::boolean
+			OR je.visibility = 'members'
+			OR je.author_user_id = $4
+			OR (je.visibility = 'players' AND EXISTS(
+				SELECT 1 FROM journal_entry_recipients jr WHERE jr.entry_id = je.id AND jr.user_id = $4
+			))
+		  )
+		ORDER BY je.campaign_day DESC, je.created_at DESC
+	`
+
+	var entries []models.JournalEntry
+	err = h.db.Select(&entries, query, campaignID, day, isGM, userID)
+	if err != nil {
+		log.Printf("Error fetching journal: %v", err)
+		http.Error(w, "Error fetching journal", http.StatusInternalServerError)
+		return
+	}
+
+	if entries == nil {
+		entries = []models.JournalEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// Get returns a single entry if the caller can see it
+func (h *JournalHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var entry models.JournalEntry
+	err = h.db.Get(&entry, journalEntrySelect+" WHERE je.id = $1", entryID)
+	if err != nil {
+		http.Error(w, "Journal entry not found", http.StatusNotFound)
+		return
+	}
+
+	_, isGM, isMember, err := h.campaignAccess(r, entry.CampaignID, userID)
+	if err != nil || !isMember || !canReadJournalEntry(&entry, userID, isGM) {
+		http.Error(w, "Journal entry not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// Update edits an entry and records a new revision (author or GM only)
+func (h *JournalHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var entry models.JournalEntry
+	err = h.db.Get(&entry, journalEntrySelect+" WHERE je.id = $1", entryID)
+	if err != nil {
+		http.Error(w, "Journal entry not found", http.StatusNotFound)
+		return
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", entry.CampaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	isAuthor := entry.AuthorUserID != nil && *entry.AuthorUserID == userID
+	if gmUserID != userID && !isAuthor {
+		http.Error(w, "Only the author or the GM can edit this entry", http.StatusForbidden)
+		return
+	}
+
+	var req models.UpdateJournalEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	title := entry.Title
+	if req.Title != nil {
+		title = strings.TrimSpace(htmlTagPattern.ReplaceAllString(*req.Title, ""))
+	}
+	body := entry.Body
+	if req.Body != nil {
+		body = sanitizeMarkdown(*req.Body)
+	}
+	if title == "" || body == "" {
+		http.Error(w, "Title and body are required", http.StatusBadRequest)
+		return
+	}
+
+	day := entry.CampaignDay
+	if req.CampaignDay != nil {
+		day = *req.CampaignDay
+	}
+	if day < 1 {
+		http.Error(w, "Campaign day must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	visibility := entry.Visibility
+	if req.Visibility != nil {
+		visibility = *req.Visibility
+	}
+	if !validJournalVisibility(visibility) {
+		http.Error(w, "Invalid visibility. Must be: gm, members, or players", http.StatusBadRequest)
+		return
+	}
+	if visibility == models.JournalVisibilityGM && gmUserID != userID {
+		http.Error(w, "Only the GM can make an entry GM-only", http.StatusForbidden)
+		return
+	}
+
+	recipients := req.RecipientUserIDs
+	replaceRecipients := recipients != nil || visibility != models.JournalVisibilityPlayers
+	if visibility == models.JournalVisibilityPlayers {
+		if recipients == nil {
+			for _, id := range entry.RecipientUserIDs {
+				recipients = append(recipients, int(id))
+			}
+		}
+		if len(recipients) == 0 {
+			http.Error(w, "At least one recipient is required for players visibility", http.StatusBadRequest)
+			return
+		}
+		if !h.validateRecipients(entry.CampaignID, recipients) {
+			http.Error(w, "All recipients must be members of this campaign", http.StatusBadRequest)
+			return
+		}
+	} else {
+		recipients = nil
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error updating journal entry", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var revision int
+	err = tx.Get(&revision, `
+		UPDATE journal_entries
+		SET title = $1, body = $2, campaign_day = $3, visibility = $4,
+		    revision = revision + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+		RETURNING revision
+	`, title, body, day, visibility, entryID)
+	if err != nil {
+		log.Printf("Error updating journal entry: %v", err)
+		http.Error(w, "Error updating journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	if replaceRecipients {
+		if _, err := tx.Exec("DELETE FROM journal_entry_recipients WHERE entry_id = $1", entryID); err != nil {
+			http.Error(w, "Error updating journal entry", http.StatusInternalServerError)
+			return
+		}
+		for _, recipientID := range recipients {
+			_, err = tx.Exec(`
+				INSERT INTO journal_entry_recipients (entry_id, user_id)
+				VALUES ($1, $2)
+				ON CONFLICT DO NOTHING
+			`, entryID, recipientID)
+			if err != nil {
+				http.Error(w, "Error updating journal entry", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO journal_entry_revisions (entry_id, revision, title, body, campaign_day, visibility, edited_by_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, entryID, revision, title, body, day, visibility, userID)
+	if err != nil {
+		log.Printf("Error recording journal revision: %v", err)
+		http.Error(w, "Error updating journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.Get(&entry, journalEntrySelect+" WHERE je.id = $1", entryID)
+	if err != nil {
+		http.Error(w, "Error fetching journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	h.notify(&entry, gmUserID, "updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// Delete removes an entry and its history (author or GM only)
+func (h *JournalHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var entry models.JournalEntry
+	err = h.db.Get(&entry, journalEntrySelect+" WHERE je.id = $1", entryID)
+	if err != nil {
+		http.Error(w, "Journal entry not found", http.StatusNotFound)
+		return
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", entry.CampaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	isAuthor := entry.AuthorUserID != nil && *entry.AuthorUserID == userID
+	if gmUserID != userID && !isAuthor {
+		http.Error(w, "Only the author or the GM can delete this entry", http.StatusForbidden)
+		return
+	}
+
+	_, err = h.db.Exec("DELETE FROM journal_entries WHERE id = $1", entryID)
+	if err != nil {
+		http.Error(w, "Error deleting journal entry", http.StatusInternalServerError)
+		return
+	}
+
+	h.notify(&entry, gmUserID, "deleted")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Journal entry deleted successfully"})
+}
+
+// ListRevisions returns every saved version of an entry, newest first
+func (h *JournalHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entryID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var entry models.JournalEntry
+	err = h.db.Get(&entry, journalEntrySelect+" WHERE je.id = $1", entryID)
+	if err != nil {
+		http.Error(w, "Journal entry not found", http.StatusNotFound)
+		return
+	}
+
+	_, isGM, isMember, err := h.campaignAccess(r, entry.CampaignID, userID)
+	if err != nil || !isMember || !canReadJournalEntry(&entry, userID, isGM) {
+		http.Error(w, "Journal entry not found", http.StatusNotFound)
+		return
+	}
+
+	query := `
+		SELECT
+			jr.id, jr.entry_id, jr.revision, jr.title, jr.body, jr.campaign_day,
+			jr.visibility, jr.edited_by_user_id, u.username as edited_by_name, jr.created_at
+		FROM journal_entry_revisions jr
+		LEFT JOIN users u ON jr.edited_by_user_id = u.id
+		WHERE jr.entry_id = $1
+		ORDER BY jr.revision DESC
+	`
+
+	var revisions []models.JournalEntryRevision
+	err = h.db.Select(&revisions, query, entryID)
+	if err != nil {
+		log.Printf("Error fetching journal revisions: %v", err)
+		http.Error(w, "Error fetching revisions", http.StatusInternalServerError)
+		return
+	}
+
+	if revisions == nil {
+		revisions = []models.JournalEntryRevision{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// visibleToUser filters queries joining characters c and campaigns ca down to
+// what the user in $2 may see
+const visibleToUser = `(c.kind = 'pc' OR c.is_revealed OR ca.gm_user_id = $2)`
+
+// characterVisibility is who may see a character: hidden NPCs are for the GM's eyes only
+type characterVisibility struct {
+	CampaignID int  `db:"campaign_id"`
+	GMUserID   int  `db:"gm_user_id"`
+	Hidden     bool `db:"hidden"`
+}
+
+func loadCharacterVisibility(q sqlx.Queryer, characterID int) (characterVisibility, error) {
+	var v characterVisibility
+	err := sqlx.Get(q, &v, `
+		SELECT ch.campaign_id, ca.gm_user_id, (ch.kind = 'npc' AND NOT ch.is_revealed) as hidden
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	return v, err
+}
+
+// broadcastForCharacter sends a character's update to the whole campaign,
+// or only to the GM while the character is a hidden NPC
+func broadcastForCharacter(db *database.Database, hub *websocket.Hub, characterID int, msgType websocket.MessageType, payload map[string]any) {
+	v, err := loadCharacterVisibility(db, characterID)
+	if err != nil {
+		log.Printf("Error checking character visibility: %v", err)
+		return
+	}
+	if v.Hidden {
+		hub.BroadcastToUsers(v.CampaignID, []int{v.GMUserID}, msgType, payload)
+		return
+	}
+	hub.BroadcastToCampaign(v.CampaignID, msgType, payload)
+}
+
+// checkCharacterVisible answers 404 for a hidden NPC unless the user is the
+// GM, as CharacterHandler.Get does. It returns false when it wrote a response.
+func checkCharacterVisible(w http.ResponseWriter, r *http.Request, db *database.Database, characterID int) bool {
+	v, err := loadCharacterVisibility(db, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return false
+	}
+	userID, _ := middleware.GetUserID(r.Context())
+	if v.Hidden && v.GMUserID != userID {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// checkNPCRoller stops anyone but the GM rolling for an NPC. It writes the
+// error response and returns false when the roll isn't allowed.
+func checkNPCRoller(w http.ResponseWriter, r *http.Request, db *database.Database, characterID int) bool {
+	var info struct {
+		Kind     string `db:"kind"`
+		GMUserID int    `db:"gm_user_id"`
+	}
+	err := db.Get(&info, `
+		SELECT ch.kind, ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return false
+	}
+	if info.Kind != models.CharacterKindNPC {
+		return true
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	if userID != info.GMUserID {
+		http.Error(w, "Only the GM can roll for NPCs", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// ListNPCs returns a campaign's NPCs. The GM sees all of them with their
+// stat blocks; players only see the ones that have been revealed.
+func (h *CharacterHandler) ListNPCs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+	isGM := gmUserID == userID
+
+	includeRetired := r.URL.Query().Get("include_retired") == "true"
+
+	var npcs []models.Character
+	err = h.db.Select(&npcs, `
+		SELECT `+characterColumns+`
+		FROM characters
+		WHERE campaign_id = $1 AND kind = 'npc'
+		  AND ($2::boolean OR is_revealed)
+		  AND ($3::boolean OR status = 'active')
+		ORDER BY name ASC, id ASC
+	`, campaignID, isGM, includeRetired)
+	if err != nil {
+		log.Printf("Error fetching NPCs: %v", err)
+		http.Error(w, "Error fetching NPCs", http.StatusInternalServerError)
+		return
+	}
+
+	if npcs == nil {
+		npcs = []models.Character{}
+	}
+
+	ids := make([]int, len(npcs))
+	for i, c := range npcs {
+		ids[i] = c.ID
+	}
+	traits, err := loadCharacterTraits(h.db, ids)
+	if err != nil {
+		http.Error(w, "Error fetching NPCs", http.StatusInternalServerError)
+		return
+	}
+	for i := range npcs {
+		npcs[i].Traits = traits[npcs[i].ID]
+		if !isGM {
+			npcs[i].StatBlock = nil
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(npcs)
+}
+
+// CreateNPCs adds one or more NPCs from quick stat blocks in a single go (GM only)
+func (h *CharacterHandler) CreateNPCs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+	if gmUserID != userID {
+		http.Error(w, "Only the GM can create NPCs", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateNPCsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.NPCs) == 0 || len(req.NPCs) > 50 {
+		http.Error(w, "Between 1 and 50 NPCs can be created at once", http.StatusBadRequest)
+		return
+	}
+	for i := range req.NPCs {
+		npc := &req.NPCs[i]
+		npc.Name = strings.TrimSpace(npc.Name)
+		if npc.Name == "" {
+			http.Error(w, "Every NPC needs a name", http.StatusBadRequest)
+			return
+		}
+		if npc.MaxDailyDice != nil && (*npc.MaxDailyDice < 1 || *npc.MaxDailyDice > 20) {
+			http.Error(w, "max_daily_dice must be between 1 and 20", http.StatusBadRequest)
+			return
+		}
+		if npc.SkillModifier < -5 || npc.SkillModifier > 5 || npc.WeaknessModifier < -5 || npc.WeaknessModifier > 5 {
+			http.Error(w, "Modifiers must be between -5 and 5", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating NPCs", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	created := make([]models.Character, 0, len(req.NPCs))
+	for _, npc := range req.NPCs {
+		var character models.Character
+		err = tx.QueryRowx(`
+			INSERT INTO characters (
+				campaign_id, name, description, skill_name, skill_modifier, weakness_name, weakness_modifier,
+				max_daily_dice, kind, is_revealed, stat_block
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7,
+			        COALESCE($8, (SELECT (settings->>'default_max_daily_dice')::int FROM campaigns WHERE id = $1), 3),
+			        'npc', $9, $10)
+			RETURNING `+characterColumns,
+			campaignID, npc.Name, npc.Description, npc.SkillName, npc.SkillModifier, npc.WeaknessName, npc.WeaknessModifier,
+			npc.MaxDailyDice, npc.IsRevealed, npc.StatBlock,
+		).StructScan(&character)
+		if err != nil {
+			log.Printf("Error creating NPC: %v", err)
+			http.Error(w, "Error creating NPCs", http.StatusInternalServerError)
+			return
+		}
+
+		character.Traits = []models.CharacterTrait{}
+		for _, t := range []struct {
+			name     *string
+			kind     string
+			modifier int
+		}{
+			{npc.SkillName, models.TraitKindSkill, npc.SkillModifier},
+			{npc.WeaknessName, models.TraitKindWeakness, npc.WeaknessModifier},
+		} {
+			if t.name == nil || *t.name == "" {
+				continue
+			}
+			var trait models.CharacterTrait
+			err = tx.QueryRowx(`
+				INSERT INTO character_traits (character_id, name, kind, modifier)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id, character_id, name, kind, modifier, description, uses_per_day, created_at
+			`, character.ID, *t.name, t.kind, t.modifier).StructScan(&trait)
+			if err != nil {
+				log.Printf("Error creating NPC trait: %v", err)
+				http.Error(w, "Error creating NPCs", http.StatusInternalServerError)
+				return
+			}
+			character.Traits = append(character.Traits, trait)
+		}
+
+		if _, err := recordCharacterVersion(tx, character.ID, userID, models.CharacterChangeCreated); err != nil {
+			log.Printf("Error recording character version: %v", err)
+			http.Error(w, "Error creating NPCs", http.StatusInternalServerError)
+			return
+		}
+
+		created = append(created, character)
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating NPCs", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range created {
+		h.broadcastCharacter(&created[i], "created")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// UpdateNPC reveals or hides an NPC and edits its stat block (GM only)
+func (h *CharacterHandler) UpdateNPC(w http.ResponseWriter, r *http.Request) {
+	character, ok := h.loadCharacterForGM(w, r, "edit")
+	if !ok {
+		return
+	}
+
+	if character.Kind != models.CharacterKindNPC {
+		http.Error(w, "Character is not an NPC", http.StatusBadRequest)
+		return
+	}
+
+	var req models.UpdateNPCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wasRevealed := character.IsRevealed
+	query := `
+		UPDATE characters
+		SET is_revealed = COALESCE($1, is_revealed),
+		    stat_block = COALESCE($2, stat_block)
+		WHERE id = $3
+		RETURNING ` + characterColumns
+	err := h.db.QueryRowx(query, req.IsRevealed, req.StatBlock, character.ID).StructScan(character)
+	if err != nil {
+		log.Printf("Error updating NPC: %v", err)
+		http.Error(w, "Error updating NPC", http.StatusInternalServerError)
+		return
+	}
+
+	// Players learn about an NPC when it's revealed and lose it when hidden again
+	switch {
+	case character.IsRevealed && !wasRevealed:
+		h.broadcastCharacter(character, "revealed")
+	case !character.IsRevealed && wasRevealed:
+		h.broadcastCharacter(character, "hidden")
+	case character.IsRevealed:
+		h.broadcastCharacter(character, "updated")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(character)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxOutcomeWeight caps how far a single roll can move a progress clock
+const maxOutcomeWeight = 10
+
+// newProgressClock builds a clock from a request, returning a client-facing
+// message if it's unusable
+func newProgressClock(req *models.ProgressClockRequest) (*models.ProgressClock, string) {
+	if req.SuccessTarget == nil && req.FailureTarget == nil {
+		return nil, "A progress clock needs a success target, a failure target or both"
+	}
+	if req.SuccessTarget != nil && *req.SuccessTarget < 1 {
+		return nil, "success_target must be at least 1"
+	}
+	if req.FailureTarget != nil && *req.FailureTarget < 1 {
+		return nil, "failure_target must be at least 1"
+	}
+
+	clock := &models.ProgressClock{
+		SuccessTarget: req.SuccessTarget,
+		FailureTarget: req.FailureTarget,
+		Weights:       models.DefaultProgressClockWeights,
+	}
+	if req.Weights != nil {
+		for name, w := range map[string]models.OutcomeWeight{
+			"success": req.Weights.Success,
+			"neutral": req.Weights.Neutral,
+			"failure": req.Weights.Failure,
+		} {
+			if w.Success < 0 || w.Success > maxOutcomeWeight || w.Failure < 0 || w.Failure > maxOutcomeWeight {
+				return nil, fmt.Sprintf("weights.%s must be between 0 and %d", name, maxOutcomeWeight)
+			}
+		}
+		clock.Weights = *req.Weights
+	}
+	return clock, ""
+}
+
+// tallyProgressClock recomputes a challenge's progress clock from its linked
+// rolls. An active challenge is completed once a target is reached, in which
+// case completed is true. It returns a nil challenge if there is no clock.
+func tallyProgressClock(tx *sqlx.Tx, challengeID int) (challenge *models.Challenge, completed bool, err error) {
+	var c models.Challenge
+	err = tx.Get(&c, "SELECT "+challengeColumns+" FROM challenges WHERE id = $1 FOR UPDATE", challengeID)
+	if err != nil {
+		return nil, false, fmt.Errorf("error locking challenge: %w", err)
+	}
+	if c.ProgressClock == nil {
+		return nil, false, nil
+	}
+
+	var counts []struct {
+		Outcome string `db:"outcome"`
+		Count   int    `db:"count"`
+	}
+	err = tx.Select(&counts, `
+		SELECT outcome, COUNT(*) as count
+		FROM roll_history
+		WHERE challenge_id = $1
+		GROUP BY outcome
+	`, challengeID)
+	if err != nil {
+		return nil, false, fmt.Errorf("error counting challenge rolls: %w", err)
+	}
+	outcomes := make(map[string]int, len(counts))
+	for _, row := range counts {
+		outcomes[row.Outcome] = row.Count
+	}
+
+	c.ProgressClock.Tally(outcomes)
+	completed = c.IsActive && c.ProgressClock.Result != nil
+
+	err = tx.QueryRowx(`
+		UPDATE challenges
+		SET progress_clock = $1, status = CASE WHEN $2 THEN 'completed' ELSE status END
+		WHERE id = $3
+		RETURNING `+challengeColumns,
+		c.ProgressClock, completed, challengeID,
+	).StructScan(&c)
+	if err != nil {
+		return nil, false, fmt.Errorf("error updating progress clock: %w", err)
+	}
+	return &c, completed, nil
+}
@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/storage"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type SceneHandler struct {
+	db    *database.Database
+	hub   *websocket.Hub
+	store storage.BlobStore
+}
+
+// sceneColumns lists the scene fields in models.Scene order
+const sceneColumns = "id, campaign_id, name, description, position, is_active, created_by_user_id, created_at, updated_at"
+
+func NewSceneHandler(db *database.Database, hub *websocket.Hub, store storage.BlobStore) *SceneHandler {
+	return &SceneHandler{db: db, hub: hub, store: store}
+}
+
+// sceneInCampaign reports whether a scene belongs to the campaign
+func sceneInCampaign(q sqlx.Queryer, sceneID, campaignID int) (bool, error) {
+	var exists bool
+	err := sqlx.Get(q, &exists, "SELECT EXISTS(SELECT 1 FROM scenes WHERE id = $1 AND campaign_id = $2)", sceneID, campaignID)
+	return exists, err
+}
+
+// activateScene makes a scene the campaign's only active one
+func activateScene(tx *sqlx.Tx, scene *models.Scene) error {
+	_, err := tx.Exec(`
+		UPDATE scenes SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE campaign_id = $1 AND is_active AND id <> $2
+	`, scene.CampaignID, scene.ID)
+	if err != nil {
+		return err
+	}
+	return tx.QueryRowx(`
+		UPDATE scenes SET is_active = true, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING `+sceneColumns, scene.ID).StructScan(scene)
+}
+
+// broadcastSceneActivated tells every client to focus on the scene
+func (h *SceneHandler) broadcastSceneActivated(scene *models.Scene) {
+	h.hub.BroadcastToCampaign(scene.CampaignID, websocket.MessageTypeSceneActivated, map[string]any{
+		"scene": scene,
+	})
+}
+
+// Create adds a scene to the end of a campaign's scene list (GM only)
+func (h *SceneHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateSceneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is GM
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can create scenes", http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating scene", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var scene models.Scene
+	query := `
+		INSERT INTO scenes (campaign_id, name, description, position, created_by_user_id)
+		SELECT $1, $2, $3, COALESCE(MAX(position) + 1, 0), $4 FROM scenes WHERE campaign_id = $1
+		RETURNING ` + sceneColumns
+	err = tx.QueryRowx(query, campaignID, req.Name, req.Description, userID).StructScan(&scene)
+	if err == nil && req.Activate {
+		err = activateScene(tx, &scene)
+	}
+	if err != nil {
+		log.Printf("Error creating scene: %v", err)
+		http.Error(w, "Error creating scene", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating scene", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeSceneUpdate, map[string]any{
+		"action": "created",
+		"scene":  scene,
+	})
+	if scene.IsActive {
+		h.broadcastSceneActivated(&scene)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scene)
+}
+
+// ListByCampaign returns a campaign's scenes in order with their activity counts
+func (h *SceneHandler) ListByCampaign(w http.ResponseWriter, r *http.Request) {
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	query := `
+		SELECT
+			s.id, s.campaign_id, s.name, s.description, s.position, s.is_active,
+			s.created_by_user_id, s.created_at, s.updated_at,
+			(SELECT COUNT(*) FROM challenges ch WHERE ch.scene_id = s.id AND ` + challengeVisibleToUser + `) as challenge_count,
+			(SELECT COUNT(*) FROM campaign_images ci WHERE ci.scene_id = s.id AND ` + imageVisibleToUser + `) as image_count,
+			(SELECT COUNT(*) FROM roll_history rh
+			 JOIN characters c ON rh.character_id = c.id
+			 JOIN campaigns ca ON c.campaign_id = ca.id
+			 WHERE rh.scene_id = s.id AND ` + rollVisibleToUser + `) as roll_count
+		FROM scenes s
+		WHERE s.campaign_id = $1
+		ORDER BY s.position ASC, s.id ASC
+	`
+
+	var scenes []models.SceneWithStats
+	err = h.db.Select(&scenes, query, campaignID, userID)
+	if err != nil {
+		log.Printf("Error fetching scenes: %v", err)
+		http.Error(w, "Error fetching scenes", http.StatusInternalServerError)
+		return
+	}
+
+	if scenes == nil {
+		scenes = []models.SceneWithStats{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scenes)
+}
+
+// Summary returns a scene with its challenges, images and rolls, and the
+// outcomes of those rolls
+func (h *SceneHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	sceneID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid scene ID", http.StatusBadRequest)
+		return
+	}
+
+	var summary models.SceneSummary
+	err = h.db.Get(&summary.Scene, "SELECT "+sceneColumns+" FROM scenes WHERE id = $1", sceneID)
+	if err != nil {
+		http.Error(w, "Scene not found", http.StatusNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	outcomeQuery := `
+		SELECT ` + challengeStatsColumns + `
+		FROM roll_history rh
+		JOIN characters c ON rh.character_id = c.id
+		JOIN campaigns ca ON c.campaign_id = ca.id
+		WHERE rh.scene_id = $1 AND ` + rollVisibleToUser
+	err = h.db.Get(&summary.Outcomes, outcomeQuery, sceneID, userID)
+	if err != nil {
+		log.Printf("Error fetching scene outcomes: %v", err)
+		http.Error(w, "Error fetching scene outcomes", http.StatusInternalServerError)
+		return
+	}
+
+	challengeQuery := `
+		SELECT ` + challengeColumnsCh + `,` + challengeStatsColumns + `
+		FROM challenges ch` + challengeRollsJoin + `
+		WHERE ch.scene_id = $1 AND ` + challengeVisibleToUser + `
+		GROUP BY ch.id
+		ORDER BY ch.created_at ASC
+	`
+	err = h.db.Select(&summary.Challenges, challengeQuery, sceneID, userID)
+	if err != nil {
+		log.Printf("Error fetching scene challenges: %v", err)
+		http.Error(w, "Error fetching scene challenges", http.StatusInternalServerError)
+		return
+	}
+	challenges := make([]*models.Challenge, len(summary.Challenges))
+	for i := range summary.Challenges {
+		challenges[i] = &summary.Challenges[i].Challenge
+	}
+	if err := attachChallengeTargets(h.db, challenges...); err != nil {
+		log.Printf("Error fetching scene challenge targets: %v", err)
+		http.Error(w, "Error fetching scene challenges", http.StatusInternalServerError)
+		return
+	}
+
+	imageQuery := `
+		SELECT ` + imageColumns + `
+		FROM campaign_images ci
+		WHERE ci.scene_id = $1 AND ` + imageVisibleToUser + `
+		ORDER BY ci.uploaded_at ASC
+	`
+	err = h.db.Select(&summary.Images, imageQuery, sceneID, userID)
+	if err != nil {
+		log.Printf("Error fetching scene images: %v", err)
+		http.Error(w, "Error fetching scene images", http.StatusInternalServerError)
+		return
+	}
+	for i := range summary.Images {
+		signImageURLs(h.store, &summary.Images[i])
+	}
+
+	rollQuery := `
+		SELECT
+			rh.id, rh.character_id, rh.pool_dice_id, rh.d20_roll,
+			rh.action_type, rh.success, rh.notes, rh.created_at,
+			rh.challenge_id, rh.skill_applied, rh.other_modifiers, rh.modified_d6,
+			rh.outcome, rh.session_id, rh.scene_id, rh.campaign_day, rh.character_version, rh.consequence,
+			c.name as character_name
+		FROM roll_history rh
+		JOIN characters c ON rh.character_id = c.id
+		JOIN campaigns ca ON c.campaign_id = ca.id
+		WHERE rh.scene_id = $1 AND ` + rollVisibleToUser + `
+		ORDER BY rh.created_at ASC, rh.id ASC
+	`
+	err = h.db.Select(&summary.Rolls, rollQuery, sceneID, userID)
+	if err != nil {
+		log.Printf("Error fetching scene rolls: %v", err)
+		http.Error(w, "Error fetching scene rolls", http.StatusInternalServerError)
+		return
+	}
+	if err := attachRollDetails(h.db, summary.Rolls); err != nil {
+		log.Printf("Error fetching scene roll details: %v", err)
+		http.Error(w, "Error fetching scene rolls", http.StatusInternalServerError)
+		return
+	}
+
+	if summary.Challenges == nil {
+		summary.Challenges = []models.ChallengeWithStats{}
+	}
+	if summary.Images == nil {
+		summary.Images = []models.CampaignImage{}
+	}
+	if summary.Rolls == nil {
+		summary.Rolls = []models.RollHistoryWithCharacter{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// loadSceneForGM fetches a scene for a GM-only action, writing the error
+// response and returning false if it's missing or the user isn't the GM
+func (h *SceneHandler) loadSceneForGM(w http.ResponseWriter, r *http.Request, action string) (*models.Scene, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	sceneID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid scene ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var scene models.Scene
+	err = h.db.Get(&scene, "SELECT "+sceneColumns+" FROM scenes WHERE id = $1", sceneID)
+	if err != nil {
+		http.Error(w, "Scene not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", scene.CampaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can "+action+" scenes", http.StatusForbidden)
+		return nil, false
+	}
+
+	return &scene, true
+}
+
+// Update edits a scene's name and description (GM only)
+func (h *SceneHandler) Update(w http.ResponseWriter, r *http.Request) {
+	scene, ok := h.loadSceneForGM(w, r, "edit")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSceneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Name != nil {
+		trimmed := strings.TrimSpace(*req.Name)
+		if trimmed == "" {
+			http.Error(w, "Name cannot be empty", http.StatusBadRequest)
+			return
+		}
+		req.Name = &trimmed
+	}
+
+	query := `
+		UPDATE scenes
+		SET name = COALESCE($1, name),
+		    description = COALESCE($2, description),
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+		RETURNING ` + sceneColumns
+	err := h.db.QueryRowx(query, req.Name, req.Description, scene.ID).StructScan(scene)
+	if err != nil {
+		log.Printf("Error updating scene: %v", err)
+		http.Error(w, "Error updating scene", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.BroadcastToCampaign(scene.CampaignID, websocket.MessageTypeSceneUpdate, map[string]any{
+		"action": "updated",
+		"scene":  scene,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scene)
+}
+
+// Delete removes a scene (GM only). Its challenges, images and rolls are
+// kept, just no longer linked to a scene.
+func (h *SceneHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	scene, ok := h.loadSceneForGM(w, r, "delete")
+	if !ok {
+		return
+	}
+
+	_, err := h.db.Exec("DELETE FROM scenes WHERE id = $1", scene.ID)
+	if err != nil {
+		log.Printf("Error deleting scene: %v", err)
+		http.Error(w, "Error deleting scene", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.BroadcastToCampaign(scene.CampaignID, websocket.MessageTypeSceneUpdate, map[string]any{
+		"action":   "deleted",
+		"scene_id": scene.ID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Scene deleted successfully"})
+}
+
+// Activate makes a scene the campaign's active one and has every client focus on it (GM only)
+func (h *SceneHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	scene, ok := h.loadSceneForGM(w, r, "activate")
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error activating scene", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if err := activateScene(tx, scene); err != nil {
+		log.Printf("Error activating scene: %v", err)
+		http.Error(w, "Error activating scene", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error activating scene", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastSceneActivated(scene)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scene)
+}
+
+// Deactivate leaves a campaign without an active scene (GM only)
+func (h *SceneHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	scene, ok := h.loadSceneForGM(w, r, "deactivate")
+	if !ok {
+		return
+	}
+
+	if !scene.IsActive {
+		http.Error(w, "Scene is not active", http.StatusConflict)
+		return
+	}
+
+	query := `
+		UPDATE scenes SET is_active = false, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING ` + sceneColumns
+	err := h.db.QueryRowx(query, scene.ID).StructScan(scene)
+	if err != nil {
+		log.Printf("Error deactivating scene: %v", err)
+		http.Error(w, "Error deactivating scene", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.BroadcastToCampaign(scene.CampaignID, websocket.MessageTypeSceneUpdate, map[string]any{
+		"action": "deactivated",
+		"scene":  scene,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scene)
+}
+
+// Reorder sets the order of a campaign's scenes; every scene must be listed exactly once (GM only)
+func (h *SceneHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReorderScenesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is GM
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can reorder scenes", http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error reordering scenes", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var existing []int
+	err = tx.Select(&existing, "SELECT id FROM scenes WHERE campaign_id = $1 FOR UPDATE", campaignID)
+	if err != nil {
+		http.Error(w, "Error reordering scenes", http.StatusInternalServerError)
+		return
+	}
+	inCampaign := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		inCampaign[id] = true
+	}
+	ids := uniqueInts(req.SceneIDs)
+	if len(ids) != len(req.SceneIDs) || len(ids) != len(existing) {
+		http.Error(w, "scene_ids must list every scene in the campaign exactly once", http.StatusBadRequest)
+		return
+	}
+	for _, id := range ids {
+		if !inCampaign[id] {
+			http.Error(w, "scene_ids must list every scene in the campaign exactly once", http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE scenes s
+		SET position = o.ord - 1, updated_at = CURRENT_TIMESTAMP
+		FROM unnest($1::int[]) WITH ORDINALITY AS o(id, ord)
+		WHERE s.id = o.id
+	`, pq.Array(req.SceneIDs))
+	if err != nil {
+		log.Printf("Error reordering scenes: %v", err)
+		http.Error(w, "Error reordering scenes", http.StatusInternalServerError)
+		return
+	}
+
+	var scenes []models.Scene
+	err = tx.Select(&scenes, "SELECT "+sceneColumns+" FROM scenes WHERE campaign_id = $1 ORDER BY position ASC", campaignID)
+	if err != nil {
+		http.Error(w, "Error reordering scenes", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error reordering scenes", http.StatusInternalServerError)
+		return
+	}
+
+	if scenes == nil {
+		scenes = []models.Scene{}
+	}
+
+	h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeSceneUpdate, map[string]any{
+		"action": "reordered",
+		"scenes": scenes,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scenes)
+}
@@ -0,0 +1,541 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+)
+
+type SessionHandler struct {
+	db  *database.Database
+	hub *websocket.Hub
+}
+
+func NewSessionHandler(db *database.Database, hub *websocket.Hub) *SessionHandler {
+	return &SessionHandler{db: db, hub: hub}
+}
+
+// Start opens a new play session for a campaign (GM only)
+func (h *SessionHandler) Start(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.StartSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Check if user is GM
+	var gmUserID int
+	err = h.db.Get(&gmUserID, "SELECT gm_user_id FROM campaigns WHERE id = $1", campaignID)
+	if err != nil || gmUserID != userID {
+		http.Error(w, "Only the GM can start a session", http.StatusForbidden)
+		return
+	}
+
+	var openCount int
+	err = h.db.Get(&openCount, "SELECT COUNT(*) FROM game_sessions WHERE campaign_id = $1 AND ended_at IS NULL", campaignID)
+	if err != nil {
+		http.Error(w, "Error checking open sessions", http.StatusInternalServerError)
+		return
+	}
+	if openCount > 0 {
+		http.Error(w, "This campaign already has an open session", http.StatusConflict)
+		return
+	}
+
+	var session models.GameSession
+	query := `
+		INSERT INTO game_sessions (campaign_id, title, notes, started_by_user_id, start_day)
+		SELECT $1, $2, $3, $4, current_day FROM campaigns WHERE id = $1
+		RETURNING id, campaign_id, title, notes, started_by_user_id, start_day, end_day, started_at, ended_at
+	`
+	err = h.db.QueryRowx(query, campaignID, req.Title, req.Notes, userID).StructScan(&session)
+	if err != nil {
+		log.Printf("Error starting session: %v", err)
+		http.Error(w, "Error starting session", http.StatusInternalServerError)
+		return
+	}
+
+	// The GM is always present for their own session
+	_, err = h.db.Exec(`
+		INSERT INTO session_attendance (session_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (session_id, user_id) DO NOTHING
+	`, session.ID, userID)
+	if err != nil {
+		log.Printf("Error recording GM attendance: %v", err)
+	}
+
+	h.hub.BroadcastToCampaign(campaignID, websocket.MessageTypeSessionUpdate, map[string]any{
+		"action":  "started",
+		"session": session,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// End closes an open play session (GM only)
+func (h *SessionHandler) End(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var campaignInfo struct {
+		GMUserID   int `db:"gm_user_id"`
+		CampaignID int `db:"campaign_id"`
+	}
+	query := `
+		SELECT c.gm_user_id, gs.campaign_id
+		FROM game_sessions gs
+		JOIN campaigns c ON gs.campaign_id = c.id
+		WHERE gs.id = $1
+	`
+	err = h.db.Get(&campaignInfo, query, sessionID)
+	if err != nil || campaignInfo.GMUserID != userID {
+		http.Error(w, "Only the GM can end a session", http.StatusForbidden)
+		return
+	}
+
+	var session models.GameSession
+	updateQuery := `
+		UPDATE game_sessions gs
+		SET ended_at = CURRENT_TIMESTAMP, end_day = c.current_day
+		FROM campaigns c
+		WHERE gs.id = $1 AND gs.campaign_id = c.id AND gs.ended_at IS NULL
+		RETURNING gs.id, gs.campaign_id, gs.title, gs.notes, gs.started_by_user_id,
+		          gs.start_day, gs.end_day, gs.started_at, gs.ended_at
+	`
+	err = h.db.QueryRowx(updateQuery, sessionID).StructScan(&session)
+	if err != nil {
+		http.Error(w, "Session not found or already ended", http.StatusConflict)
+		return
+	}
+
+	h.hub.BroadcastToCampaign(campaignInfo.CampaignID, websocket.MessageTypeSessionUpdate, map[string]any{
+		"action":  "ended",
+		"session": session,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// Update edits a session's title and notes (GM only)
+func (h *SessionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var campaignInfo struct {
+		GMUserID   int `db:"gm_user_id"`
+		CampaignID int `db:"campaign_id"`
+	}
+	query := `
+		SELECT c.gm_user_id, gs.campaign_id
+		FROM game_sessions gs
+		JOIN campaigns c ON gs.campaign_id = c.id
+		WHERE gs.id = $1
+	`
+	err = h.db.Get(&campaignInfo, query, sessionID)
+	if err != nil || campaignInfo.GMUserID != userID {
+		http.Error(w, "Only the GM can edit session notes", http.StatusForbidden)
+		return
+	}
+
+	var req models.UpdateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var session models.GameSession
+	updateQuery := `
+		UPDATE game_sessions
+		SET title = COALESCE($1, title), notes = COALESCE($2, notes)
+		WHERE id = $3
+		RETURNING id, campaign_id, title, notes, started_by_user_id, start_day, end_day, started_at, ended_at
+	`
+	err = h.db.QueryRowx(updateQuery, req.Title, req.Notes, sessionID).StructScan(&session)
+	if err != nil {
+		http.Error(w, "Error updating session", http.StatusInternalServerError)
+		return
+	}
+
+	h.hub.BroadcastToCampaign(campaignInfo.CampaignID, websocket.MessageTypeSessionUpdate, map[string]any{
+		"action":  "updated",
+		"session": session,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// ListByCampaign returns all sessions for a campaign, newest first
+func (h *SessionHandler) ListByCampaign(w http.ResponseWriter, r *http.Request) {
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	// Counts only include what the user may see, so hidden NPCs and
+	// challenges don't show up as numbers either
+	query := `
+		SELECT
+			gs.id, gs.campaign_id, gs.title, gs.notes, gs.started_by_user_id,
+			gs.start_day, gs.end_day, gs.started_at, gs.ended_at,
+			(SELECT COUNT(*) FROM roll_history rh
+			 JOIN characters c ON rh.character_id = c.id
+			 JOIN campaigns ca ON c.campaign_id = ca.id
+			 WHERE rh.session_id = gs.id AND ` + rollVisibleToUser + `) as roll_count,
+			(SELECT COUNT(*) FROM dice_pools dp
+			 JOIN characters c ON dp.character_id = c.id
+			 JOIN campaigns ca ON c.campaign_id = ca.id
+			 WHERE dp.session_id = gs.id AND ` + visibleToUser + `) as pool_count,
+			(SELECT COUNT(*) FROM challenges ch
+			 WHERE ch.session_id = gs.id AND ` + challengeVisibleToUser + `) as challenge_count,
+			(SELECT COUNT(*) FROM session_attendance sa WHERE sa.session_id = gs.id) as attendee_count
+		FROM game_sessions gs
+		WHERE gs.campaign_id = $1
+		ORDER BY gs.started_at DESC
+	`
+
+	userID, _ := middleware.GetUserID(r.Context())
+	var sessions []models.GameSessionWithStats
+	err = h.db.Select(&sessions, query, campaignID, userID)
+	if err != nil {
+		log.Printf("Error fetching sessions: %v", err)
+		http.Error(w, "Error fetching sessions", http.StatusInternalServerError)
+		return
+	}
+
+	if sessions == nil {
+		sessions = []models.GameSessionWithStats{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// GetCurrent returns the open session for a campaign, if any
+func (h *SessionHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
+	campaignID, err := strconv.Atoi(chi.URLParam(r, "campaignId"))
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	var session models.GameSession
+	query := `
+		SELECT id, campaign_id, title, notes, started_by_user_id, start_day, end_day, started_at, ended_at
+		FROM game_sessions
+		WHERE campaign_id = $1 AND ended_at IS NULL
+	`
+	err = h.db.Get(&session, query, campaignID)
+	if err != nil {
+		http.Error(w, "No open session", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// Get returns a session with its attendance, rolls and challenges
+func (h *SessionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var detail models.GameSessionDetail
+	query := `
+		SELECT id, campaign_id, title, notes, started_by_user_id, start_day, end_day, started_at, ended_at
+		FROM game_sessions
+		WHERE id = $1
+	`
+	err = h.db.Get(&detail.GameSession, query, sessionID)
+	if err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	// Attendees playing a hidden NPC are listed without their character
+	attendeeQuery := `
+		SELECT sa.id, sa.session_id, sa.user_id, sa.joined_at, u.username,
+		       CASE WHEN ` + visibleToUser + ` THEN sa.character_id END as character_id,
+		       CASE WHEN ` + visibleToUser + ` THEN c.name END as character_name
+		FROM session_attendance sa
+		JOIN users u ON sa.user_id = u.id
+		LEFT JOIN characters c ON sa.character_id = c.id
+		LEFT JOIN campaigns ca ON c.campaign_id = ca.id
+		WHERE sa.session_id = $1
+		ORDER BY sa.joined_at ASC
+	`
+	userID, _ := middleware.GetUserID(r.Context())
+	err = h.db.Select(&detail.Attendees, attendeeQuery, sessionID, userID)
+	if err != nil {
+		log.Printf("Error fetching attendance: %v", err)
+		http.Error(w, "Error fetching attendance", http.StatusInternalServerError)
+		return
+	}
+
+	rollQuery := `
+		SELECT
+			rh.id, rh.character_id, rh.pool_dice_id, rh.d20_roll,
+			rh.action_type, rh.success, rh.notes, rh.created_at,
+			rh.challenge_id, rh.skill_applied, rh.other_modifiers, rh.modified_d6,
+			rh.outcome, rh.session_id, rh.scene_id, rh.campaign_day, rh.character_version, rh.consequence,
+			c.name as character_name
+		FROM roll_history rh
+		JOIN characters c ON rh.character_id = c.id
+		JOIN campaigns ca ON c.campaign_id = ca.id
+		WHERE rh.session_id = $1 AND ` + rollVisibleToUser + `
+		ORDER BY rh.created_at ASC
+	`
+	err = h.db.Select(&detail.Rolls, rollQuery, sessionID, userID)
+	if err != nil {
+		log.Printf("Error fetching session rolls: %v", err)
+		http.Error(w, "Error fetching session rolls", http.StatusInternalServerError)
+		return
+	}
+	if err := attachRollDetails(h.db, detail.Rolls); err != nil {
+		log.Printf("Error fetching session roll traits: %v", err)
+		http.Error(w, "Error fetching session rolls", http.StatusInternalServerError)
+		return
+	}
+
+	challengeQuery := `
+		SELECT ` + challengeColumns + `
+		FROM challenges ch
+		WHERE ch.session_id = $1 AND ` + challengeVisibleToUser + `
+		ORDER BY ch.created_at ASC
+	`
+	err = h.db.Select(&detail.Challenges, challengeQuery, sessionID, userID)
+	if err != nil {
+		log.Printf("Error fetching session challenges: %v", err)
+		http.Error(w, "Error fetching session challenges", http.StatusInternalServerError)
+		return
+	}
+	challenges := make([]*models.Challenge, len(detail.Challenges))
+	for i := range detail.Challenges {
+		challenges[i] = &detail.Challenges[i]
+	}
+	if err := attachChallengeTargets(h.db, challenges...); err != nil {
+		log.Printf("Error fetching session challenge targets: %v", err)
+		http.Error(w, "Error fetching session challenges", http.StatusInternalServerError)
+		return
+	}
+
+	if detail.Attendees == nil {
+		detail.Attendees = []models.SessionAttendee{}
+	}
+	if detail.Rolls == nil {
+		detail.Rolls = []models.RollHistoryWithCharacter{}
+	}
+	if detail.Challenges == nil {
+		detail.Challenges = []models.Challenge{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// AddAttendee marks a user as present for a session.
+// The GM can mark anyone; players can only check themselves in.
+func (h *SessionHandler) AddAttendee(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AddAttendeeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == 0 {
+		req.UserID = userID
+	}
+
+	var campaignInfo struct {
+		GMUserID   int `db:"gm_user_id"`
+		CampaignID int `db:"campaign_id"`
+	}
+	query := `
+		SELECT c.gm_user_id, gs.campaign_id
+		FROM game_sessions gs
+		JOIN campaigns c ON gs.campaign_id = c.id
+		WHERE gs.id = $1
+	`
+	err = h.db.Get(&campaignInfo, query, sessionID)
+	if err != nil {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	if campaignInfo.GMUserID != userID && req.UserID != userID {
+		http.Error(w, "Only the GM can record attendance for other players", http.StatusForbidden)
+		return
+	}
+
+	// Attendees must be campaign members
+	var isMember bool
+	err = h.db.Get(&isMember, "SELECT EXISTS(SELECT 1 FROM campaign_members WHERE campaign_id = $1 AND user_id = $2)", campaignInfo.CampaignID, req.UserID)
+	if err != nil || !isMember {
+		http.Error(w, "User is not a member of this campaign", http.StatusBadRequest)
+		return
+	}
+
+	if req.CharacterID != nil {
+		var character struct {
+			CampaignID int  `db:"campaign_id"`
+			UserID     *int `db:"user_id"`
+		}
+		err = h.db.Get(&character, "SELECT campaign_id, user_id FROM characters WHERE id = $1", *req.CharacterID)
+		if err != nil || character.CampaignID != campaignInfo.CampaignID {
+			http.Error(w, "Character not found in this campaign", http.StatusBadRequest)
+			return
+		}
+		if !checkCharacterVisible(w, r, h.db, *req.CharacterID) {
+			return
+		}
+		if campaignInfo.GMUserID != userID && (character.UserID == nil || *character.UserID != userID) {
+			http.Error(w, "You can only attend as your own character", http.StatusForbidden)
+			return
+		}
+	}
+
+	var attendee models.SessionAttendee
+	insertQuery := `
+		INSERT INTO session_attendance (session_id, user_id, character_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (session_id, user_id) DO UPDATE SET character_id = EXCLUDED.character_id
+		RETURNING id, session_id, user_id, character_id, joined_at,
+		          (SELECT username FROM users WHERE id = $2) as username,
+		          (SELECT name FROM characters WHERE id = $3) as character_name
+	`
+	err = h.db.QueryRowx(insertQuery, sessionID, req.UserID, req.CharacterID).StructScan(&attendee)
+	if err != nil {
+		log.Printf("Error recording attendance: %v", err)
+		http.Error(w, "Error recording attendance", http.StatusInternalServerError)
+		return
+	}
+
+	payload := map[string]any{
+		"action":   "attendee_added",
+		"attendee": attendee,
+	}
+	if req.CharacterID != nil {
+		// A hidden NPC's name only goes to the GM
+		broadcastForCharacter(h.db, h.hub, *req.CharacterID, websocket.MessageTypeSessionUpdate, payload)
+	} else {
+		h.hub.BroadcastToCampaign(campaignInfo.CampaignID, websocket.MessageTypeSessionUpdate, payload)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attendee)
+}
+
+// RemoveAttendee removes a user from a session's attendance (GM only)
+func (h *SessionHandler) RemoveAttendee(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	attendeeUserID, err := strconv.Atoi(chi.URLParam(r, "userId"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var campaignInfo struct {
+		GMUserID   int `db:"gm_user_id"`
+		CampaignID int `db:"campaign_id"`
+	}
+	query := `
+		SELECT c.gm_user_id, gs.campaign_id
+		FROM game_sessions gs
+		JOIN campaigns c ON gs.campaign_id = c.id
+		WHERE gs.id = $1
+	`
+	err = h.db.Get(&campaignInfo, query, sessionID)
+	if err != nil || campaignInfo.GMUserID != userID {
+		http.Error(w, "Only the GM can edit attendance", http.StatusForbidden)
+		return
+	}
+
+	result, err := h.db.Exec("DELETE FROM session_attendance WHERE session_id = $1 AND user_id = $2", sessionID, attendeeUserID)
+	if err != nil {
+		http.Error(w, "Error removing attendee", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		http.Error(w, "Attendee not found", http.StatusNotFound)
+		return
+	}
+
+	h.hub.BroadcastToCampaign(campaignInfo.CampaignID, websocket.MessageTypeSessionUpdate, map[string]any{
+		"action":     "attendee_removed",
+		"session_id": sessionID,
+		"user_id":    attendeeUserID,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Attendee removed successfully"})
+}
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/SamPCunningham/sleeper-system/internal/websocket"
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+)
+
+// traitSelect loads traits along with how often each was used on the campaign's current day
+const traitSelect = `
+	SELECT
+		ct.id, ct.character_id, ct.name, ct.kind, ct.modifier, ct.description,
+		ct.uses_per_day, ct.created_at,
+		(
+			SELECT COUNT(*) FROM roll_history_traits rt
+			JOIN roll_history rh ON rt.roll_id = rh.id
+			WHERE rt.trait_id = ct.id AND rh.campaign_day = ca.current_day
+		) as uses_today
+	FROM character_traits ct
+	JOIN characters ch ON ct.character_id = ch.id
+	JOIN campaigns ca ON ch.campaign_id = ca.id
+`
+
+type TraitHandler struct {
+	db  *database.Database
+	hub *websocket.Hub
+}
+
+func NewTraitHandler(db *database.Database, hub *websocket.Hub) *TraitHandler {
+	return &TraitHandler{db: db, hub: hub}
+}
+
+func validTraitKind(kind string) bool {
+	return kind == models.TraitKindSkill || kind == models.TraitKindWeakness
+}
+
+// loadCharacterTraits returns the traits of each given character keyed by character ID
+func loadCharacterTraits(db *database.Database, characterIDs []int) (map[int][]models.CharacterTrait, error) {
+	byCharacter := make(map[int][]models.CharacterTrait, len(characterIDs))
+	if len(characterIDs) == 0 {
+		return byCharacter, nil
+	}
+
+	var traits []models.CharacterTrait
+	query := traitSelect + ` WHERE ct.character_id = ANY($1) ORDER BY ct.kind ASC, ct.id ASC`
+	if err := db.Select(&traits, query, pq.Array(characterIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, t := range traits {
+		byCharacter[t.CharacterID] = append(byCharacter[t.CharacterID], t)
+	}
+	return byCharacter, nil
+}
+
+// loadRollTraits returns the traits applied to each given roll keyed by roll ID
+func loadRollTraits(db *database.Database, rollIDs []int) (map[int][]models.RollTrait, error) {
+	byRoll := make(map[int][]models.RollTrait, len(rollIDs))
+	if len(rollIDs) == 0 {
+		return byRoll, nil
+	}
+
+	var traits []models.RollTrait
+	query := `
+		SELECT roll_id, trait_id, name, kind, modifier
+		FROM roll_history_traits
+		WHERE roll_id = ANY($1)
+		ORDER BY roll_id ASC, kind ASC, name ASC
+	`
+	if err := db.Select(&traits, query, pq.Array(rollIDs)); err != nil {
+		return nil, err
+	}
+
+	for _, t := range traits {
+		byRoll[t.RollID] = append(byRoll[t.RollID], t)
+	}
+	return byRoll, nil
+}
+
+// attachRollDetails fills in the traits, conditions and items applied to each roll
+func attachRollDetails(db *database.Database, rolls []models.RollHistoryWithCharacter) error {
+	ids := make([]int, len(rolls))
+	for i, roll := range rolls {
+		ids[i] = roll.ID
+	}
+
+	traits, err := loadRollTraits(db, ids)
+	if err != nil {
+		return err
+	}
+	conditions, err := loadRollConditions(db, ids)
+	if err != nil {
+		return err
+	}
+	items, err := loadRollItems(db, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range rolls {
+		rolls[i].Traits = traits[rolls[i].ID]
+		if rolls[i].Traits == nil {
+			rolls[i].Traits = []models.RollTrait{}
+		}
+		rolls[i].Conditions = conditions[rolls[i].ID]
+		if rolls[i].Conditions == nil {
+			rolls[i].Conditions = []models.RollCondition{}
+		}
+		rolls[i].Items = items[rolls[i].ID]
+		if rolls[i].Items == nil {
+			rolls[i].Items = []models.RollItem{}
+		}
+	}
+	return nil
+}
+
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	unique := make([]int, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// characterAccess reports whether the user owns a character or runs its campaign.
+// Owners may rename and describe traits; only the GM may change their mechanics.
+func (h *TraitHandler) characterAccess(characterID, userID int) (isOwner, isGM bool, err error) {
+	var info struct {
+		OwnerID  *int `db:"user_id"`
+		GMUserID int  `db:"gm_user_id"`
+	}
+	err = h.db.Get(&info, `
+		SELECT ch.user_id, ca.gm_user_id
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		return false, false, err
+	}
+
+	isOwner = info.OwnerID != nil && *info.OwnerID == userID
+	return isOwner, info.GMUserID == userID, nil
+}
+
+func (h *TraitHandler) broadcastTraits(characterID int) {
+	broadcastForCharacter(h.db, h.hub, characterID, websocket.MessageTypeCharacterUpdate, map[string]any{
+		"action":       "traits_updated",
+		"character_id": characterID,
+	})
+}
+
+func (h *TraitHandler) ListByCharacter(w http.ResponseWriter, r *http.Request) {
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+	if !checkCharacterVisible(w, r, h.db, characterID) {
+		return
+	}
+
+	byCharacter, err := loadCharacterTraits(h.db, []int{characterID})
+	if err != nil {
+		log.Printf("Error fetching traits: %v", err)
+		http.Error(w, "Error fetching traits", http.StatusInternalServerError)
+		return
+	}
+
+	traits := byCharacter[characterID]
+	if traits == nil {
+		traits = []models.CharacterTrait{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(traits)
+}
+
+func (h *TraitHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	characterID, err := strconv.Atoi(chi.URLParam(r, "characterId"))
+	if err != nil {
+		http.Error(w, "Invalid character ID", http.StatusBadRequest)
+		return
+	}
+
+	_, isGM, err := h.characterAccess(characterID, userID)
+	if err != nil {
+		http.Error(w, "Character not found", http.StatusNotFound)
+		return
+	}
+	if !isGM {
+		http.Error(w, "Only the GM can add traits", http.StatusForbidden)
+		return
+	}
+
+	var req models.CreateTraitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "Trait name is required", http.StatusBadRequest)
+		return
+	}
+	if !validTraitKind(req.Kind) {
+		http.Error(w, "kind must be 'skill' or 'weakness'", http.StatusBadRequest)
+		return
+	}
+	if req.UsesPerDay != nil && *req.UsesPerDay < 1 {
+		http.Error(w, "uses_per_day must be at least 1", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error creating trait", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var trait models.CharacterTrait
+	query := `
+		INSERT INTO character_traits (character_id, name, kind, modifier, description, uses_per_day)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, character_id, name, kind, modifier, description, uses_per_day, created_at
+	`
+	err = tx.QueryRowx(query, characterID, req.Name, req.Kind, req.Modifier, req.Description, req.UsesPerDay).StructScan(&trait)
+	if err == nil {
+		_, err = recordCharacterVersion(tx, characterID, userID, models.CharacterChangeTraits)
+	}
+	if err != nil {
+		log.Printf("Error creating trait: %v", err)
+		http.Error(w, "Error creating trait", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error creating trait", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastTraits(characterID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(trait)
+}
+
+func (h *TraitHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	traitID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid trait ID", http.StatusBadRequest)
+		return
+	}
+
+	var existing models.CharacterTrait
+	err = h.db.Get(&existing, traitSelect+` WHERE ct.id = $1`, traitID)
+	if err != nil {
+		http.Error(w, "Trait not found", http.StatusNotFound)
+		return
+	}
+
+	isOwner, isGM, err := h.characterAccess(existing.CharacterID, userID)
+	if err != nil || (!isOwner && !isGM) {
+		http.Error(w, "You don't have permission to update this character", http.StatusForbidden)
+		return
+	}
+
+	var req models.UpdateTraitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !isGM && (req.Kind != nil || req.Modifier != nil || req.UsesPerDay != nil || req.Unlimited) {
+		http.Error(w, "Only the GM can change a trait's kind, modifier or uses", http.StatusForbidden)
+		return
+	}
+
+	if req.Name != nil {
+		existing.Name = strings.TrimSpace(*req.Name)
+		if existing.Name == "" {
+			http.Error(w, "Trait name is required", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Kind != nil {
+		if !validTraitKind(*req.Kind) {
+			http.Error(w, "kind must be 'skill' or 'weakness'", http.StatusBadRequest)
+			return
+		}
+		existing.Kind = *req.Kind
+	}
+	if req.Modifier != nil {
+		existing.Modifier = *req.Modifier
+	}
+	if req.Description != nil {
+		existing.Description = req.Description
+	}
+	if req.UsesPerDay != nil {
+		if *req.UsesPerDay < 1 {
+			http.Error(w, "uses_per_day must be at least 1", http.StatusBadRequest)
+			return
+		}
+		existing.UsesPerDay = req.UsesPerDay
+	}
+	if req.Unlimited {
+		existing.UsesPerDay = nil
+	}
+
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error updating trait", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE character_traits
+		SET name = $1, kind = $2, modifier = $3, description = $4, uses_per_day = $5
+		WHERE id = $6
+	`, existing.Name, existing.Kind, existing.Modifier, existing.Description, existing.UsesPerDay, traitID)
+	if err == nil {
+		_, err = recordCharacterVersion(tx, existing.CharacterID, userID, models.CharacterChangeTraits)
+	}
+	if err != nil {
+		log.Printf("Error updating trait: %v", err)
+		http.Error(w, "Error updating trait", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error updating trait", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastTraits(existing.CharacterID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(existing)
+}
+
+func (h *TraitHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	traitID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid trait ID", http.StatusBadRequest)
+		return
+	}
+
+	var characterID int
+	err = h.db.Get(&characterID, "SELECT character_id FROM character_traits WHERE id = $1", traitID)
+	if err != nil {
+		http.Error(w, "Trait not found", http.StatusNotFound)
+		return
+	}
+
+	_, isGM, err := h.characterAccess(characterID, userID)
+	if err != nil || !isGM {
+		http.Error(w, "Only the GM can remove traits", http.StatusForbidden)
+		return
+	}
+
+	// Past rolls keep their copy of the trait's name and modifier
+	tx, err := h.db.Beginx()
+	if err != nil {
+		http.Error(w, "Error deleting trait", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM character_traits WHERE id = $1", traitID)
+	if err == nil {
+		_, err = recordCharacterVersion(tx, characterID, userID, models.CharacterChangeTraits)
+	}
+	if err != nil {
+		log.Printf("Error deleting trait: %v", err)
+		http.Error(w, "Error deleting trait", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Error deleting trait", http.StatusInternalServerError)
+		return
+	}
+
+	h.broadcastTraits(characterID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Trait deleted successfully"})
+}
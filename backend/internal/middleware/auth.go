@@ -3,9 +3,11 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -15,58 +17,130 @@ type contextKey string
 const (
 	UserIDKey   contextKey = "userID"
 	UserRoleKey contextKey = "userRole"
+	SessionKey  contextKey = "sessionID"
 )
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
-		}
+// AuthError is a rejected access token, with the status and message to answer with
+type AuthError struct {
+	Status  int
+	Message string
+}
+
+func (e *AuthError) Error() string {
+	return e.Message
+}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-			return
+// Identity is who an access token was issued to
+type Identity struct {
+	UserID       int
+	Role         string
+	SessionID    int
+	TokenVersion int
+}
+
+// Authenticate checks an access token, that the login session it was issued
+// for hasn't been revoked or expired, and that it was issued since the user's
+// role last changed. A rejected token returns an *AuthError.
+func (c *AuthCache) Authenticate(tokenString string) (Identity, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
 
-		tokenString := parts[1]
+	if err != nil || !token.Valid {
+		return Identity{}, &AuthError{http.StatusUnauthorized, "Invalid token"}
+	}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Identity{}, &AuthError{http.StatusUnauthorized, "Invalid token claims"}
+	}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return Identity{}, &AuthError{http.StatusUnauthorized, "Invalid user ID in token"}
+	}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-			return
-		}
+	// Get role from token (default to "player" for backward compatibility)
+	userRole, _ := claims["role"].(string)
+	if userRole == "" {
+		userRole = "player"
+	}
 
-		userID, ok := claims["user_id"].(float64)
-		if !ok {
-			http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
-			return
-		}
+	// Tokens from before login sessions existed carry no session and can't be revoked
+	sessionID, ok := claims["sid"].(float64)
+	if !ok {
+		return Identity{}, &AuthError{http.StatusUnauthorized, "Token has no session; please log in again"}
+	}
 
-		// Get role from token (default to "player" for backward compatibility)
-		userRole, _ := claims["role"].(string)
-		if userRole == "" {
-			userRole = "player"
-		}
+	// The role claim is only trusted if nothing has changed since the token was issued
+	tokenVersion, ok := claims["ver"].(float64)
+	if !ok {
+		return Identity{}, &AuthError{http.StatusUnauthorized, "Token is out of date; please refresh it"}
+	}
 
-		ctx := context.WithValue(r.Context(), UserIDKey, int(userID))
-		ctx = context.WithValue(ctx, UserRoleKey, userRole)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	id := Identity{
+		UserID:       int(userID),
+		Role:         userRole,
+		SessionID:    int(sessionID),
+		TokenVersion: int(tokenVersion),
+	}
+	if err := c.Check(id); err != nil {
+		return Identity{}, err
+	}
+	return id, nil
+}
+
+// Check confirms an identity's session is still open and its token version
+// current. Long-lived connections call it again to notice a logout.
+func (c *AuthCache) Check(id Identity) error {
+	state, found, err := c.lookup(id.SessionID, id.UserID)
+	if err != nil {
+		log.Printf("Error checking auth session: %v", err)
+		return &AuthError{http.StatusInternalServerError, "Error checking session"}
+	}
+	if !found || !state.sessionActive || time.Now().After(state.sessionExpiresAt) {
+		return &AuthError{http.StatusUnauthorized, "Session has been revoked or has expired"}
+	}
+	if id.TokenVersion != state.tokenVersion {
+		return &AuthError{http.StatusUnauthorized, "Token is out of date; please refresh it"}
+	}
+	return nil
+}
+
+// AuthMiddleware rejects requests without a valid access token and puts the
+// token's user, role and session into the request context. Lookups go
+// through the cache.
+func AuthMiddleware(cache *AuthCache) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				return
+			}
+
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				return
+			}
+
+			id, err := cache.Authenticate(parts[1])
+			if err != nil {
+				authErr := err.(*AuthError)
+				http.Error(w, authErr.Message, authErr.Status)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, id.UserID)
+			ctx = context.WithValue(ctx, UserRoleKey, id.Role)
+			ctx = context.WithValue(ctx, SessionKey, id.SessionID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 func GetUserID(ctx context.Context) (int, bool) {
@@ -78,3 +152,8 @@ func GetUserRole(ctx context.Context) (string, bool) {
 	role, ok := ctx.Value(UserRoleKey).(string)
 	return role, ok
 }
+
+func GetSessionID(ctx context.Context) (int, bool) {
+	sessionID, ok := ctx.Value(SessionKey).(int)
+	return sessionID, ok
+}
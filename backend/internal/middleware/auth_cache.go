@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+)
+
+// authCacheTTL bounds how long a lookup is trusted. Changes made through this
+// server invalidate entries straight away; the TTL only matters for changes
+// made by another instance or directly in the database.
+const authCacheTTL = 30 * time.Second
+
+// authCacheSweepSize is how many entries the cache holds before stale ones are swept out
+const authCacheSweepSize = 1000
+
+// authState is what AuthMiddleware needs to know about a token's user and session
+type authState struct {
+	userID           int
+	tokenVersion     int
+	sessionActive    bool
+	sessionExpiresAt time.Time
+	fetchedAt        time.Time
+}
+
+// AuthCache remembers recent user and session lookups by session ID so
+// AuthMiddleware doesn't cost a database round trip on every request
+type AuthCache struct {
+	db       *database.Database
+	mu       sync.Mutex
+	sessions map[int]authState
+
+	// Bumped by InvalidateUser so a lookup that read the database before the
+	// change can tell its result is stale and not cache it
+	generations map[int]uint64
+}
+
+func NewAuthCache(db *database.Database) *AuthCache {
+	return &AuthCache{db: db, sessions: make(map[int]authState), generations: make(map[int]uint64)}
+}
+
+// lookup returns the state of a session and its user. found is false if the
+// session doesn't exist or belongs to someone else, e.g. a deleted user.
+func (c *AuthCache) lookup(sessionID, userID int) (state authState, found bool, err error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	state, cached := c.sessions[sessionID]
+	generation := c.generations[userID]
+	c.mu.Unlock()
+	if cached && now.Sub(state.fetchedAt) < authCacheTTL {
+		return state, state.userID == userID, nil
+	}
+
+	// Expiry is worked out by the database so the two clocks can't disagree
+	var row struct {
+		UserID        int     `db:"user_id"`
+		TokenVersion  int     `db:"token_version"`
+		SessionActive bool    `db:"session_active"`
+		ExpiresIn     float64 `db:"expires_in"`
+	}
+	err = c.db.Get(&row, `
+		SELECT s.user_id, u.token_version, s.revoked_at IS NULL as session_active,
+		       EXTRACT(EPOCH FROM s.expires_at - CURRENT_TIMESTAMP)::float8 as expires_in
+		FROM auth_sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.id = $1
+	`, sessionID)
+	if err == sql.ErrNoRows {
+		c.InvalidateSession(sessionID)
+		return authState{}, false, nil
+	}
+	if err != nil {
+		return authState{}, false, err
+	}
+
+	state = authState{
+		userID:           row.UserID,
+		tokenVersion:     row.TokenVersion,
+		sessionActive:    row.SessionActive,
+		sessionExpiresAt: now.Add(time.Duration(row.ExpiresIn * float64(time.Second))),
+		fetchedAt:        now,
+	}
+	if state.userID != userID {
+		return state, false, nil
+	}
+
+	c.mu.Lock()
+	if c.generations[userID] != generation {
+		// The user was invalidated while we read, so the row may predate the
+		// change. Read again rather than caching or trusting it.
+		c.mu.Unlock()
+		return c.lookup(sessionID, userID)
+	}
+	if len(c.sessions) >= authCacheSweepSize {
+		for id, s := range c.sessions {
+			if now.Sub(s.fetchedAt) >= authCacheTTL {
+				delete(c.sessions, id)
+			}
+		}
+	}
+	c.sessions[sessionID] = state
+	c.mu.Unlock()
+	return state, true, nil
+}
+
+// InvalidateSession drops a session so its next request is checked against the database
+func (c *AuthCache) InvalidateSession(sessionID int) {
+	c.mu.Lock()
+	delete(c.sessions, sessionID)
+	c.mu.Unlock()
+}
+
+// InvalidateUser drops every session belonging to a user, e.g. after a role change or deletion
+func (c *AuthCache) InvalidateUser(userID int) {
+	c.mu.Lock()
+	c.generations[userID]++
+	for id, state := range c.sessions {
+		if state.userID == userID {
+			delete(c.sessions, id)
+		}
+	}
+	c.mu.Unlock()
+}
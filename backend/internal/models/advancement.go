@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+const (
+	AdvancementMaxDailyDice  = "max_daily_dice"
+	AdvancementNewTrait      = "new_trait"
+	AdvancementTraitModifier = "trait_modifier"
+
+	AdvancementPending  = "pending"
+	AdvancementApproved = "approved"
+	AdvancementRejected = "rejected"
+)
+
+// DefaultAdvancementThresholds is the cumulative experience needed for each
+// advancement when a campaign doesn't configure its own
+var DefaultAdvancementThresholds = []int{10, 25, 45, 70, 100}
+
+type ExperienceEntry struct {
+	ID              int       `json:"id" db:"id"`
+	CharacterID     int       `json:"character_id" db:"character_id"`
+	Amount          int       `json:"amount" db:"amount"`
+	Reason          string    `json:"reason" db:"reason"`
+	IsMilestone     bool      `json:"is_milestone" db:"is_milestone"`
+	CampaignDay     int       `json:"campaign_day" db:"campaign_day"`
+	AwardedByUserID *int      `json:"awarded_by_user_id" db:"awarded_by_user_id"`
+	AwardedByName   *string   `json:"awarded_by_name" db:"awarded_by_name"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+type CharacterAdvancement struct {
+	ID                int        `json:"id" db:"id"`
+	CharacterID       int        `json:"character_id" db:"character_id"`
+	Kind              string     `json:"kind" db:"kind"`
+	Status            string     `json:"status" db:"status"`
+	TraitID           *int       `json:"trait_id" db:"trait_id"`
+	TraitName         *string    `json:"trait_name" db:"trait_name"`
+	TraitKind         *string    `json:"trait_kind" db:"trait_kind"`
+	TraitModifier     *int       `json:"trait_modifier" db:"trait_modifier"`
+	Note              *string    `json:"note" db:"note"`
+	RequestedByUserID *int       `json:"requested_by_user_id" db:"requested_by_user_id"`
+	ReviewedByUserID  *int       `json:"reviewed_by_user_id" db:"reviewed_by_user_id"`
+	ReviewNote        *string    `json:"review_note" db:"review_note"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	ReviewedAt        *time.Time `json:"reviewed_at" db:"reviewed_at"`
+}
+
+// CharacterProgress summarises a character's experience and advancement history
+type CharacterProgress struct {
+	CharacterID        int                    `json:"character_id"`
+	Experience         int                    `json:"experience"`
+	Thresholds         []int                  `json:"thresholds"`
+	NextThreshold      *int                   `json:"next_threshold"` // nil once every threshold is reached
+	AdvancementsEarned int                    `json:"advancements_earned"`
+	AdvancementsUsed   int                    `json:"advancements_used"` // Approved and pending requests
+	Available          int                    `json:"available"`
+	Experiences        []ExperienceEntry      `json:"experience_entries"`
+	Advancements       []CharacterAdvancement `json:"advancements"`
+}
+
+type AwardExperienceRequest struct {
+	Amount      int    `json:"amount"` // Negative amounts correct earlier mistakes
+	Reason      string `json:"reason"`
+	IsMilestone bool   `json:"is_milestone"`
+}
+
+type RequestAdvancementRequest struct {
+	Kind          string  `json:"kind"`
+	TraitID       *int    `json:"trait_id"`       // trait_modifier: the trait to improve
+	TraitName     *string `json:"trait_name"`     // new_trait
+	TraitKind     *string `json:"trait_kind"`     // new_trait, defaults to skill
+	TraitModifier *int    `json:"trait_modifier"` // new_trait, defaults to +1
+	Note          *string `json:"note"`
+}
+
+type ReviewAdvancementRequest struct {
+	Note *string `json:"note"`
+}
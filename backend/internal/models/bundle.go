@@ -0,0 +1,234 @@
+package models
+
+import "time"
+
+const (
+	// BundleFormat identifies a campaign export file
+	BundleFormat = "sleeper-campaign"
+
+	// BundleVersion is bumped whenever the bundle layout changes incompatibly
+	BundleVersion = 1
+)
+
+// CampaignBundle is a portable snapshot of an entire campaign.
+// IDs inside a bundle are the source instance's IDs and are only
+// used to link records together; they are remapped on import.
+type CampaignBundle struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Campaign   BundleCampaign    `json:"campaign"`
+	Members    []BundleMember    `json:"members"`
+	Characters []BundleCharacter `json:"characters"`
+	DicePools  []BundleDicePool  `json:"dice_pools"`
+	Challenges []BundleChallenge `json:"challenges"`
+	Rolls      []BundleRoll      `json:"rolls"`
+}
+
+type BundleCampaign struct {
+	Name       string           `json:"name" db:"name"`
+	CurrentDay int              `json:"current_day" db:"current_day"`
+	TimePhase  int              `json:"time_phase" db:"time_phase"`
+	Settings   CampaignSettings `json:"settings" db:"settings"`
+	GMUsername string           `json:"gm_username" db:"gm_username"`
+	GMEmail    string           `json:"gm_email" db:"gm_email"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+}
+
+type BundleMember struct {
+	Username string    `json:"username" db:"username"`
+	Email    string    `json:"email" db:"email"`
+	JoinedAt time.Time `json:"joined_at" db:"joined_at"`
+}
+
+type BundleCharacter struct {
+	ID               int        `json:"id" db:"id"`
+	OwnerUsername    *string    `json:"owner_username" db:"owner_username"`
+	OwnerEmail       *string    `json:"owner_email" db:"owner_email"`
+	Name             string     `json:"name" db:"name"`
+	Description      *string    `json:"description,omitempty" db:"description"`
+	Notes            *string    `json:"notes,omitempty" db:"notes"`
+	SkillName        *string    `json:"skill_name" db:"skill_name"`
+	SkillModifier    int        `json:"skill_modifier" db:"skill_modifier"`
+	WeaknessName     *string    `json:"weakness_name" db:"weakness_name"`
+	WeaknessModifier int        `json:"weakness_modifier" db:"weakness_modifier"`
+	MaxDailyDice     int        `json:"max_daily_dice" db:"max_daily_dice"`
+	Kind             string     `json:"kind,omitempty" db:"kind"` // Empty in older bundles; unowned characters become NPCs
+	IsRevealed       bool       `json:"is_revealed,omitempty" db:"is_revealed"`
+	StatBlock        *string    `json:"stat_block,omitempty" db:"stat_block"`
+	Status           string     `json:"status,omitempty" db:"status"` // Empty in older bundles, meaning active
+	RetiredAt        *time.Time `json:"retired_at,omitempty" db:"retired_at"`
+	RetiredReason    *string    `json:"retired_reason,omitempty" db:"retired_reason"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+
+	// Traits is nil in bundles exported before traits existed; importing
+	// such a bundle derives them from the skill and weakness fields
+	Traits     []BundleTrait     `json:"traits,omitempty"`
+	Conditions []BundleCondition `json:"conditions,omitempty"`
+	Items      []BundleItem      `json:"items,omitempty"`
+
+	Experience   []BundleExperience  `json:"experience,omitempty"`
+	Advancements []BundleAdvancement `json:"advancements,omitempty"`
+
+	// Versions is nil in older bundles; importing records the character as version 1
+	Versions []BundleCharacterVersion `json:"versions,omitempty"`
+}
+
+type BundleCharacterVersion struct {
+	CharacterID int               `json:"-" db:"character_id"`
+	Version     int               `json:"version" db:"version"`
+	Change      string            `json:"change" db:"change"`
+	Snapshot    CharacterSnapshot `json:"snapshot" db:"snapshot"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+}
+
+type BundleExperience struct {
+	CharacterID int       `json:"-" db:"character_id"`
+	Amount      int       `json:"amount" db:"amount"`
+	Reason      string    `json:"reason" db:"reason"`
+	IsMilestone bool      `json:"is_milestone" db:"is_milestone"`
+	CampaignDay int       `json:"campaign_day" db:"campaign_day"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+type BundleAdvancement struct {
+	CharacterID   int        `json:"-" db:"character_id"`
+	Kind          string     `json:"kind" db:"kind"`
+	Status        string     `json:"status" db:"status"`
+	TraitID       *int       `json:"trait_id" db:"trait_id"`
+	TraitName     *string    `json:"trait_name" db:"trait_name"`
+	TraitKind     *string    `json:"trait_kind" db:"trait_kind"`
+	TraitModifier *int       `json:"trait_modifier" db:"trait_modifier"`
+	Note          *string    `json:"note" db:"note"`
+	ReviewNote    *string    `json:"review_note" db:"review_note"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ReviewedAt    *time.Time `json:"reviewed_at" db:"reviewed_at"`
+}
+
+type BundleItem struct {
+	ID          int     `json:"id" db:"id"`
+	CharacterID int     `json:"-" db:"character_id"`
+	Name        string  `json:"name" db:"name"`
+	Description *string `json:"description" db:"description"`
+	Quantity    int     `json:"quantity" db:"quantity"`
+	D6Modifier  int     `json:"d6_modifier" db:"d6_modifier"`
+	Consumable  bool    `json:"consumable" db:"consumable"`
+}
+
+type BundleCondition struct {
+	ID              int        `json:"id" db:"id"`
+	CharacterID     int        `json:"-" db:"character_id"`
+	Name            string     `json:"name" db:"name"`
+	Description     *string    `json:"description" db:"description"`
+	D6Modifier      int        `json:"d6_modifier" db:"d6_modifier"`
+	DiceModifier    int        `json:"dice_modifier" db:"dice_modifier"`
+	StartedOnDay    int        `json:"started_on_day" db:"started_on_day"`
+	ExpiresAfterDay *int       `json:"expires_after_day" db:"expires_after_day"`
+	RollsRemaining  *int       `json:"rolls_remaining" db:"rolls_remaining"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	EndedAt         *time.Time `json:"ended_at" db:"ended_at"`
+	EndedReason     *string    `json:"ended_reason" db:"ended_reason"`
+}
+
+type BundleTrait struct {
+	ID          int     `json:"id" db:"id"`
+	CharacterID int     `json:"-" db:"character_id"`
+	Name        string  `json:"name" db:"name"`
+	Kind        string  `json:"kind" db:"kind"`
+	Modifier    int     `json:"modifier" db:"modifier"`
+	Description *string `json:"description" db:"description"`
+	UsesPerDay  *int    `json:"uses_per_day" db:"uses_per_day"`
+}
+
+type BundleDicePool struct {
+	ID          int             `json:"id" db:"id"`
+	CharacterID int             `json:"character_id" db:"character_id"`
+	RolledAt    time.Time       `json:"rolled_at" db:"rolled_at"`
+	Dice        []BundlePoolDie `json:"dice"`
+}
+
+type BundlePoolDie struct {
+	ID        int  `json:"id" db:"id"`
+	PoolID    int  `json:"-" db:"pool_id"`
+	DieResult int  `json:"die_result" db:"die_result"`
+	IsUsed    bool `json:"is_used" db:"is_used"`
+	Position  int  `json:"position" db:"position"`
+}
+
+type BundleChallenge struct {
+	ID                      int            `json:"id" db:"id"`
+	Description             string         `json:"description" db:"description"`
+	DifficultyModifier      int            `json:"difficulty_modifier" db:"difficulty_modifier"`
+	IsGroupChallenge        bool           `json:"is_group_challenge" db:"is_group_challenge"`
+	IsActive                bool           `json:"is_active" db:"is_active"`
+	Status                  string         `json:"status,omitempty" db:"status"` // Empty in older bundles; is_active decides
+	StartsOnDay             *int           `json:"starts_on_day,omitempty" db:"starts_on_day"`
+	EndsAfterDay            *int           `json:"ends_after_day,omitempty" db:"ends_after_day"`
+	ProgressClock           *ProgressClock `json:"progress_clock,omitempty" db:"progress_clock"`
+	MaxAttemptsPerCharacter *int           `json:"max_attempts_per_character,omitempty" db:"max_attempts_per_character"`
+	IsHidden                bool           `json:"is_hidden,omitempty" db:"is_hidden"`
+	TargetCharacterIDs      []int          `json:"target_character_ids,omitempty" db:"-"`
+	Consequences
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type BundleRoll struct {
+	CharacterID      int              `json:"character_id" db:"character_id"`
+	PoolDieID        *int             `json:"pool_die_id" db:"pool_dice_id"`
+	D20Roll          *int             `json:"d20_roll" db:"d20_roll"`
+	ActionType       *string          `json:"action_type" db:"action_type"`
+	Success          *bool            `json:"success" db:"success"`
+	Outcome          string           `json:"outcome" db:"outcome"`
+	Notes            *string          `json:"notes" db:"notes"`
+	ChallengeID      *int             `json:"challenge_id" db:"challenge_id"`
+	SkillApplied     bool             `json:"skill_applied" db:"skill_applied"`
+	OtherModifiers   int              `json:"other_modifiers" db:"other_modifiers"`
+	ModifiedD6       *int             `json:"modified_d6" db:"modified_d6"`
+	CampaignDay      *int             `json:"campaign_day,omitempty" db:"campaign_day"`
+	CharacterVersion *int             `json:"character_version,omitempty" db:"character_version"`
+	Consequence      *RollConsequence `json:"consequence,omitempty" db:"consequence"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
+
+	ID         int                   `json:"-" db:"id"`
+	Traits     []BundleRollTrait     `json:"traits,omitempty"`
+	Conditions []BundleRollCondition `json:"conditions,omitempty"`
+	Items      []BundleRollItem      `json:"items,omitempty"`
+}
+
+type BundleRollItem struct {
+	RollID     int    `json:"-" db:"roll_id"`
+	ItemID     *int   `json:"item_id" db:"item_id"` // Source item ID
+	Name       string `json:"name" db:"name"`
+	D6Modifier int    `json:"d6_modifier" db:"d6_modifier"`
+	Consumed   bool   `json:"consumed" db:"consumed"`
+}
+
+type BundleRollCondition struct {
+	RollID      int    `json:"-" db:"roll_id"`
+	ConditionID *int   `json:"condition_id" db:"condition_id"` // Source condition ID
+	Name        string `json:"name" db:"name"`
+	D6Modifier  int    `json:"d6_modifier" db:"d6_modifier"`
+}
+
+type BundleRollTrait struct {
+	RollID   int    `json:"-" db:"roll_id"`
+	TraitID  *int   `json:"trait_id" db:"trait_id"` // Source trait ID, nil if it was deleted
+	Name     string `json:"name" db:"name"`
+	Kind     string `json:"kind" db:"kind"`
+	Modifier int    `json:"modifier" db:"modifier"`
+}
+
+// BundleIssue describes a problem found while importing a bundle
+type BundleIssue struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// BundleImportReport summarises what an import did, or would do in dry-run mode
+type BundleImportReport struct {
+	DryRun     bool           `json:"dry_run"`
+	CampaignID *int           `json:"campaign_id"`
+	Conflicts  []BundleIssue  `json:"conflicts"`
+	Warnings   []BundleIssue  `json:"warnings"`
+	Created    map[string]int `json:"created"`
+}
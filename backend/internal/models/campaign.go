@@ -1,15 +1,107 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 type Campaign struct {
-	ID         int       `json:"id" db:"id"`
-	Name       string    `json:"name" db:"name"`
-	GMUserID   int       `json:"gm_user_id" db:"gm_user_id"`
-	CurrentDay int       `json:"current_day" db:"current_day"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID         int              `json:"id" db:"id"`
+	Name       string           `json:"name" db:"name"`
+	GMUserID   int              `json:"gm_user_id" db:"gm_user_id"`
+	CurrentDay int              `json:"current_day" db:"current_day"`
+	TimePhase  int              `json:"time_phase" db:"time_phase"`
+	Settings   CampaignSettings `json:"settings" db:"settings"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+}
+
+// CampaignSettings holds GM-configurable options, stored as JSONB
+type CampaignSettings struct {
+	// Dice pool size for newly created characters (database default is 3)
+	DefaultMaxDailyDice *int `json:"default_max_daily_dice,omitempty"`
+
+	// Active characters each player may have (defaults to 1)
+	MaxCharactersPerUser *int `json:"max_characters_per_user,omitempty"`
+
+	// Cumulative experience needed for each advancement
+	AdvancementThresholds []int `json:"advancement_thresholds,omitempty"`
+
+	// Named months, weekdays and time-of-day phases for the in-game clock
+	Calendar *CalendarSettings `json:"calendar,omitempty"`
+}
+
+// CharacterLimit returns how many active characters each player may have
+func (s CampaignSettings) CharacterLimit() int {
+	if s.MaxCharactersPerUser == nil {
+		return 1
+	}
+	return *s.MaxCharactersPerUser
+}
+
+// Thresholds returns the campaign's advancement thresholds, or the defaults
+func (s CampaignSettings) Thresholds() []int {
+	if len(s.AdvancementThresholds) == 0 {
+		return DefaultAdvancementThresholds
+	}
+	return s.AdvancementThresholds
+}
+
+// Scan implements sql.Scanner for the JSONB settings column
+func (s *CampaignSettings) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = CampaignSettings{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into CampaignSettings", src)
+	}
+}
+
+// Value implements driver.Valuer for the JSONB settings column
+func (s CampaignSettings) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
 }
 
 type CreateCampaignRequest struct {
 	Name string `json:"name"`
 }
+
+type CloneCampaignRequest struct {
+	Name              string `json:"name"`
+	IncludeChallenges bool   `json:"include_challenges"`
+	IncludeCharacters bool   `json:"include_characters"` // Only unassigned characters are copied
+	IncludeSettings   bool   `json:"include_settings"`
+}
+
+type CampaignTemplate struct {
+	ID               int       `json:"id" db:"id"`
+	Name             string    `json:"name" db:"name"`
+	Description      *string   `json:"description" db:"description"`
+	CreatedByUserID  *int      `json:"created_by_user_id" db:"created_by_user_id"`
+	SourceCampaignID *int      `json:"source_campaign_id" db:"source_campaign_id"`
+	ChallengeCount   int       `json:"challenge_count" db:"challenge_count"`
+	CharacterCount   int       `json:"character_count" db:"character_count"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateCampaignTemplateRequest struct {
+	Name              string  `json:"name"`
+	Description       *string `json:"description"`
+	IncludeChallenges bool    `json:"include_challenges"`
+	IncludeCharacters bool    `json:"include_characters"`
+	IncludeSettings   bool    `json:"include_settings"`
+}
+
+type InstantiateTemplateRequest struct {
+	Name string `json:"name"`
+}
@@ -3,26 +3,110 @@ package models
 import "time"
 
 type Challenge struct {
-	ID                 int       `json:"id" db:"id"`
-	CampaignID         int       `json:"campaign_id" db:"campaign_id"`
-	CreatedByUserID    int       `json:"created_by_user_id" db:"created_by_user_id"`
-	Description        string    `json:"description" db:"description"`
-	DifficultyModifier int       `json:"difficulty_modifier" db:"difficulty_modifier"`
-	IsGroupChallenge   bool      `json:"is_group_challenge" db:"is_group_challenge"`
-	IsActive           bool      `json:"is_active" db:"is_active"`
-	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	ID                 int    `json:"id" db:"id"`
+	CampaignID         int    `json:"campaign_id" db:"campaign_id"`
+	CreatedByUserID    int    `json:"created_by_user_id" db:"created_by_user_id"`
+	Description        string `json:"description" db:"description"`
+	DifficultyModifier int    `json:"difficulty_modifier" db:"difficulty_modifier"`
+	IsGroupChallenge   bool   `json:"is_group_challenge" db:"is_group_challenge"`
+	IsActive           bool   `json:"is_active" db:"is_active"` // status == active
+	Status             string `json:"status" db:"status"`
+	ChallengeSchedule
+	SessionID               *int           `json:"session_id" db:"session_id"`
+	SceneID                 *int           `json:"scene_id" db:"scene_id"`
+	ProgressClock           *ProgressClock `json:"progress_clock" db:"progress_clock"` // nil for single-roll challenges
+	TemplateID              *int           `json:"template_id" db:"template_id"`
+	MaxAttemptsPerCharacter *int           `json:"max_attempts_per_character" db:"max_attempts_per_character"` // nil = unlimited
+	IsHidden                bool           `json:"is_hidden" db:"is_hidden"`
+	TargetCharacterIDs      []int          `json:"target_character_ids" db:"-"` // Empty = open to the whole campaign
+	Consequences
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ChallengeSchedule limits a challenge to a window of campaign days. It is
+// scheduled before StartsOnDay and expires once the day passes EndsAfterDay.
+type ChallengeSchedule struct {
+	StartsOnDay  *int `json:"starts_on_day" db:"starts_on_day"`   // nil = starts straight away
+	EndsAfterDay *int `json:"ends_after_day" db:"ends_after_day"` // nil = never expires
+}
+
+// ChallengeTargeting limits who may attempt a challenge
+type ChallengeTargeting struct {
+	TargetCharacterIDs      []int `json:"target_character_ids"`       // Empty = anyone in the campaign
+	MaxAttemptsPerCharacter *int  `json:"max_attempts_per_character"` // nil = unlimited
+	IsHidden                bool  `json:"is_hidden"`                  // Only the GM and targeted players see it
+}
+
+// Consequences is what happens when a roll against a challenge lands: a line
+// of text per outcome and, optionally, a short random table to roll on
+type Consequences struct {
+	SuccessText       *string            `json:"success_text" db:"success_text"`
+	NeutralText       *string            `json:"neutral_text" db:"neutral_text"`
+	FailureText       *string            `json:"failure_text" db:"failure_text"`
+	ConsequenceTables *ConsequenceTables `json:"consequence_tables" db:"consequence_tables"`
+}
+
+// ChallengeStats summarises the rolls made against a challenge, counted by outcome
+type ChallengeStats struct {
+	TotalAttempts      int      `json:"total_attempts" db:"total_attempts"`
+	SuccessfulAttempts int      `json:"successful_attempts" db:"successful_attempts"`
+	NeutralAttempts    int      `json:"neutral_attempts" db:"neutral_attempts"`
+	FailedAttempts     int      `json:"failed_attempts" db:"failed_attempts"`
+	AverageModifiedD6  *float64 `json:"average_modified_d6" db:"average_modified_d6"` // nil until someone rolls
+	SkillUsageRate     *float64 `json:"skill_usage_rate" db:"skill_usage_rate"`       // Fraction of attempts with a skill applied
 }
 
 type ChallengeWithStats struct {
 	Challenge
-	TotalAttempts      int `json:"total_attempts" db:"total_attempts"`
-	SuccessfulAttempts int `json:"successful_attempts" db:"successful_attempts"`
-	FailedAttempts     int `json:"failed_attempts" db:"failed_attempts"`
+	ChallengeStats
+}
+
+type ChallengeCharacterStats struct {
+	CharacterID   int    `json:"character_id" db:"character_id"`
+	CharacterName string `json:"character_name" db:"character_name"`
+	ChallengeStats
+}
+
+// ChallengeDetail is a challenge with its overall stats, a breakdown per
+// character and every attempt in the order they were rolled
+type ChallengeDetail struct {
+	ChallengeWithStats
+	Characters []ChallengeCharacterStats  `json:"characters"`
+	Attempts   []RollHistoryWithCharacter `json:"attempts"`
 }
 
 type CreateChallengeRequest struct {
-	CampaignID         int    `json:"campaign_id"`
-	Description        string `json:"description"`
-	DifficultyModifier int    `json:"difficulty_modifier"`
-	IsGroupChallenge   bool   `json:"is_group_challenge"`
+	CampaignID         int                   `json:"campaign_id"`
+	Description        string                `json:"description"`
+	DifficultyModifier int                   `json:"difficulty_modifier"`
+	IsGroupChallenge   bool                  `json:"is_group_challenge"`
+	ProgressClock      *ProgressClockRequest `json:"progress_clock"`
+	SceneID            *int                  `json:"scene_id"` // Defaults to the campaign's active scene
+	Consequences
+	ChallengeTargeting
+	ChallengeSchedule
+}
+
+// Challenge statuses, which are also the values for the ?status= filter when
+// listing challenges. ChallengeStatusAll lists every status.
+const (
+	ChallengeStatusScheduled = "scheduled" // Waiting for its start day
+	ChallengeStatusActive    = "active"
+	ChallengeStatusCompleted = "completed"
+	ChallengeStatusExpired   = "expired" // Its end day passed before it was completed
+	ChallengeStatusAll       = "all"
+)
+
+// UpdateChallengeRequest edits a challenge; omitted fields are left unchanged
+type UpdateChallengeRequest struct {
+	Description         *string               `json:"description"`
+	DifficultyModifier  *int                  `json:"difficulty_modifier"`
+	IsGroupChallenge    *bool                 `json:"is_group_challenge"`
+	ProgressClock       *ProgressClockRequest `json:"progress_clock"` // Replaces the clock's targets and weights
+	RemoveProgressClock bool                  `json:"remove_progress_clock"`
+	SceneID             *int                  `json:"scene_id"`
+	RemoveScene         bool                  `json:"remove_scene"`
+	Consequences                              // Omitted texts and tables are left unchanged
+	ChallengeSchedule                         // Omitted days are left unchanged
+	ClearSchedule       bool                  `json:"clear_schedule"` // Drops both days before applying any given ones
 }
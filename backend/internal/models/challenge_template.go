@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Scopes a challenge template can belong to
+const (
+	ChallengeTemplateScopePersonal = "personal" // Only the owning GM sees it
+	ChallengeTemplateScopeInstance = "instance" // Shared with every GM on the instance
+)
+
+type ChallengeTemplate struct {
+	ID                 int            `json:"id" db:"id"`
+	OwnerUserID        *int           `json:"owner_user_id" db:"owner_user_id"` // nil for instance-wide templates
+	Scope              string         `json:"scope" db:"scope"`
+	Name               string         `json:"name" db:"name"`
+	Description        string         `json:"description" db:"description"`
+	DifficultyModifier int            `json:"difficulty_modifier" db:"difficulty_modifier"`
+	IsGroupChallenge   bool           `json:"is_group_challenge" db:"is_group_challenge"`
+	ProgressClock      *ProgressClock `json:"progress_clock" db:"progress_clock"`
+	Consequences
+	CreatedByUserID *int      `json:"created_by_user_id" db:"created_by_user_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateChallengeTemplateRequest struct {
+	Scope              string                `json:"scope"` // personal (default) or instance (admins only)
+	Name               string                `json:"name"`
+	Description        string                `json:"description"`
+	DifficultyModifier int                   `json:"difficulty_modifier"`
+	IsGroupChallenge   bool                  `json:"is_group_challenge"`
+	ProgressClock      *ProgressClockRequest `json:"progress_clock"`
+	Consequences
+}
+
+// UpdateChallengeTemplateRequest edits a template; omitted fields are left unchanged
+type UpdateChallengeTemplateRequest struct {
+	Name                *string               `json:"name"`
+	Description         *string               `json:"description"`
+	DifficultyModifier  *int                  `json:"difficulty_modifier"`
+	IsGroupChallenge    *bool                 `json:"is_group_challenge"`
+	ProgressClock       *ProgressClockRequest `json:"progress_clock"`
+	RemoveProgressClock bool                  `json:"remove_progress_clock"`
+	Consequences
+}
+
+// InstantiateChallengeTemplateRequest creates a challenge from a template,
+// optionally tweaking its description or difficulty for this campaign
+type InstantiateChallengeTemplateRequest struct {
+	CampaignID         int     `json:"campaign_id"`
+	Description        *string `json:"description"`
+	DifficultyModifier *int    `json:"difficulty_modifier"`
+	SceneID            *int    `json:"scene_id"` // Defaults to the campaign's active scene
+	ChallengeTargeting
+	ChallengeSchedule
+}
@@ -2,25 +2,65 @@ package models
 
 import "time"
 
+const (
+	CharacterStatusActive  = "active"
+	CharacterStatusRetired = "retired"
+)
+
 type Character struct {
-	ID               int       `json:"id" db:"id"`
-	CampaignID       int       `json:"campaign_id" db:"campaign_id"`
-	UserID           int       `json:"user_id" db:"user_id"`
-	Name             string    `json:"name" db:"name"`
-	SkillName        *string   `json:"skill_name" db:"skill_name"`
-	SkillModifier    int       `json:"skill_modifier" db:"skill_modifier"`
-	WeaknessName     *string   `json:"weakness_name" db:"weakness_name"`
-	WeaknessModifier int       `json:"weakness_modifier" db:"weakness_modifier"`
-	MaxDailyDice     int       `json:"max_daily_dice" db:"max_daily_dice"`
-	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	ID               int        `json:"id" db:"id"`
+	CampaignID       int        `json:"campaign_id" db:"campaign_id"`
+	UserID           *int       `json:"user_id" db:"user_id"` // nil for unassigned characters
+	Name             string     `json:"name" db:"name"`
+	Description      *string    `json:"description" db:"description"`
+	Notes            *string    `json:"notes" db:"notes"`
+	SkillName        *string    `json:"skill_name" db:"skill_name"`
+	SkillModifier    int        `json:"skill_modifier" db:"skill_modifier"`
+	WeaknessName     *string    `json:"weakness_name" db:"weakness_name"`
+	WeaknessModifier int        `json:"weakness_modifier" db:"weakness_modifier"`
+	MaxDailyDice     int        `json:"max_daily_dice" db:"max_daily_dice"`
+	Kind             string     `json:"kind" db:"kind"`
+	IsRevealed       bool       `json:"is_revealed" db:"is_revealed"`         // NPCs only; players can't see hidden NPCs
+	StatBlock        *string    `json:"stat_block,omitempty" db:"stat_block"` // GM-only notes for NPCs
+	Status           string     `json:"status" db:"status"`
+	RetiredAt        *time.Time `json:"retired_at" db:"retired_at"`
+	RetiredReason    *string    `json:"retired_reason" db:"retired_reason"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+
+	Traits []CharacterTrait `json:"traits,omitempty" db:"-"`
 }
 
 type CreateCharacterRequest struct {
 	CampaignID       int     `json:"campaign_id"`
 	Name             string  `json:"name"`
+	Description      *string `json:"description"`
 	SkillName        *string `json:"skill_name"`
 	SkillModifier    int     `json:"skill_modifier"`
 	WeaknessName     *string `json:"weakness_name"`
 	WeaknessModifier int     `json:"weakness_modifier"`
 	AssignedUserID   *int    `json:"assigned_user_id"` // GM can assign to specific user
 }
+
+// UpdateCharacterRequest holds the fields a player may change on their own character
+type UpdateCharacterRequest struct {
+	Name         *string `json:"name"`
+	Description  *string `json:"description"`
+	Notes        *string `json:"notes"`
+	SkillName    *string `json:"skill_name"`
+	WeaknessName *string `json:"weakness_name"`
+}
+
+// UpdateCharacterMechanicsRequest holds the GM-controlled fields of a character
+type UpdateCharacterMechanicsRequest struct {
+	SkillModifier    *int `json:"skill_modifier"`    // Applied to the character's first skill trait
+	WeaknessModifier *int `json:"weakness_modifier"` // Applied to the character's first weakness trait
+	MaxDailyDice     *int `json:"max_daily_dice"`
+}
+
+type RetireCharacterRequest struct {
+	Reason *string `json:"reason"` // e.g. "Died in the flooded mine"
+}
+
+type ReassignCharacterRequest struct {
+	UserID *int `json:"user_id"` // nil leaves the character unassigned
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	CharacterChangeCreated     = "created"
+	CharacterChangeUpdated     = "updated"
+	CharacterChangeMechanics   = "mechanics_updated"
+	CharacterChangeTraits      = "traits_updated"
+	CharacterChangeAdvancement = "advancement_approved"
+	CharacterChangeRetired     = "retired"
+	CharacterChangeReactivated = "reactivated"
+	CharacterChangeReassigned  = "reassigned"
+	CharacterChangeImported    = "imported"
+)
+
+// CharacterSnapshot is everything about a character that can affect a roll
+type CharacterSnapshot struct {
+	Name             string          `json:"name" db:"name"`
+	Description      *string         `json:"description" db:"description"`
+	UserID           *int            `json:"user_id" db:"user_id"`
+	Status           string          `json:"status" db:"status"`
+	SkillName        *string         `json:"skill_name" db:"skill_name"`
+	SkillModifier    int             `json:"skill_modifier" db:"skill_modifier"`
+	WeaknessName     *string         `json:"weakness_name" db:"weakness_name"`
+	WeaknessModifier int             `json:"weakness_modifier" db:"weakness_modifier"`
+	MaxDailyDice     int             `json:"max_daily_dice" db:"max_daily_dice"`
+	Traits           []SnapshotTrait `json:"traits" db:"-"`
+}
+
+type SnapshotTrait struct {
+	ID         int    `json:"id" db:"id"`
+	Name       string `json:"name" db:"name"`
+	Kind       string `json:"kind" db:"kind"`
+	Modifier   int    `json:"modifier" db:"modifier"`
+	UsesPerDay *int   `json:"uses_per_day" db:"uses_per_day"`
+}
+
+// Scan implements sql.Scanner for the JSONB snapshot column
+func (s *CharacterSnapshot) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("cannot scan %T into CharacterSnapshot", src)
+	}
+}
+
+// Value implements driver.Valuer for the JSONB snapshot column
+func (s CharacterSnapshot) Value() (driver.Value, error) {
+	if s.Traits == nil {
+		s.Traits = []SnapshotTrait{}
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+type CharacterVersion struct {
+	ID              int               `json:"id" db:"id"`
+	CharacterID     int               `json:"character_id" db:"character_id"`
+	Version         int               `json:"version" db:"version"`
+	Change          string            `json:"change" db:"change"`
+	Snapshot        CharacterSnapshot `json:"snapshot" db:"snapshot"`
+	ChangedByUserID *int              `json:"changed_by_user_id" db:"changed_by_user_id"`
+	ChangedByName   *string           `json:"changed_by_name" db:"changed_by_name"`
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+}
+
+// FieldChange is one difference between two character versions. Trait fields
+// are named like "traits[Sneaky].modifier"; added or removed traits have a nil side.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+type CharacterVersionDiff struct {
+	CharacterID int           `json:"character_id"`
+	From        int           `json:"from"`
+	To          int           `json:"to"`
+	Changes     []FieldChange `json:"changes"`
+}
+
+// Diff lists the fields that differ going from s to other
+func (s CharacterSnapshot) Diff(other CharacterSnapshot) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field string, from, to any) {
+		if !equalJSON(from, to) {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	add("name", s.Name, other.Name)
+	add("description", s.Description, other.Description)
+	add("user_id", s.UserID, other.UserID)
+	add("status", s.Status, other.Status)
+	add("skill_name", s.SkillName, other.SkillName)
+	add("skill_modifier", s.SkillModifier, other.SkillModifier)
+	add("weakness_name", s.WeaknessName, other.WeaknessName)
+	add("weakness_modifier", s.WeaknessModifier, other.WeaknessModifier)
+	add("max_daily_dice", s.MaxDailyDice, other.MaxDailyDice)
+
+	before := make(map[int]SnapshotTrait, len(s.Traits))
+	for _, t := range s.Traits {
+		before[t.ID] = t
+	}
+	for _, t := range other.Traits {
+		old, ok := before[t.ID]
+		if !ok {
+			changes = append(changes, FieldChange{Field: "traits[" + t.Name + "]", From: nil, To: t})
+			continue
+		}
+		delete(before, t.ID)
+		prefix := "traits[" + old.Name + "]."
+		add(prefix+"name", old.Name, t.Name)
+		add(prefix+"kind", old.Kind, t.Kind)
+		add(prefix+"modifier", old.Modifier, t.Modifier)
+		add(prefix+"uses_per_day", old.UsesPerDay, t.UsesPerDay)
+	}
+	for _, t := range s.Traits {
+		if _, removed := before[t.ID]; removed {
+			changes = append(changes, FieldChange{Field: "traits[" + t.Name + "]", From: t, To: nil})
+		}
+	}
+
+	return changes
+}
+
+// equalJSON compares values by their JSON form so nil and non-nil pointers compare sensibly
+func equalJSON(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestCharacterSnapshotDiff(t *testing.T) {
+	desc := "A retired sailor"
+	base := CharacterSnapshot{
+		Name:         "Mara",
+		Status:       CharacterStatusActive,
+		MaxDailyDice: 3,
+		Traits: []SnapshotTrait{
+			{ID: 1, Name: "Sailing", Kind: TraitKindSkill, Modifier: 2},
+			{ID: 2, Name: "Seasick", Kind: TraitKindWeakness, Modifier: -1},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		change func(s *CharacterSnapshot)
+		want   []string // changed field paths, in order
+	}{
+		{
+			name:   "no changes",
+			change: func(s *CharacterSnapshot) {},
+			want:   []string{},
+		},
+		{
+			name: "top-level fields",
+			change: func(s *CharacterSnapshot) {
+				s.Name = "Mara Vell"
+				s.MaxDailyDice = 4
+			},
+			want: []string{"name", "max_daily_dice"},
+		},
+		{
+			name:   "nil pointer to value",
+			change: func(s *CharacterSnapshot) { s.Description = &desc },
+			want:   []string{"description"},
+		},
+		{
+			name: "trait fields are named by the trait's old name",
+			change: func(s *CharacterSnapshot) {
+				s.Traits[0].Name = "Navigation"
+				s.Traits[0].Modifier = 3
+			},
+			want: []string{"traits[Sailing].name", "traits[Sailing].modifier"},
+		},
+		{
+			name:   "trait uses per day",
+			change: func(s *CharacterSnapshot) { s.Traits[1].UsesPerDay = intPtr(1) },
+			want:   []string{"traits[Seasick].uses_per_day"},
+		},
+		{
+			name: "trait added and removed",
+			change: func(s *CharacterSnapshot) {
+				s.Traits = []SnapshotTrait{s.Traits[0], {ID: 3, Name: "Knots", Kind: TraitKindSkill, Modifier: 1}}
+			},
+			want: []string{"traits[Knots]", "traits[Seasick]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base
+			other.Traits = append([]SnapshotTrait(nil), base.Traits...)
+			tt.change(&other)
+
+			got := []string{}
+			for _, c := range base.Diff(other) {
+				got = append(got, c.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got changes %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharacterSnapshotDiffValues(t *testing.T) {
+	before := CharacterSnapshot{Traits: []SnapshotTrait{{ID: 1, Name: "Sailing", Kind: TraitKindSkill}}}
+	after := CharacterSnapshot{}
+
+	changes := before.Diff(after)
+	if len(changes) != 1 {
+		t.Fatalf("got %d changes, want 1", len(changes))
+	}
+	if changes[0].From != before.Traits[0] || changes[0].To != nil {
+		t.Errorf("removed trait should go from the trait to nil, got %v to %v", changes[0].From, changes[0].To)
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+const (
+	ClockReasonIncrement = "increment"
+	ClockReasonAdvance   = "advance"
+	ClockReasonRewind    = "rewind"
+)
+
+// DefaultTimePhases is used when a campaign has no calendar configured
+var DefaultTimePhases = []string{"Morning", "Afternoon", "Evening", "Night"}
+
+// CalendarSettings describes how a campaign's day counter maps onto a calendar.
+// Day 1 is the first day of the first month of FirstYear.
+type CalendarSettings struct {
+	Months    []CalendarMonth `json:"months"`
+	WeekDays  []string        `json:"week_days"`
+	Phases    []string        `json:"phases"`
+	FirstYear int             `json:"first_year"`
+}
+
+type CalendarMonth struct {
+	Name string `json:"name"`
+	Days int    `json:"days"`
+}
+
+// CalendarDate is a day counter resolved against a campaign calendar
+type CalendarDate struct {
+	Year       int     `json:"year"`
+	Month      *string `json:"month"`
+	MonthIndex int     `json:"month_index"`
+	DayOfMonth int     `json:"day_of_month"`
+	WeekDay    *string `json:"week_day"`
+}
+
+// PhaseNames returns the configured time-of-day phases, or the defaults
+func (c *CalendarSettings) PhaseNames() []string {
+	if c == nil || len(c.Phases) == 0 {
+		return DefaultTimePhases
+	}
+	return c.Phases
+}
+
+// DateFor resolves a 1-based campaign day into a calendar date.
+// Without configured months the whole year is a single unnamed month.
+func (c *CalendarSettings) DateFor(day int) CalendarDate {
+	date := CalendarDate{DayOfMonth: day}
+	if c == nil {
+		return date
+	}
+
+	date.Year = c.FirstYear
+	if len(c.WeekDays) > 0 {
+		wd := c.WeekDays[(day-1)%len(c.WeekDays)]
+		date.WeekDay = &wd
+	}
+
+	yearLength := 0
+	for _, m := range c.Months {
+		yearLength += m.Days
+	}
+	if yearLength == 0 {
+		return date
+	}
+
+	remaining := day - 1
+	date.Year += remaining / yearLength
+	remaining %= yearLength
+	for i, m := range c.Months {
+		if remaining < m.Days {
+			name := m.Name
+			date.Month = &name
+			date.MonthIndex = i
+			date.DayOfMonth = remaining + 1
+			break
+		}
+		remaining -= m.Days
+	}
+	return date
+}
+
+// CampaignClock is the current in-game time of a campaign
+type CampaignClock struct {
+	CampaignID int          `json:"campaign_id"`
+	CurrentDay int          `json:"current_day"`
+	TimePhase  int          `json:"time_phase"`
+	PhaseName  string       `json:"phase_name"`
+	Date       CalendarDate `json:"date"`
+}
+
+type CampaignClockLogEntry struct {
+	ID              int       `json:"id" db:"id"`
+	CampaignID      int       `json:"campaign_id" db:"campaign_id"`
+	ChangedByUserID *int      `json:"changed_by_user_id" db:"changed_by_user_id"`
+	ChangedByName   *string   `json:"changed_by_name" db:"changed_by_name"`
+	Reason          string    `json:"reason" db:"reason"`
+	PreviousDay     int       `json:"previous_day" db:"previous_day"`
+	PreviousPhase   int       `json:"previous_phase" db:"previous_phase"`
+	NewDay          int       `json:"new_day" db:"new_day"`
+	NewPhase        int       `json:"new_phase" db:"new_phase"`
+	Note            *string   `json:"note" db:"note"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+type AdvanceClockRequest struct {
+	Days   int     `json:"days"`
+	Phases int     `json:"phases"` // Wraps into days past the last phase
+	Note   *string `json:"note"`
+}
+
+type RewindClockRequest struct {
+	Days int     `json:"days"` // Defaults to 1
+	Note *string `json:"note"`
+}
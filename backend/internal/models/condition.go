@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+const (
+	ConditionEndedExpired = "expired"
+	ConditionEndedUsedUp  = "used_up"
+	ConditionEndedRemoved = "removed"
+)
+
+type CharacterCondition struct {
+	ID              int        `json:"id" db:"id"`
+	CharacterID     int        `json:"character_id" db:"character_id"`
+	Name            string     `json:"name" db:"name"`
+	Description     *string    `json:"description" db:"description"`
+	D6Modifier      int        `json:"d6_modifier" db:"d6_modifier"`
+	DiceModifier    int        `json:"dice_modifier" db:"dice_modifier"` // Extra (or fewer) dice in new pools
+	StartedOnDay    int        `json:"started_on_day" db:"started_on_day"`
+	ExpiresAfterDay *int       `json:"expires_after_day" db:"expires_after_day"`
+	RollsRemaining  *int       `json:"rolls_remaining" db:"rolls_remaining"`
+	CreatedByUserID *int       `json:"created_by_user_id" db:"created_by_user_id"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	EndedAt         *time.Time `json:"ended_at" db:"ended_at"`
+	EndedReason     *string    `json:"ended_reason" db:"ended_reason"`
+}
+
+// RollCondition is a condition as it was when applied to a roll
+type RollCondition struct {
+	RollID      int    `json:"-" db:"roll_id"`
+	ConditionID *int   `json:"condition_id" db:"condition_id"`
+	Name        string `json:"name" db:"name"`
+	D6Modifier  int    `json:"d6_modifier" db:"d6_modifier"`
+}
+
+// PoolCondition is a condition that changed the size of a dice pool
+type PoolCondition struct {
+	PoolID       int    `json:"-" db:"pool_id"`
+	ConditionID  *int   `json:"condition_id" db:"condition_id"`
+	Name         string `json:"name" db:"name"`
+	DiceModifier int    `json:"dice_modifier" db:"dice_modifier"`
+}
+
+// PoolSize works out how many dice a new pool gets from a character's daily
+// dice and active conditions, along with the conditions that changed it.
+// Conditions can add or remove dice, but a pool always has at least one.
+func PoolSize(maxDailyDice int, conditions []CharacterCondition) (int, []PoolCondition) {
+	size := maxDailyDice
+	applied := []PoolCondition{}
+	for _, c := range conditions {
+		if c.DiceModifier == 0 {
+			continue
+		}
+		conditionID := c.ID
+		size += c.DiceModifier
+		applied = append(applied, PoolCondition{
+			ConditionID: &conditionID, Name: c.Name, DiceModifier: c.DiceModifier,
+		})
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size, applied
+}
+
+type CreateConditionRequest struct {
+	Name          string  `json:"name"`
+	Description   *string `json:"description"`
+	D6Modifier    int     `json:"d6_modifier"`
+	DiceModifier  int     `json:"dice_modifier"`
+	DurationDays  *int    `json:"duration_days"`  // Counts the current day; nil lasts until removed
+	DurationRolls *int    `json:"duration_rolls"` // Number of rolls before it wears off
+}
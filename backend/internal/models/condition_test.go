@@ -0,0 +1,59 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPoolSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxDice    int
+		conditions []CharacterCondition
+		wantSize   int
+		wantNames  []string // conditions recorded against the pool, in order
+	}{
+		{
+			name:      "no conditions",
+			maxDice:   3,
+			wantSize:  3,
+			wantNames: []string{},
+		},
+		{
+			name:    "only dice modifiers change the pool",
+			maxDice: 3,
+			conditions: []CharacterCondition{
+				{ID: 1, Name: "Inspired", DiceModifier: 2},
+				{ID: 2, Name: "Bruised", D6Modifier: -1},
+				{ID: 3, Name: "Exhausted", DiceModifier: -1},
+			},
+			wantSize:  4,
+			wantNames: []string{"Inspired", "Exhausted"},
+		},
+		{
+			name:    "never below one die",
+			maxDice: 2,
+			conditions: []CharacterCondition{
+				{ID: 1, Name: "Poisoned", DiceModifier: -5},
+			},
+			wantSize:  1,
+			wantNames: []string{"Poisoned"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, applied := PoolSize(tt.maxDice, tt.conditions)
+			if size != tt.wantSize {
+				t.Errorf("size = %d, want %d", size, tt.wantSize)
+			}
+			names := []string{}
+			for _, c := range applied {
+				names = append(names, c.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("applied = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
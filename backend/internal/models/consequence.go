@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ConsequenceTables are short random tables, one per roll outcome
+type ConsequenceTables struct {
+	Success []string `json:"success,omitempty"`
+	Neutral []string `json:"neutral,omitempty"`
+	Failure []string `json:"failure,omitempty"`
+}
+
+// ForOutcome returns the table for a roll outcome
+func (t ConsequenceTables) ForOutcome(outcome string) []string {
+	switch outcome {
+	case "success":
+		return t.Success
+	case "neutral":
+		return t.Neutral
+	case "failure":
+		return t.Failure
+	}
+	return nil
+}
+
+// Scan implements sql.Scanner for the JSONB consequence_tables column
+func (t *ConsequenceTables) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return fmt.Errorf("cannot scan %T into ConsequenceTables", src)
+	}
+}
+
+// Value implements driver.Valuer for the JSONB consequence_tables column
+func (t ConsequenceTables) Value() (driver.Value, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// TextForOutcome returns the challenge's consequence text for a roll outcome
+func (c Consequences) TextForOutcome(outcome string) *string {
+	switch outcome {
+	case "success":
+		return c.SuccessText
+	case "neutral":
+		return c.NeutralText
+	case "failure":
+		return c.FailureText
+	}
+	return nil
+}
+
+// RollConsequence is what a roll against a challenge resolved to: the text
+// for its outcome and the entry rolled on that outcome's table, if any
+type RollConsequence struct {
+	Outcome    string  `json:"outcome"`
+	Text       *string `json:"text,omitempty"`
+	TableRoll  *int    `json:"table_roll,omitempty"` // 1-based row of the table
+	TableSize  *int    `json:"table_size,omitempty"`
+	TableEntry *string `json:"table_entry,omitempty"`
+}
+
+// Scan implements sql.Scanner for the JSONB consequence column
+func (c *RollConsequence) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into RollConsequence", src)
+	}
+}
+
+// Value implements driver.Valuer for the JSONB consequence column
+func (c RollConsequence) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
@@ -5,6 +5,7 @@ import "time"
 type DicePool struct {
 	ID          int       `json:"id" db:"id"`
 	CharacterID int       `json:"character_id" db:"character_id"`
+	SessionID   *int      `json:"session_id" db:"session_id"`
 	RolledAt    time.Time `json:"rolled_at" db:"rolled_at"`
 }
 
@@ -18,34 +19,45 @@ type PoolDie struct {
 
 type DicePoolWithDice struct {
 	DicePool
-	Dice []PoolDie `json:"dice"`
+	Dice       []PoolDie       `json:"dice"`
+	Conditions []PoolCondition `json:"conditions"`
 }
 
 type CreateRollRequest struct {
-	CharacterID    int     `json:"character_id"`
-	PoolDiceID     int     `json:"pool_dice_id"`
-	D20Roll        int     `json:"d20_roll"`
-	ActionType     *string `json:"action_type"`
-	Notes          *string `json:"notes"`
-	ChallengeID    *int    `json:"challenge_id"`
-	SkillApplied   bool    `json:"skill_applied"`
-	OtherModifiers int     `json:"other_modifiers"`
+	CharacterID    int               `json:"character_id"`
+	PoolDiceID     int               `json:"pool_dice_id"`
+	D20Roll        int               `json:"d20_roll"`
+	ActionType     *string           `json:"action_type"`
+	Notes          *string           `json:"notes"`
+	ChallengeID    *int              `json:"challenge_id"`
+	SkillApplied   bool              `json:"skill_applied"` // Legacy: applies the character's first skill when trait_ids is empty
+	TraitIDs       []int             `json:"trait_ids"`
+	Items          []RollItemRequest `json:"items"`
+	OtherModifiers int               `json:"other_modifiers"`
 }
 
 type RollHistory struct {
-	ID             int       `json:"id" db:"id"`
-	CharacterID    int       `json:"character_id" db:"character_id"`
-	PoolDiceID     *int      `json:"pool_dice_id" db:"pool_dice_id"`
-	D20Roll        *int      `json:"d20_roll" db:"d20_roll"`
-	ActionType     *string   `json:"action_type" db:"action_type"`
-	Success        *bool     `json:"success" db:"success"` // Keep for backward compatibility
-	Outcome        string    `json:"outcome" db:"outcome"` // Add this
-	Notes          *string   `json:"notes" db:"notes"`
-	ChallengeID    *int      `json:"challenge_id" db:"challenge_id"`
-	SkillApplied   bool      `json:"skill_applied" db:"skill_applied"`
-	OtherModifiers int       `json:"other_modifiers" db:"other_modifiers"`
-	ModifiedD6     *int      `json:"modified_d6" db:"modified_d6"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	ID               int              `json:"id" db:"id"`
+	CharacterID      int              `json:"character_id" db:"character_id"`
+	PoolDiceID       *int             `json:"pool_dice_id" db:"pool_dice_id"`
+	D20Roll          *int             `json:"d20_roll" db:"d20_roll"`
+	ActionType       *string          `json:"action_type" db:"action_type"`
+	Success          *bool            `json:"success" db:"success"` // Keep for backward compatibility
+	Outcome          string           `json:"outcome" db:"outcome"` // Add this
+	Notes            *string          `json:"notes" db:"notes"`
+	ChallengeID      *int             `json:"challenge_id" db:"challenge_id"`
+	SkillApplied     bool             `json:"skill_applied" db:"skill_applied"`
+	OtherModifiers   int              `json:"other_modifiers" db:"other_modifiers"`
+	ModifiedD6       *int             `json:"modified_d6" db:"modified_d6"`
+	SessionID        *int             `json:"session_id" db:"session_id"`
+	SceneID          *int             `json:"scene_id" db:"scene_id"`
+	CampaignDay      *int             `json:"campaign_day" db:"campaign_day"`
+	CharacterVersion *int             `json:"character_version" db:"character_version"` // nil for rolls made before versioning
+	Consequence      *RollConsequence `json:"consequence" db:"consequence"`             // Set for rolls against challenges with consequence text
+	Traits           []RollTrait      `json:"traits" db:"-"`
+	Conditions       []RollCondition  `json:"conditions" db:"-"`
+	Items            []RollItem       `json:"items" db:"-"`
+	CreatedAt        time.Time        `json:"created_at" db:"created_at"`
 }
 
 type RollHistoryWithCharacter struct {
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type CampaignImage struct {
+	ID               int        `json:"id" db:"id"`
+	CampaignID       int        `json:"campaign_id" db:"campaign_id"`
+	URL              string     `json:"url" db:"url"`
+	ThumbnailURL     *string    `json:"thumbnail_url" db:"thumbnail_url"`
+	Description      *string    `json:"description" db:"description"`
+	SceneID          *int       `json:"scene_id" db:"scene_id"`
+	StorageKey       *string    `json:"-" db:"storage_key"`
+	ThumbnailKey     *string    `json:"-" db:"thumbnail_key"`
+	OriginalFilename *string    `json:"original_filename" db:"original_filename"`
+	ContentType      *string    `json:"content_type" db:"content_type"`
+	SizeBytes        *int64     `json:"size_bytes" db:"size_bytes"`
+	Width            *int       `json:"width" db:"width"`
+	Height           *int       `json:"height" db:"height"`
+	UploadedByUserID *int       `json:"uploaded_by_user_id" db:"uploaded_by_user_id"`
+	UploadedAt       time.Time  `json:"uploaded_at" db:"uploaded_at"`
+	ShownAt          *time.Time `json:"shown_at" db:"shown_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+type CharacterItem struct {
+	ID              int       `json:"id" db:"id"`
+	CharacterID     int       `json:"character_id" db:"character_id"`
+	Name            string    `json:"name" db:"name"`
+	Description     *string   `json:"description" db:"description"`
+	Quantity        int       `json:"quantity" db:"quantity"`
+	D6Modifier      int       `json:"d6_modifier" db:"d6_modifier"`
+	Consumable      bool      `json:"consumable" db:"consumable"`
+	GrantedByUserID *int      `json:"granted_by_user_id" db:"granted_by_user_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RollItem is an item as it was when declared on a roll
+type RollItem struct {
+	RollID     int    `json:"-" db:"roll_id"`
+	ItemID     *int   `json:"item_id" db:"item_id"` // nil once the item is gone
+	Name       string `json:"name" db:"name"`
+	D6Modifier int    `json:"d6_modifier" db:"d6_modifier"`
+	Consumed   bool   `json:"consumed" db:"consumed"`
+}
+
+// RollItemRequest declares an item used on a roll
+type RollItemRequest struct {
+	ItemID  int  `json:"item_id"`
+	Consume bool `json:"consume"` // Use up one of the item
+}
+
+type GrantItemRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Quantity    int     `json:"quantity"` // Defaults to 1
+	D6Modifier  int     `json:"d6_modifier"`
+	Consumable  bool    `json:"consumable"`
+}
+
+type TransferItemRequest struct {
+	ToCharacterID int `json:"to_character_id"`
+	Quantity      int `json:"quantity"` // Defaults to the whole stack
+}
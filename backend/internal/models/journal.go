@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	JournalVisibilityGM      = "gm"
+	JournalVisibilityMembers = "members"
+	JournalVisibilityPlayers = "players"
+)
+
+type JournalEntry struct {
+	ID               int           `json:"id" db:"id"`
+	CampaignID       int           `json:"campaign_id" db:"campaign_id"`
+	AuthorUserID     *int          `json:"author_user_id" db:"author_user_id"`
+	AuthorName       *string       `json:"author_name" db:"author_name"`
+	Title            string        `json:"title" db:"title"`
+	Body             string        `json:"body" db:"body"` // Sanitized markdown
+	CampaignDay      int           `json:"campaign_day" db:"campaign_day"`
+	Visibility       string        `json:"visibility" db:"visibility"`
+	RecipientUserIDs pq.Int64Array `json:"recipient_user_ids" db:"recipient_user_ids"`
+	Revision         int           `json:"revision" db:"revision"`
+	CreatedAt        time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+type JournalEntryRevision struct {
+	ID             int       `json:"id" db:"id"`
+	EntryID        int       `json:"entry_id" db:"entry_id"`
+	Revision       int       `json:"revision" db:"revision"`
+	Title          string    `json:"title" db:"title"`
+	Body           string    `json:"body" db:"body"`
+	CampaignDay    int       `json:"campaign_day" db:"campaign_day"`
+	Visibility     string    `json:"visibility" db:"visibility"`
+	EditedByUserID *int      `json:"edited_by_user_id" db:"edited_by_user_id"`
+	EditedByName   *string   `json:"edited_by_name" db:"edited_by_name"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateJournalEntryRequest struct {
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	CampaignDay      *int   `json:"campaign_day"` // Defaults to the campaign's current day
+	Visibility       string `json:"visibility"`
+	RecipientUserIDs []int  `json:"recipient_user_ids"` // Only used with "players" visibility
+}
+
+type UpdateJournalEntryRequest struct {
+	Title            *string `json:"title"`
+	Body             *string `json:"body"`
+	CampaignDay      *int    `json:"campaign_day"`
+	Visibility       *string `json:"visibility"`
+	RecipientUserIDs []int   `json:"recipient_user_ids"` // Replaces the list when provided
+}
@@ -0,0 +1,28 @@
+package models
+
+const (
+	CharacterKindPC  = "pc"
+	CharacterKindNPC = "npc"
+)
+
+// NPCStatBlock is the quick set of numbers a GM needs to run an NPC
+type NPCStatBlock struct {
+	Name             string  `json:"name"`
+	Description      *string `json:"description"`
+	MaxDailyDice     *int    `json:"max_daily_dice"` // Defaults to the campaign's default
+	SkillName        *string `json:"skill_name"`
+	SkillModifier    int     `json:"skill_modifier"`
+	WeaknessName     *string `json:"weakness_name"`
+	WeaknessModifier int     `json:"weakness_modifier"`
+	StatBlock        *string `json:"stat_block"`
+	IsRevealed       bool    `json:"is_revealed"`
+}
+
+type CreateNPCsRequest struct {
+	NPCs []NPCStatBlock `json:"npcs"`
+}
+
+type UpdateNPCRequest struct {
+	IsRevealed *bool   `json:"is_revealed"`
+	StatBlock  *string `json:"stat_block"`
+}
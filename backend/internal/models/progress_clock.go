@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Results of a progress clock once one of its targets is reached
+const (
+	ProgressClockSucceeded = "succeeded"
+	ProgressClockFailed    = "failed"
+)
+
+// OutcomeWeight is how far one roll outcome moves each side of a progress clock
+type OutcomeWeight struct {
+	Success float64 `json:"success"`
+	Failure float64 `json:"failure"`
+}
+
+type ProgressClockWeights struct {
+	Success OutcomeWeight `json:"success"`
+	Neutral OutcomeWeight `json:"neutral"`
+	Failure OutcomeWeight `json:"failure"`
+}
+
+// DefaultProgressClockWeights counts a success or failure as one tick and ignores neutrals
+var DefaultProgressClockWeights = ProgressClockWeights{
+	Success: OutcomeWeight{Success: 1},
+	Failure: OutcomeWeight{Failure: 1},
+}
+
+// ForOutcome returns the weight of a roll outcome
+func (w ProgressClockWeights) ForOutcome(outcome string) OutcomeWeight {
+	switch outcome {
+	case "success":
+		return w.Success
+	case "neutral":
+		return w.Neutral
+	case "failure":
+		return w.Failure
+	}
+	return OutcomeWeight{}
+}
+
+// ProgressClock tracks a challenge that needs several rolls to resolve,
+// e.g. 4 successes before 3 failures. Either target may be left out.
+type ProgressClock struct {
+	SuccessTarget *int                 `json:"success_target"`
+	FailureTarget *int                 `json:"failure_target"`
+	Weights       ProgressClockWeights `json:"weights"`
+	Successes     float64              `json:"successes"`
+	Failures      float64              `json:"failures"`
+	Result        *string              `json:"result"` // succeeded or failed once a target is reached
+}
+
+// Scan implements sql.Scanner for the JSONB progress_clock column
+func (c *ProgressClock) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into ProgressClock", src)
+	}
+}
+
+// Value implements driver.Valuer for the JSONB progress_clock column
+func (c ProgressClock) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// ProgressClockRequest sets up a challenge's progress clock; omitted weights use the defaults
+type ProgressClockRequest struct {
+	SuccessTarget *int                  `json:"success_target"`
+	FailureTarget *int                  `json:"failure_target"`
+	Weights       *ProgressClockWeights `json:"weights"`
+}
+
+// Tally recomputes the clock's progress from a count of roll outcomes and
+// sets Result once a target is reached. Success wins if both are reached.
+func (c *ProgressClock) Tally(outcomes map[string]int) {
+	c.Successes, c.Failures = 0, 0
+	for outcome, n := range outcomes {
+		w := c.Weights.ForOutcome(outcome)
+		c.Successes += w.Success * float64(n)
+		c.Failures += w.Failure * float64(n)
+	}
+
+	c.Result = nil
+	switch {
+	case c.SuccessTarget != nil && c.Successes >= float64(*c.SuccessTarget):
+		result := ProgressClockSucceeded
+		c.Result = &result
+	case c.FailureTarget != nil && c.Failures >= float64(*c.FailureTarget):
+		result := ProgressClockFailed
+		c.Result = &result
+	}
+}
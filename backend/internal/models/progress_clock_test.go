@@ -0,0 +1,108 @@
+package models
+
+import "testing"
+
+func TestProgressClockTally(t *testing.T) {
+	tests := []struct {
+		name          string
+		clock         ProgressClock
+		outcomes      map[string]int
+		wantSuccesses float64
+		wantFailures  float64
+		wantResult    string // empty for no result
+	}{
+		{
+			name:          "default weights ignore neutrals",
+			clock:         ProgressClock{SuccessTarget: intPtr(4), FailureTarget: intPtr(3), Weights: DefaultProgressClockWeights},
+			outcomes:      map[string]int{"success": 2, "neutral": 5, "failure": 1},
+			wantSuccesses: 2,
+			wantFailures:  1,
+		},
+		{
+			name:          "success target reached",
+			clock:         ProgressClock{SuccessTarget: intPtr(3), FailureTarget: intPtr(3), Weights: DefaultProgressClockWeights},
+			outcomes:      map[string]int{"success": 3, "failure": 2},
+			wantSuccesses: 3,
+			wantFailures:  2,
+			wantResult:    ProgressClockSucceeded,
+		},
+		{
+			name:          "failure target reached",
+			clock:         ProgressClock{SuccessTarget: intPtr(3), FailureTarget: intPtr(2), Weights: DefaultProgressClockWeights},
+			outcomes:      map[string]int{"success": 1, "failure": 2},
+			wantSuccesses: 1,
+			wantFailures:  2,
+			wantResult:    ProgressClockFailed,
+		},
+		{
+			name:          "success wins when both targets are reached",
+			clock:         ProgressClock{SuccessTarget: intPtr(2), FailureTarget: intPtr(2), Weights: DefaultProgressClockWeights},
+			outcomes:      map[string]int{"success": 2, "failure": 2},
+			wantSuccesses: 2,
+			wantFailures:  2,
+			wantResult:    ProgressClockSucceeded,
+		},
+		{
+			name: "fractional weights fall short of the target",
+			clock: ProgressClock{SuccessTarget: intPtr(2), Weights: ProgressClockWeights{
+				Success: OutcomeWeight{Success: 1},
+				Neutral: OutcomeWeight{Success: 0.5, Failure: 0.5},
+			}},
+			outcomes:      map[string]int{"success": 1, "neutral": 1},
+			wantSuccesses: 1.5,
+			wantFailures:  0.5,
+		},
+		{
+			name: "fractional weights add up to the target",
+			clock: ProgressClock{SuccessTarget: intPtr(2), Weights: ProgressClockWeights{
+				Success: OutcomeWeight{Success: 1},
+				Neutral: OutcomeWeight{Success: 0.5},
+			}},
+			outcomes:      map[string]int{"success": 1, "neutral": 2},
+			wantSuccesses: 2,
+			wantResult:    ProgressClockSucceeded,
+		},
+		{
+			name:          "missing target never resolves",
+			clock:         ProgressClock{FailureTarget: intPtr(5), Weights: DefaultProgressClockWeights},
+			outcomes:      map[string]int{"success": 10},
+			wantSuccesses: 10,
+		},
+		{
+			name:          "unknown outcomes count for nothing",
+			clock:         ProgressClock{SuccessTarget: intPtr(1), Weights: DefaultProgressClockWeights},
+			outcomes:      map[string]int{"critical": 3},
+			wantSuccesses: 0,
+		},
+		{
+			name: "retally clears an earlier result",
+			clock: ProgressClock{
+				SuccessTarget: intPtr(1),
+				Weights:       DefaultProgressClockWeights,
+				Successes:     1,
+				Result:        func() *string { s := ProgressClockSucceeded; return &s }(),
+			},
+			outcomes:      map[string]int{},
+			wantSuccesses: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.clock
+			c.Tally(tt.outcomes)
+
+			if c.Successes != tt.wantSuccesses || c.Failures != tt.wantFailures {
+				t.Errorf("got %v successes and %v failures, want %v and %v",
+					c.Successes, c.Failures, tt.wantSuccesses, tt.wantFailures)
+			}
+			got := ""
+			if c.Result != nil {
+				got = *c.Result
+			}
+			if got != tt.wantResult {
+				t.Errorf("got result %q, want %q", got, tt.wantResult)
+			}
+		})
+	}
+}
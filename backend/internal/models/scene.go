@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Scene is an encounter within a campaign that challenges, images and rolls can belong to
+type Scene struct {
+	ID              int       `json:"id" db:"id"`
+	CampaignID      int       `json:"campaign_id" db:"campaign_id"`
+	Name            string    `json:"name" db:"name"`
+	Description     *string   `json:"description" db:"description"`
+	Position        int       `json:"position" db:"position"`
+	IsActive        bool      `json:"is_active" db:"is_active"` // At most one scene per campaign
+	CreatedByUserID *int      `json:"created_by_user_id" db:"created_by_user_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SceneWithStats includes activity counts for scene browsing
+type SceneWithStats struct {
+	Scene
+	ChallengeCount int `json:"challenge_count" db:"challenge_count"`
+	ImageCount     int `json:"image_count" db:"image_count"`
+	RollCount      int `json:"roll_count" db:"roll_count"`
+}
+
+// SceneSummary is a scene with everything linked to it and the outcomes of its rolls
+type SceneSummary struct {
+	Scene
+	Outcomes   ChallengeStats             `json:"outcomes"`
+	Challenges []ChallengeWithStats       `json:"challenges"`
+	Images     []CampaignImage            `json:"images"`
+	Rolls      []RollHistoryWithCharacter `json:"rolls"`
+}
+
+type CreateSceneRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Activate    bool    `json:"activate"` // Switch every client to the new scene straight away
+}
+
+type UpdateSceneRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// ReorderScenesRequest lists every scene in a campaign in its new order
+type ReorderScenesRequest struct {
+	SceneIDs []int `json:"scene_ids"`
+}
+
+// SetSceneRequest moves an image into a scene, or out of any scene when SceneID is nil
+type SetSceneRequest struct {
+	SceneID *int `json:"scene_id"`
+}
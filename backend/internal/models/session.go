@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+type GameSession struct {
+	ID              int        `json:"id" db:"id"`
+	CampaignID      int        `json:"campaign_id" db:"campaign_id"`
+	Title           *string    `json:"title" db:"title"`
+	Notes           *string    `json:"notes" db:"notes"`
+	StartedByUserID *int       `json:"started_by_user_id" db:"started_by_user_id"`
+	StartDay        int        `json:"start_day" db:"start_day"`
+	EndDay          *int       `json:"end_day" db:"end_day"`
+	StartedAt       time.Time  `json:"started_at" db:"started_at"`
+	EndedAt         *time.Time `json:"ended_at" db:"ended_at"`
+}
+
+// GameSessionWithStats includes activity counts for session browsing
+type GameSessionWithStats struct {
+	GameSession
+	RollCount      int `json:"roll_count" db:"roll_count"`
+	PoolCount      int `json:"pool_count" db:"pool_count"`
+	ChallengeCount int `json:"challenge_count" db:"challenge_count"`
+	AttendeeCount  int `json:"attendee_count" db:"attendee_count"`
+}
+
+type SessionAttendee struct {
+	ID            int       `json:"id" db:"id"`
+	SessionID     int       `json:"session_id" db:"session_id"`
+	UserID        int       `json:"user_id" db:"user_id"`
+	CharacterID   *int      `json:"character_id" db:"character_id"`
+	JoinedAt      time.Time `json:"joined_at" db:"joined_at"`
+	Username      string    `json:"username" db:"username"`
+	CharacterName *string   `json:"character_name" db:"character_name"`
+}
+
+// GameSessionDetail is a session with everything linked to it
+type GameSessionDetail struct {
+	GameSession
+	Attendees  []SessionAttendee          `json:"attendees"`
+	Rolls      []RollHistoryWithCharacter `json:"rolls"`
+	Challenges []Challenge                `json:"challenges"`
+}
+
+type StartSessionRequest struct {
+	Title *string `json:"title"`
+	Notes *string `json:"notes"`
+}
+
+type UpdateSessionRequest struct {
+	Title *string `json:"title"`
+	Notes *string `json:"notes"`
+}
+
+type AddAttendeeRequest struct {
+	UserID      int  `json:"user_id"`
+	CharacterID *int `json:"character_id"`
+}
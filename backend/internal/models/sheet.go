@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+const (
+	// SheetFormat identifies a character sheet file
+	SheetFormat = "sleeper-character"
+
+	// SheetVersion is bumped whenever the sheet layout changes incompatibly
+	SheetVersion = 1
+)
+
+// CharacterSheet is a portable description of one character, for moving
+// characters between campaigns or generating them with other tools.
+// The layout is described by the JSON Schema served at /api/character-sheets/schema.
+type CharacterSheet struct {
+	Format       string           `json:"format"`
+	Version      int              `json:"version"`
+	ExportedAt   *time.Time       `json:"exported_at,omitempty"`
+	Name         string           `json:"name"`
+	Description  *string          `json:"description,omitempty"`
+	Notes        *string          `json:"notes,omitempty"`
+	Kind         string           `json:"kind,omitempty"` // pc (default) or npc
+	StatBlock    *string          `json:"stat_block,omitempty"`
+	MaxDailyDice int              `json:"max_daily_dice"`
+	Traits       []SheetTrait     `json:"traits"`
+	Conditions   []SheetCondition `json:"conditions"`
+	Items        []SheetItem      `json:"items"`
+}
+
+type SheetTrait struct {
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	Modifier    int     `json:"modifier"`
+	Description *string `json:"description,omitempty"`
+	UsesPerDay  *int    `json:"uses_per_day,omitempty"`
+}
+
+// SheetCondition is an active condition. Durations count from the day the
+// sheet is imported rather than carrying over the old campaign's calendar.
+type SheetCondition struct {
+	Name           string  `json:"name"`
+	Description    *string `json:"description,omitempty"`
+	D6Modifier     int     `json:"d6_modifier"`
+	DiceModifier   int     `json:"dice_modifier"`
+	DaysRemaining  *int    `json:"days_remaining,omitempty"` // Counts the current day
+	RollsRemaining *int    `json:"rolls_remaining,omitempty"`
+}
+
+type SheetItem struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	Quantity    int     `json:"quantity"`
+	D6Modifier  int     `json:"d6_modifier"`
+	Consumable  bool    `json:"consumable"`
+}
+
+// SheetError points at the field of a sheet that failed validation,
+// e.g. "traits[1].modifier" or "characters[2].sheet.name" in a party import
+type SheetError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type SheetValidationResponse struct {
+	Errors []SheetError `json:"errors"`
+}
+
+// PartyImportEntry is one character in a bulk import, optionally assigned to a player
+type PartyImportEntry struct {
+	UserID *int           `json:"user_id"`
+	Sheet  CharacterSheet `json:"sheet"`
+}
+
+type PartyImportRequest struct {
+	Characters []PartyImportEntry `json:"characters"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+const (
+	TraitKindSkill    = "skill"
+	TraitKindWeakness = "weakness"
+)
+
+type CharacterTrait struct {
+	ID          int       `json:"id" db:"id"`
+	CharacterID int       `json:"character_id" db:"character_id"`
+	Name        string    `json:"name" db:"name"`
+	Kind        string    `json:"kind" db:"kind"`
+	Modifier    int       `json:"modifier" db:"modifier"`
+	Description *string   `json:"description" db:"description"`
+	UsesPerDay  *int      `json:"uses_per_day" db:"uses_per_day"` // nil means unlimited
+	UsesToday   int       `json:"uses_today" db:"uses_today"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// RollTrait is a trait as it was when applied to a roll
+type RollTrait struct {
+	RollID   int    `json:"-" db:"roll_id"`
+	TraitID  *int   `json:"trait_id" db:"trait_id"` // nil once the trait is deleted
+	Name     string `json:"name" db:"name"`
+	Kind     string `json:"kind" db:"kind"`
+	Modifier int    `json:"modifier" db:"modifier"`
+}
+
+type CreateTraitRequest struct {
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"`
+	Modifier    int     `json:"modifier"`
+	Description *string `json:"description"`
+	UsesPerDay  *int    `json:"uses_per_day"`
+}
+
+type UpdateTraitRequest struct {
+	Name        *string `json:"name"`
+	Kind        *string `json:"kind"`
+	Modifier    *int    `json:"modifier"`
+	Description *string `json:"description"`
+	UsesPerDay  *int    `json:"uses_per_day"`
+	Unlimited   bool    `json:"unlimited"` // Clears uses_per_day
+}
@@ -14,6 +14,7 @@ type User struct {
 	Email        string    `json:"email" db:"email"`
 	PasswordHash string    `json:"-" db:"password_hash"` // Never send password in JSON
 	SystemRole   string    `json:"system_role" db:"system_role"`
+	TokenVersion int       `json:"-" db:"token_version"` // Bumped to invalidate outstanding access tokens
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -54,6 +55,25 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token          string    `json:"token"` // Short-lived access token
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	RefreshToken   string    `json:"refresh_token"` // Trade for a new pair at /api/auth/refresh; single use
+	User           User      `json:"user"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthSession is a signed-in device. Its refresh token is never sent back.
+type AuthSession struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	UserAgent  *string    `json:"user_agent" db:"user_agent"`
+	IPAddress  *string    `json:"ip_address" db:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	Current    bool       `json:"current" db:"-"` // The session making the request
 }
@@ -0,0 +1,259 @@
+// Package sheet converts single characters to and from the portable
+// character sheet format described by schema.json.
+package sheet
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// Schema is the JSON Schema for models.CharacterSheet
+//
+//go:embed schema.json
+var Schema []byte
+
+// maxNameLength matches the VARCHAR(100) name columns
+const maxNameLength = 100
+
+// Export builds a sheet for a character. Only active conditions are included.
+func Export(q sqlx.Queryer, characterID int) (*models.CharacterSheet, error) {
+	var c struct {
+		Name         string  `db:"name"`
+		Description  *string `db:"description"`
+		Notes        *string `db:"notes"`
+		Kind         string  `db:"kind"`
+		StatBlock    *string `db:"stat_block"`
+		MaxDailyDice int     `db:"max_daily_dice"`
+		CurrentDay   int     `db:"current_day"`
+	}
+	err := sqlx.Get(q, &c, `
+		SELECT ch.name, ch.description, ch.notes, ch.kind, ch.stat_block, ch.max_daily_dice, ca.current_day
+		FROM characters ch
+		JOIN campaigns ca ON ch.campaign_id = ca.id
+		WHERE ch.id = $1
+	`, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching character: %w", err)
+	}
+
+	now := time.Now().UTC()
+	s := &models.CharacterSheet{
+		Format:       models.SheetFormat,
+		Version:      models.SheetVersion,
+		ExportedAt:   &now,
+		Name:         c.Name,
+		Description:  c.Description,
+		Notes:        c.Notes,
+		Kind:         c.Kind,
+		StatBlock:    c.StatBlock,
+		MaxDailyDice: c.MaxDailyDice,
+		Traits:       []models.SheetTrait{},
+		Conditions:   []models.SheetCondition{},
+		Items:        []models.SheetItem{},
+	}
+
+	err = sqlx.Select(q, &s.Traits, `
+		SELECT name, kind, modifier, description, uses_per_day
+		FROM character_traits
+		WHERE character_id = $1
+		ORDER BY kind ASC, id ASC
+	`, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching traits: %w", err)
+	}
+
+	err = sqlx.Select(q, &s.Conditions, `
+		SELECT name, description, d6_modifier, dice_modifier, rolls_remaining,
+		       expires_after_day - $2 + 1 as days_remaining
+		FROM character_conditions
+		WHERE character_id = $1
+		  AND ended_at IS NULL
+		  AND (expires_after_day IS NULL OR expires_after_day >= $2)
+		ORDER BY id ASC
+	`, characterID, c.CurrentDay)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching conditions: %w", err)
+	}
+
+	err = sqlx.Select(q, &s.Items, `
+		SELECT name, description, quantity, d6_modifier, consumable
+		FROM character_items
+		WHERE character_id = $1
+		ORDER BY name ASC, id ASC
+	`, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching items: %w", err)
+	}
+
+	return s, nil
+}
+
+// Validate checks a sheet against the schema's rules. Each error names the
+// offending field, prefixed with path (e.g. "characters[0].sheet").
+func Validate(s *models.CharacterSheet, path string) []models.SheetError {
+	errs := []models.SheetError{}
+	fail := func(field, format string, args ...any) {
+		if path != "" {
+			field = path + "." + field
+		}
+		errs = append(errs, models.SheetError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	checkName := func(field, name string) {
+		switch {
+		case strings.TrimSpace(name) == "":
+			fail(field, "is required")
+		case len(name) > maxNameLength:
+			fail(field, "must be at most %d characters", maxNameLength)
+		}
+	}
+
+	if s.Format != models.SheetFormat {
+		fail("format", "must be %q", models.SheetFormat)
+	}
+	if s.Version < 1 || s.Version > models.SheetVersion {
+		fail("version", "version %d is not supported (max %d)", s.Version, models.SheetVersion)
+	}
+	checkName("name", s.Name)
+	if s.Kind != "" && s.Kind != models.CharacterKindPC && s.Kind != models.CharacterKindNPC {
+		fail("kind", "must be 'pc' or 'npc'")
+	}
+	if s.MaxDailyDice < 1 || s.MaxDailyDice > 20 {
+		fail("max_daily_dice", "must be between 1 and 20")
+	}
+
+	for i, t := range s.Traits {
+		field := fmt.Sprintf("traits[%d]", i)
+		checkName(field+".name", t.Name)
+		if t.Kind != models.TraitKindSkill && t.Kind != models.TraitKindWeakness {
+			fail(field+".kind", "must be 'skill' or 'weakness'")
+		}
+		if t.Modifier < -5 || t.Modifier > 5 {
+			fail(field+".modifier", "must be between -5 and 5")
+		}
+		if t.UsesPerDay != nil && *t.UsesPerDay < 1 {
+			fail(field+".uses_per_day", "must be at least 1")
+		}
+	}
+
+	for i, c := range s.Conditions {
+		field := fmt.Sprintf("conditions[%d]", i)
+		checkName(field+".name", c.Name)
+		if c.D6Modifier < -5 || c.D6Modifier > 5 {
+			fail(field+".d6_modifier", "must be between -5 and 5")
+		}
+		if c.DiceModifier < -20 || c.DiceModifier > 20 {
+			fail(field+".dice_modifier", "must be between -20 and 20")
+		}
+		if c.DaysRemaining != nil && *c.DaysRemaining < 1 {
+			fail(field+".days_remaining", "must be at least 1")
+		}
+		if c.RollsRemaining != nil && *c.RollsRemaining < 1 {
+			fail(field+".rolls_remaining", "must be at least 1")
+		}
+	}
+
+	for i, item := range s.Items {
+		field := fmt.Sprintf("items[%d]", i)
+		checkName(field+".name", item.Name)
+		if item.Quantity < 1 {
+			fail(field+".quantity", "must be at least 1")
+		}
+		if item.D6Modifier < -5 || item.D6Modifier > 5 {
+			fail(field+".d6_modifier", "must be between -5 and 5")
+		}
+	}
+
+	return errs
+}
+
+// Create adds a validated sheet to a campaign as a new character and returns its ID.
+// The first skill and weakness traits also fill the older single-skill fields.
+func Create(tx *sqlx.Tx, campaignID int, ownerID *int, actorID int, s *models.CharacterSheet) (int, error) {
+	kind := s.Kind
+	if kind == "" {
+		kind = models.CharacterKindPC
+	}
+
+	var skill, weakness *models.SheetTrait
+	for i := range s.Traits {
+		t := &s.Traits[i]
+		if t.Kind == models.TraitKindSkill && skill == nil {
+			skill = t
+		}
+		if t.Kind == models.TraitKindWeakness && weakness == nil {
+			weakness = t
+		}
+	}
+	var skillName, weaknessName *string
+	var skillModifier, weaknessModifier int
+	if skill != nil {
+		skillName, skillModifier = &skill.Name, skill.Modifier
+	}
+	if weakness != nil {
+		weaknessName, weaknessModifier = &weakness.Name, weakness.Modifier
+	}
+
+	var characterID int
+	err := tx.Get(&characterID, `
+		INSERT INTO characters (
+			campaign_id, user_id, name, description, notes, kind, stat_block, max_daily_dice,
+			skill_name, skill_modifier, weakness_name, weakness_modifier
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`, campaignID, ownerID, strings.TrimSpace(s.Name), s.Description, s.Notes, kind, s.StatBlock, s.MaxDailyDice,
+		skillName, skillModifier, weaknessName, weaknessModifier)
+	if err != nil {
+		return 0, fmt.Errorf("error creating character: %w", err)
+	}
+
+	for _, t := range s.Traits {
+		_, err := tx.Exec(`
+			INSERT INTO character_traits (character_id, name, kind, modifier, description, uses_per_day)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, characterID, strings.TrimSpace(t.Name), t.Kind, t.Modifier, t.Description, t.UsesPerDay)
+		if err != nil {
+			return 0, fmt.Errorf("error creating trait %q: %w", t.Name, err)
+		}
+	}
+
+	var currentDay int
+	if err := tx.Get(&currentDay, "SELECT current_day FROM campaigns WHERE id = $1", campaignID); err != nil {
+		return 0, fmt.Errorf("error fetching campaign day: %w", err)
+	}
+	for _, c := range s.Conditions {
+		var expiresAfterDay *int
+		if c.DaysRemaining != nil {
+			day := currentDay + *c.DaysRemaining - 1
+			expiresAfterDay = &day
+		}
+		_, err := tx.Exec(`
+			INSERT INTO character_conditions (
+				character_id, name, description, d6_modifier, dice_modifier,
+				started_on_day, expires_after_day, rolls_remaining, created_by_user_id
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, characterID, strings.TrimSpace(c.Name), c.Description, c.D6Modifier, c.DiceModifier,
+			currentDay, expiresAfterDay, c.RollsRemaining, actorID)
+		if err != nil {
+			return 0, fmt.Errorf("error creating condition %q: %w", c.Name, err)
+		}
+	}
+
+	for _, item := range s.Items {
+		_, err := tx.Exec(`
+			INSERT INTO character_items (character_id, name, description, quantity, d6_modifier, consumable, granted_by_user_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, characterID, strings.TrimSpace(item.Name), item.Description, item.Quantity, item.D6Modifier, item.Consumable, actorID)
+		if err != nil {
+			return 0, fmt.Errorf("error creating item %q: %w", item.Name, err)
+		}
+	}
+
+	return characterID, nil
+}
@@ -0,0 +1,104 @@
+package sheet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SamPCunningham/sleeper-system/internal/models"
+)
+
+func validSheet() models.CharacterSheet {
+	return models.CharacterSheet{
+		Format:       models.SheetFormat,
+		Version:      models.SheetVersion,
+		Name:         "Mara",
+		MaxDailyDice: 3,
+		Traits: []models.SheetTrait{
+			{Name: "Sailing", Kind: models.TraitKindSkill, Modifier: 2},
+		},
+		Conditions: []models.SheetCondition{
+			{Name: "Bruised", D6Modifier: -1},
+		},
+		Items: []models.SheetItem{
+			{Name: "Rope", Quantity: 1},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	zero := 0
+
+	tests := []struct {
+		name   string
+		path   string
+		change func(s *models.CharacterSheet)
+		want   []string // fields with errors, in order
+	}{
+		{
+			name:   "valid sheet",
+			change: func(s *models.CharacterSheet) {},
+			want:   []string{},
+		},
+		{
+			name: "header fields",
+			change: func(s *models.CharacterSheet) {
+				s.Format = "something-else"
+				s.Version = models.SheetVersion + 1
+				s.Kind = "monster"
+			},
+			want: []string{"format", "version", "kind"},
+		},
+		{
+			name:   "blank name",
+			change: func(s *models.CharacterSheet) { s.Name = "   " },
+			want:   []string{"name"},
+		},
+		{
+			name:   "long name",
+			change: func(s *models.CharacterSheet) { s.Name = strings.Repeat("a", maxNameLength+1) },
+			want:   []string{"name"},
+		},
+		{
+			name:   "daily dice out of range",
+			change: func(s *models.CharacterSheet) { s.MaxDailyDice = 0 },
+			want:   []string{"max_daily_dice"},
+		},
+		{
+			name: "nested trait fields",
+			change: func(s *models.CharacterSheet) {
+				s.Traits = append(s.Traits, models.SheetTrait{Name: "", Kind: "talent", Modifier: 6, UsesPerDay: &zero})
+			},
+			want: []string{"traits[1].name", "traits[1].kind", "traits[1].modifier", "traits[1].uses_per_day"},
+		},
+		{
+			name: "nested condition and item fields",
+			change: func(s *models.CharacterSheet) {
+				s.Conditions[0].RollsRemaining = &zero
+				s.Items[0].Quantity = 0
+			},
+			want: []string{"conditions[0].rolls_remaining", "items[0].quantity"},
+		},
+		{
+			name:   "path prefixes every field",
+			path:   "characters[2].sheet",
+			change: func(s *models.CharacterSheet) { s.Name = ""; s.Traits[0].Modifier = -6 },
+			want:   []string{"characters[2].sheet.name", "characters[2].sheet.traits[0].modifier"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSheet()
+			tt.change(&s)
+
+			got := []string{}
+			for _, e := range Validate(&s, tt.path) {
+				got = append(got, e.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got errors on %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a key does not exist in the store
+var ErrNotFound = errors.New("blob not found")
+
+// SignedURLTTL is how long a signed URL keeps working, long enough to outlast a play session
+const SignedURLTTL = 6 * time.Hour
+
+// BlobStore is the interface for storing uploaded files.
+// Keys are slash-separated relative paths chosen by the caller.
+type BlobStore interface {
+	// Put writes the contents of r under key, replacing any existing blob
+	Put(key string, r io.Reader, contentType string) error
+
+	// Open returns a reader for the blob stored under key
+	Open(key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under key
+	Delete(key string) error
+
+	// URL returns the unsigned location of the blob, which is not fetchable on its own
+	URL(key string) string
+
+	// SignedURL returns a URL that fetches the blob until SignedURLTTL passes
+	SignedURL(key string) string
+}
+
+// LocalStore keeps blobs on the local filesystem
+type LocalStore struct {
+	// Directory all blobs are stored under
+	baseDir string
+
+	// Public URL prefix the directory is served from
+	baseURL string
+
+	// Key signed URLs are signed with
+	secret []byte
+}
+
+// NewLocalStore creates a filesystem store rooted at baseDir, creating it if needed
+func NewLocalStore(baseDir, baseURL string, secret []byte) (*LocalStore, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating upload directory: %w", err)
+	}
+
+	return &LocalStore{
+		baseDir: baseDir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+// path resolves a key to a file path, refusing anything that escapes baseDir
+func (s *LocalStore) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if cleaned == "." || filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	return filepath.Join(s.baseDir, cleaned), nil
+}
+
+func (s *LocalStore) Put(key string, r io.Reader, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating blob directory: %w", err)
+	}
+
+	// Write to a temp file first so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error creating temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing blob: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving blob: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStore) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *LocalStore) SignedURL(key string) string {
+	expires := time.Now().Add(SignedURLTTL).Unix()
+	return fmt.Sprintf("%s?expires=%d&sig=%s", s.URL(key), expires, s.sign(key, expires))
+}
+
+// sign returns the signature that lets a URL fetch key until expires
+func (s *LocalStore) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify reports whether sig is a live signature for key
+func (s *LocalStore) verify(key, expiresParam, sig string) bool {
+	expires, err := strconv.ParseInt(expiresParam, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(s.sign(key, expires)))
+}
+
+// Handler serves stored blobs over HTTP from under the given route prefix.
+// Only requests carrying a live signature from SignedURL are served.
+func (s *LocalStore) Handler(prefix string) http.Handler {
+	files := http.StripPrefix(prefix, http.FileServer(http.Dir(s.baseDir)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Don't expose directory listings, keys should only be known via the API
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, prefix)
+		query := r.URL.Query()
+		if !s.verify(key, query.Get("expires"), query.Get("sig")) {
+			http.Error(w, "Invalid or expired link", http.StatusForbidden)
+			return
+		}
+		// Handouts are per-campaign, keep them out of shared caches
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerRequiresSignature(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/uploads", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "campaigns/1/map.png"
+	if err := store.Put(key, strings.NewReader("png"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := url.Parse(store.SignedURL(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := signed.Query().Get("sig")
+	expires := signed.Query().Get("expires")
+	past := time.Now().Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"signed", signed.RequestURI(), http.StatusOK},
+		{"unsigned", "/uploads/" + key, http.StatusForbidden},
+		{"other key", "/uploads/campaigns/2/map.png?expires=" + expires + "&sig=" + sig, http.StatusForbidden},
+		{"extended expiry", fmt.Sprintf("/uploads/%s?expires=%s0&sig=%s", key, expires, sig), http.StatusForbidden},
+		{"expired", fmt.Sprintf("/uploads/%s?expires=%d&sig=%s", key, past, store.sign(key, past)), http.StatusForbidden},
+		{"directory", "/uploads/campaigns/1/", http.StatusNotFound},
+	}
+
+	handler := store.Handler("/uploads/")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.target, rec.Code, tt.want)
+			}
+		})
+	}
+}
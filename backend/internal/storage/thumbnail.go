@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	// Register decoders for the formats we can thumbnail
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// ThumbnailContentTypes are the sniffed content types Thumbnail can decode
+var ThumbnailContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// MaxImagePixels caps the width × height of images we will decode. A small
+// compressed file can claim enormous dimensions, and decoding allocates
+// memory for every pixel up front.
+const MaxImagePixels = 40_000_000
+
+// ImageSize returns the pixel dimensions of an encoded image without decoding it fully
+func ImageSize(data []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading image header: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// Thumbnail decodes an image and returns a PNG scaled down to fit within maxDim.
+// Images already smaller than maxDim are re-encoded at their original size.
+func Thumbnail(data []byte, maxDim int) ([]byte, error) {
+	w, h, err := ImageSize(data)
+	if err != nil {
+		return nil, err
+	}
+	if int64(w)*int64(h) > MaxImagePixels {
+		return nil, fmt.Errorf("image is %dx%d, over the %d pixel limit", w, h, MaxImagePixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	dstW, dstH := srcW, srcH
+	if srcW > maxDim || srcH > maxDim {
+		if srcW >= srcH {
+			dstW = maxDim
+			dstH = max(1, srcH*maxDim/srcW)
+		} else {
+			dstH = maxDim
+			dstW = max(1, srcW*maxDim/srcH)
+		}
+	}
+
+	// Box filter: each destination pixel is the average of the source pixels it covers
+	dst := image.NewRGBA64(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		sy0 := bounds.Min.Y + y*srcH/dstH
+		sy1 := max(sy0+1, bounds.Min.Y+(y+1)*srcH/dstH)
+		for x := 0; x < dstW; x++ {
+			sx0 := bounds.Min.X + x*srcW/dstW
+			sx1 := max(sx0+1, bounds.Min.X+(x+1)*srcW/dstW)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					b += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("error encoding thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
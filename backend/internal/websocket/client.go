@@ -22,9 +22,13 @@ const (
 	maxMessageSize = 512
 )
 
+// accessTokenProtocol is the subprotocol a browser offers ahead of its access token
+const accessTokenProtocol = "access_token"
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    []string{accessTokenProtocol},
 	CheckOrigin: func(r *http.Request) bool {
 		// In production, you'd want to check the origin properly
 		// For now, allow all origins during development
@@ -47,6 +51,11 @@ type Client struct {
 
 	// The user ID of the connected client
 	UserID int
+
+	// authorized reports whether the login session the client connected with
+	// is still valid. It is checked on every ping, so a logout closes the
+	// connection within pingPeriod.
+	authorized func() bool
 }
 
 // NewClient creates a new client and starts its read/write pumps
@@ -124,6 +133,11 @@ func (c *Client) writePump() {
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if c.authorized != nil && !c.authorized() {
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session ended"))
+				return
+			}
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -4,18 +4,25 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/SamPCunningham/sleeper-system/internal/database"
+	"github.com/SamPCunningham/sleeper-system/internal/middleware"
+	"github.com/SamPCunningham/sleeper-system/internal/models"
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
 )
 
 // Handler handles WebSocket connection upgrades
 type Handler struct {
-	hub *Hub
+	hub       *Hub
+	db        *database.Database
+	authCache *middleware.AuthCache
 }
 
 // NewHandler creates a new WebSocket handler
-func NewHandler(hub *Hub) *Handler {
-	return &Handler{hub: hub}
+func NewHandler(hub *Hub, db *database.Database, authCache *middleware.AuthCache) *Handler {
+	return &Handler{hub: hub, db: db, authCache: authCache}
 }
 
 // ServeWS handles WebSocket requests from clients
@@ -28,12 +35,41 @@ func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user ID from query param (passed from frontend after auth)
-	// In a production app, you'd validate a token here
-	userIDStr := r.URL.Query().Get("user_id")
-	userID, err := strconv.Atoi(userIDStr)
+	// Browsers can't set headers on a WebSocket handshake, so they offer the
+	// access token as a subprotocol after accessTokenProtocol. Keeping it out
+	// of the URL keeps it out of request logs.
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if protocols := websocket.Subprotocols(r); len(protocols) == 2 && protocols[0] == accessTokenProtocol {
+		token = protocols[1]
+	}
+	if token == "" {
+		http.Error(w, "Access token required", http.StatusUnauthorized)
+		return
+	}
+	identity, err := h.authCache.Authenticate(token)
+	if err != nil {
+		authErr := err.(*middleware.AuthError)
+		http.Error(w, authErr.Message, authErr.Status)
+		return
+	}
+	userID := identity.UserID
+
+	// Messages are filtered by user, so only the campaign's own people may listen
+	var allowed bool
+	err = h.db.Get(&allowed, `
+		SELECT EXISTS(
+			SELECT 1 FROM campaigns WHERE id = $1 AND gm_user_id = $2
+			UNION
+			SELECT 1 FROM campaign_members WHERE campaign_id = $1 AND user_id = $2
+		)
+	`, campaignID, userID)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		log.Printf("Error checking campaign membership: %v", err)
+		http.Error(w, "Error checking campaign membership", http.StatusInternalServerError)
+		return
+	}
+	if !allowed && identity.Role != models.RoleAdmin {
+		http.Error(w, "Not a member of this campaign", http.StatusForbidden)
 		return
 	}
 
@@ -46,6 +82,11 @@ func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
 
 	// Create new client and register with hub
 	client := NewClient(h.hub, conn, campaignID, userID)
+	client.authorized = func() bool {
+		// A failed lookup isn't a logout, so only a rejected session closes the connection
+		err := h.authCache.Check(identity)
+		return err == nil || err.(*middleware.AuthError).Status != http.StatusUnauthorized
+	}
 	h.hub.register <- client
 
 	// Start the client's read/write pumps
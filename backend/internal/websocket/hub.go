@@ -14,6 +14,16 @@ const (
 	MessageTypeDicePoolUpdated MessageType = "dice_pool_updated"
 	MessageTypeChallengeUpdate MessageType = "challenge_update"
 	MessageTypeDayIncremented  MessageType = "day_incremented"
+	MessageTypeSessionUpdate   MessageType = "session_update"
+	MessageTypeImageUpdate     MessageType = "image_update"
+	MessageTypeImageShown      MessageType = "image_shown"
+	MessageTypeJournalUpdate   MessageType = "journal_update"
+	MessageTypeClockChanged    MessageType = "clock_changed"
+	MessageTypeCharacterUpdate MessageType = "character_update"
+	MessageTypeConditionUpdate MessageType = "condition_update"
+	MessageTypeInventoryUpdate MessageType = "inventory_update"
+	MessageTypeSceneUpdate     MessageType = "scene_update"
+	MessageTypeSceneActivated  MessageType = "scene_activated"
 )
 
 // Message is the structure sent over WebSocket
@@ -21,6 +31,9 @@ type Message struct {
 	Type       MessageType    `json:"type"`
 	CampaignID int            `json:"campaign_id"`
 	Payload    map[string]any `json:"payload"`
+
+	// If set, only clients for these user IDs receive the message
+	recipients map[int]bool
 }
 
 // Hub maintains the set of active clients and broadcasts messages to clients
@@ -94,6 +107,9 @@ func (h *Hub) Run() {
 			}
 
 			for client := range clients {
+				if message.recipients != nil && !message.recipients[client.UserID] {
+					continue
+				}
 				select {
 				case client.send <- messageBytes:
 				default:
@@ -117,6 +133,21 @@ func (h *Hub) BroadcastToCampaign(campaignID int, msgType MessageType, payload m
 	}
 }
 
+// BroadcastToUsers sends a message only to the given users' clients in a campaign
+func (h *Hub) BroadcastToUsers(campaignID int, userIDs []int, msgType MessageType, payload map[string]any) {
+	recipients := make(map[int]bool, len(userIDs))
+	for _, id := range userIDs {
+		recipients[id] = true
+	}
+
+	h.broadcast <- Message{
+		Type:       msgType,
+		CampaignID: campaignID,
+		Payload:    payload,
+		recipients: recipients,
+	}
+}
+
 // GetCampaignClientCount returns the number of connected clients for a campaign
 func (h *Hub) GetCampaignClientCount(campaignID int) int {
 	h.mu.RLock()
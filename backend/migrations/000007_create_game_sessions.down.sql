@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_challenges_session;
+DROP INDEX IF EXISTS idx_dice_pools_session;
+DROP INDEX IF EXISTS idx_roll_history_session;
+
+ALTER TABLE challenges DROP COLUMN IF EXISTS session_id;
+ALTER TABLE dice_pools DROP COLUMN IF EXISTS session_id;
+ALTER TABLE roll_history DROP COLUMN IF EXISTS session_id;
+
+DROP TABLE IF EXISTS session_attendance;
+DROP TABLE IF EXISTS game_sessions;
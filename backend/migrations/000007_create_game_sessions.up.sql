@@ -0,0 +1,39 @@
+-- Real-world play sessions, independent of the in-game day counter
+CREATE TABLE game_sessions (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    title VARCHAR(255),
+    notes TEXT,
+    started_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    start_day INTEGER NOT NULL,
+    end_day INTEGER,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP
+);
+
+CREATE INDEX idx_game_sessions_campaign ON game_sessions(campaign_id);
+
+-- Only one open session per campaign at a time
+CREATE UNIQUE INDEX idx_one_open_session_per_campaign
+ON game_sessions(campaign_id)
+WHERE ended_at IS NULL;
+
+CREATE TABLE session_attendance (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES game_sessions(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    character_id INTEGER REFERENCES characters(id) ON DELETE SET NULL,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(session_id, user_id)
+);
+
+CREATE INDEX idx_session_attendance_session ON session_attendance(session_id);
+
+-- Link everything created during a session back to it
+ALTER TABLE roll_history ADD COLUMN session_id INTEGER REFERENCES game_sessions(id) ON DELETE SET NULL;
+ALTER TABLE dice_pools ADD COLUMN session_id INTEGER REFERENCES game_sessions(id) ON DELETE SET NULL;
+ALTER TABLE challenges ADD COLUMN session_id INTEGER REFERENCES game_sessions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_roll_history_session ON roll_history(session_id);
+CREATE INDEX idx_dice_pools_session ON dice_pools(session_id);
+CREATE INDEX idx_challenges_session ON challenges(session_id);
@@ -0,0 +1,9 @@
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS uploaded_by_user_id;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS height;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS width;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS size_bytes;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS content_type;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS original_filename;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS thumbnail_url;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS thumbnail_key;
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS storage_key;
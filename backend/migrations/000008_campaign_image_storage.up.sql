@@ -0,0 +1,10 @@
+-- Track where uploaded images live in the blob store and what they contain
+ALTER TABLE campaign_images ADD COLUMN storage_key VARCHAR(500);
+ALTER TABLE campaign_images ADD COLUMN thumbnail_key VARCHAR(500);
+ALTER TABLE campaign_images ADD COLUMN thumbnail_url VARCHAR(500);
+ALTER TABLE campaign_images ADD COLUMN original_filename VARCHAR(255);
+ALTER TABLE campaign_images ADD COLUMN content_type VARCHAR(100);
+ALTER TABLE campaign_images ADD COLUMN size_bytes BIGINT;
+ALTER TABLE campaign_images ADD COLUMN width INTEGER;
+ALTER TABLE campaign_images ADD COLUMN height INTEGER;
+ALTER TABLE campaign_images ADD COLUMN uploaded_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
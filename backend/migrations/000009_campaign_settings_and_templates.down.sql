@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS campaign_templates;
+
+-- Unassigned characters can't satisfy NOT NULL, so remove them first
+DELETE FROM characters WHERE user_id IS NULL;
+ALTER TABLE characters ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE campaigns DROP COLUMN IF EXISTS settings;
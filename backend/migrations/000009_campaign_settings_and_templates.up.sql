@@ -0,0 +1,18 @@
+-- Per-campaign configuration, stored as JSON so new options don't need migrations
+ALTER TABLE campaigns ADD COLUMN settings JSONB NOT NULL DEFAULT '{}';
+
+-- Allow GM-held characters that aren't assigned to any player yet
+ALTER TABLE characters ALTER COLUMN user_id DROP NOT NULL;
+
+-- Reusable campaign starting points any GM on the instance can instantiate
+CREATE TABLE campaign_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    source_campaign_id INTEGER REFERENCES campaigns(id) ON DELETE SET NULL,
+    bundle JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_campaign_templates_created_by ON campaign_templates(created_by_user_id);
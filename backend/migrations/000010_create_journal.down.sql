@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS journal_entry_revisions;
+DROP TABLE IF EXISTS journal_entry_recipients;
+DROP TABLE IF EXISTS journal_entries;
@@ -0,0 +1,38 @@
+-- Campaign journal entries written in markdown
+CREATE TABLE journal_entries (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    author_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    campaign_day INTEGER NOT NULL,
+    -- gm: GM only, members: everyone in the campaign, players: listed recipients only
+    visibility VARCHAR(10) NOT NULL DEFAULT 'members' CHECK (visibility IN ('gm', 'members', 'players')),
+    revision INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_journal_entries_campaign ON journal_entries(campaign_id, campaign_day);
+
+CREATE TABLE journal_entry_recipients (
+    entry_id INTEGER NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (entry_id, user_id)
+);
+
+CREATE INDEX idx_journal_entry_recipients_user ON journal_entry_recipients(user_id);
+
+-- Every saved version of an entry, including the first
+CREATE TABLE journal_entry_revisions (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    campaign_day INTEGER NOT NULL,
+    visibility VARCHAR(10) NOT NULL,
+    edited_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(entry_id, revision)
+);
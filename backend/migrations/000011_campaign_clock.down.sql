@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS campaign_clock_log;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS time_phase;
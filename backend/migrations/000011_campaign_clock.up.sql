@@ -0,0 +1,18 @@
+-- Time-of-day phase, as an index into the campaign calendar's phases
+ALTER TABLE campaigns ADD COLUMN time_phase INTEGER NOT NULL DEFAULT 0;
+
+-- Every change to a campaign's in-game clock and who made it
+CREATE TABLE campaign_clock_log (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    changed_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reason VARCHAR(20) NOT NULL,
+    previous_day INTEGER NOT NULL,
+    previous_phase INTEGER NOT NULL,
+    new_day INTEGER NOT NULL,
+    new_phase INTEGER NOT NULL,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_campaign_clock_log_campaign ON campaign_clock_log(campaign_id, created_at);
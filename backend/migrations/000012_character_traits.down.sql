@@ -0,0 +1,3 @@
+ALTER TABLE roll_history DROP COLUMN IF EXISTS campaign_day;
+DROP TABLE IF EXISTS roll_history_traits;
+DROP TABLE IF EXISTS character_traits;
@@ -0,0 +1,47 @@
+-- Any number of skills and weaknesses per character
+CREATE TABLE character_traits (
+    id SERIAL PRIMARY KEY,
+    character_id INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('skill', 'weakness')),
+    modifier INTEGER NOT NULL DEFAULT 0,
+    description TEXT,
+    uses_per_day INTEGER CHECK (uses_per_day IS NULL OR uses_per_day > 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_character_traits_character ON character_traits(character_id);
+
+-- Traits applied to a roll. Name and modifier are copied so history
+-- survives the trait being edited or deleted.
+CREATE TABLE roll_history_traits (
+    roll_id INTEGER NOT NULL REFERENCES roll_history(id) ON DELETE CASCADE,
+    trait_id INTEGER REFERENCES character_traits(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    modifier INTEGER NOT NULL
+);
+
+CREATE INDEX idx_roll_history_traits_roll ON roll_history_traits(roll_id);
+CREATE INDEX idx_roll_history_traits_trait ON roll_history_traits(trait_id);
+
+-- In-game day of each roll, used to enforce per-day trait limits
+ALTER TABLE roll_history ADD COLUMN campaign_day INTEGER;
+
+-- Move the single skill and weakness columns into traits
+INSERT INTO character_traits (character_id, name, kind, modifier, created_at)
+SELECT id, skill_name, 'skill', COALESCE(skill_modifier, 0), created_at
+FROM characters
+WHERE skill_name IS NOT NULL AND skill_name <> '';
+
+INSERT INTO character_traits (character_id, name, kind, modifier, created_at)
+SELECT id, weakness_name, 'weakness', COALESCE(weakness_modifier, 0), created_at
+FROM characters
+WHERE weakness_name IS NOT NULL AND weakness_name <> '';
+
+-- Rolls that applied the old skill now point at the migrated skill trait
+INSERT INTO roll_history_traits (roll_id, trait_id, name, kind, modifier)
+SELECT rh.id, ct.id, ct.name, ct.kind, ct.modifier
+FROM roll_history rh
+JOIN character_traits ct ON ct.character_id = rh.character_id AND ct.kind = 'skill'
+WHERE rh.skill_applied = true;
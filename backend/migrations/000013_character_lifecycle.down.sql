@@ -0,0 +1,9 @@
+-- Fails if any player has more than one character in a campaign
+CREATE UNIQUE INDEX idx_one_active_char_per_user_campaign
+ON characters(campaign_id, user_id)
+WHERE user_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_characters_campaign_status;
+ALTER TABLE characters DROP COLUMN IF EXISTS retired_reason;
+ALTER TABLE characters DROP COLUMN IF EXISTS retired_at;
+ALTER TABLE characters DROP COLUMN IF EXISTS status;
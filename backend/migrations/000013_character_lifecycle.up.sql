@@ -0,0 +1,11 @@
+-- Retired characters keep their history but can no longer roll
+ALTER TABLE characters ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'retired'));
+ALTER TABLE characters ADD COLUMN retired_at TIMESTAMP;
+ALTER TABLE characters ADD COLUMN retired_reason TEXT;
+
+CREATE INDEX idx_characters_campaign_status ON characters(campaign_id, status);
+
+-- The per-user character limit is now a campaign setting enforced by the
+-- application, and retired characters don't count towards it
+DROP INDEX IF EXISTS idx_one_active_char_per_user_campaign;
@@ -0,0 +1,2 @@
+-- Free-form backstory and appearance, editable by the owning player
+ALTER TABLE characters ADD COLUMN description TEXT;
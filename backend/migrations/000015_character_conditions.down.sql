@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS dice_pool_conditions;
+DROP TABLE IF EXISTS roll_history_conditions;
+DROP TABLE IF EXISTS character_conditions;
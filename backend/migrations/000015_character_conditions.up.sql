@@ -0,0 +1,40 @@
+-- Temporary injuries, exhaustion, blessings and the like
+CREATE TABLE character_conditions (
+    id SERIAL PRIMARY KEY,
+    character_id INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    d6_modifier INTEGER NOT NULL DEFAULT 0,
+    dice_modifier INTEGER NOT NULL DEFAULT 0,
+    started_on_day INTEGER NOT NULL,
+    -- Last campaign day the condition applies; NULL lasts until removed or used up
+    expires_after_day INTEGER,
+    -- Rolls left before the condition wears off; NULL is not limited by rolls
+    rolls_remaining INTEGER CHECK (rolls_remaining IS NULL OR rolls_remaining >= 0),
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP,
+    ended_reason VARCHAR(20) CHECK (ended_reason IN ('expired', 'used_up', 'removed'))
+);
+
+CREATE INDEX idx_character_conditions_active ON character_conditions(character_id) WHERE ended_at IS NULL;
+
+-- Conditions applied to a roll, copied so history survives the condition ending
+CREATE TABLE roll_history_conditions (
+    roll_id INTEGER NOT NULL REFERENCES roll_history(id) ON DELETE CASCADE,
+    condition_id INTEGER REFERENCES character_conditions(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    d6_modifier INTEGER NOT NULL
+);
+
+CREATE INDEX idx_roll_history_conditions_roll ON roll_history_conditions(roll_id);
+
+-- Conditions that changed the size of a dice pool
+CREATE TABLE dice_pool_conditions (
+    pool_id INTEGER NOT NULL REFERENCES dice_pools(id) ON DELETE CASCADE,
+    condition_id INTEGER REFERENCES character_conditions(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    dice_modifier INTEGER NOT NULL
+);
+
+CREATE INDEX idx_dice_pool_conditions_pool ON dice_pool_conditions(pool_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS roll_history_items;
+DROP TABLE IF EXISTS character_items;
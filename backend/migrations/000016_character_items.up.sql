@@ -0,0 +1,26 @@
+-- Possessions carried by a character
+CREATE TABLE character_items (
+    id SERIAL PRIMARY KEY,
+    character_id INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    d6_modifier INTEGER NOT NULL DEFAULT 0,
+    consumable BOOLEAN NOT NULL DEFAULT FALSE,
+    granted_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_character_items_character ON character_items(character_id);
+
+-- Items declared on a roll, copied so history survives the item being used up
+CREATE TABLE roll_history_items (
+    roll_id INTEGER NOT NULL REFERENCES roll_history(id) ON DELETE CASCADE,
+    item_id INTEGER REFERENCES character_items(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    d6_modifier INTEGER NOT NULL,
+    consumed BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX idx_roll_history_items_roll ON roll_history_items(roll_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS character_advancements;
+DROP TABLE IF EXISTS experience_entries;
@@ -0,0 +1,33 @@
+-- Experience awarded by the GM, with the reason it was given
+CREATE TABLE experience_entries (
+    id SERIAL PRIMARY KEY,
+    character_id INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    is_milestone BOOLEAN NOT NULL DEFAULT FALSE,
+    campaign_day INTEGER NOT NULL,
+    awarded_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_experience_entries_character ON experience_entries(character_id, created_at);
+
+-- Upgrades unlocked by reaching advancement thresholds; the GM approves each one
+CREATE TABLE character_advancements (
+    id SERIAL PRIMARY KEY,
+    character_id INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('max_daily_dice', 'new_trait', 'trait_modifier')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    trait_id INTEGER REFERENCES character_traits(id) ON DELETE SET NULL,
+    trait_name VARCHAR(100),
+    trait_kind VARCHAR(20),
+    trait_modifier INTEGER,
+    note TEXT,
+    requested_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP
+);
+
+CREATE INDEX idx_character_advancements_character ON character_advancements(character_id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE roll_history DROP COLUMN IF EXISTS character_version;
+ALTER TABLE characters DROP COLUMN IF EXISTS current_version;
+DROP TABLE IF EXISTS character_versions;
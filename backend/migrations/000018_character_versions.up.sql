@@ -0,0 +1,47 @@
+-- Every change to a character is kept as a numbered snapshot
+CREATE TABLE character_versions (
+    id SERIAL PRIMARY KEY,
+    character_id INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    change VARCHAR(50) NOT NULL,
+    snapshot JSONB NOT NULL,
+    changed_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (character_id, version)
+);
+
+ALTER TABLE characters ADD COLUMN current_version INTEGER NOT NULL DEFAULT 0;
+
+-- Rolls made before versioning existed don't know their version
+ALTER TABLE roll_history ADD COLUMN character_version INTEGER;
+
+-- Existing characters start at version 1 as they are today
+INSERT INTO character_versions (character_id, version, change, snapshot, changed_by_user_id, created_at)
+SELECT
+    ch.id, 1, 'created',
+    jsonb_build_object(
+        'name', ch.name,
+        'description', ch.description,
+        'user_id', ch.user_id,
+        'status', ch.status,
+        'skill_name', ch.skill_name,
+        'skill_modifier', ch.skill_modifier,
+        'weakness_name', ch.weakness_name,
+        'weakness_modifier', ch.weakness_modifier,
+        'max_daily_dice', ch.max_daily_dice,
+        'traits', COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'id', ct.id,
+                'name', ct.name,
+                'kind', ct.kind,
+                'modifier', ct.modifier,
+                'uses_per_day', ct.uses_per_day
+            ) ORDER BY ct.kind, ct.id)
+            FROM character_traits ct
+            WHERE ct.character_id = ch.id
+        ), '[]'::jsonb)
+    ),
+    ch.user_id, ch.created_at
+FROM characters ch;
+
+UPDATE characters SET current_version = 1;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_characters_campaign_kind;
+ALTER TABLE characters DROP CONSTRAINT IF EXISTS npcs_have_no_owner;
+ALTER TABLE characters DROP COLUMN IF EXISTS stat_block;
+ALTER TABLE characters DROP COLUMN IF EXISTS is_revealed;
+ALTER TABLE characters DROP COLUMN IF EXISTS kind;
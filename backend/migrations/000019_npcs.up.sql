@@ -0,0 +1,10 @@
+ALTER TABLE characters ADD COLUMN kind VARCHAR(10) NOT NULL DEFAULT 'pc' CHECK (kind IN ('pc', 'npc'));
+ALTER TABLE characters ADD COLUMN is_revealed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE characters ADD COLUMN stat_block TEXT;
+
+-- Unassigned characters were the old way of making NPCs; keep them visible
+UPDATE characters SET kind = 'npc', is_revealed = TRUE WHERE user_id IS NULL;
+
+ALTER TABLE characters ADD CONSTRAINT npcs_have_no_owner CHECK (kind = 'pc' OR user_id IS NULL);
+
+CREATE INDEX idx_characters_campaign_kind ON characters(campaign_id, kind);
@@ -0,0 +1,2 @@
+-- Free-form notes kept on the character sheet
+ALTER TABLE characters ADD COLUMN notes TEXT;
@@ -0,0 +1,3 @@
+-- Optional progress clock, e.g. "4 successes before 3 failures". Progress is
+-- recomputed from the challenge's linked rolls whenever one is recorded.
+ALTER TABLE challenges ADD COLUMN progress_clock JSONB;
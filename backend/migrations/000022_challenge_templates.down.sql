@@ -0,0 +1,5 @@
+ALTER TABLE challenges DROP COLUMN IF EXISTS template_id;
+DROP TABLE IF EXISTS challenge_templates;
+ALTER TABLE challenges DROP COLUMN IF EXISTS failure_text;
+ALTER TABLE challenges DROP COLUMN IF EXISTS neutral_text;
+ALTER TABLE challenges DROP COLUMN IF EXISTS success_text;
@@ -0,0 +1,25 @@
+-- Text read out when a roll against a challenge lands
+ALTER TABLE challenges ADD COLUMN success_text TEXT;
+ALTER TABLE challenges ADD COLUMN neutral_text TEXT;
+ALTER TABLE challenges ADD COLUMN failure_text TEXT;
+
+-- Reusable challenges. Templates without an owner are shared across the instance.
+CREATE TABLE challenge_templates (
+    id SERIAL PRIMARY KEY,
+    owner_user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    difficulty_modifier INTEGER NOT NULL DEFAULT 0,
+    is_group_challenge BOOLEAN NOT NULL DEFAULT FALSE,
+    success_text TEXT,
+    neutral_text TEXT,
+    failure_text TEXT,
+    progress_clock JSONB,
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_challenge_templates_owner ON challenge_templates(owner_user_id);
+
+ALTER TABLE challenges ADD COLUMN template_id INTEGER REFERENCES challenge_templates(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE challenges DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE challenges DROP COLUMN IF EXISTS max_attempts_per_character;
+DROP TABLE IF EXISTS challenge_targets;
@@ -0,0 +1,13 @@
+-- Challenges aimed at specific characters. A challenge with no targets is open to everyone.
+CREATE TABLE challenge_targets (
+    challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    character_id INTEGER NOT NULL REFERENCES characters(id) ON DELETE CASCADE,
+    PRIMARY KEY (challenge_id, character_id)
+);
+
+CREATE INDEX idx_challenge_targets_character ON challenge_targets(character_id);
+
+ALTER TABLE challenges ADD COLUMN max_attempts_per_character INTEGER CHECK (max_attempts_per_character > 0);
+
+-- Hidden challenges are only shown to the GM and the players of targeted characters
+ALTER TABLE challenges ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+ALTER TABLE roll_history DROP COLUMN IF EXISTS consequence;
+ALTER TABLE challenge_templates DROP COLUMN IF EXISTS consequence_tables;
+ALTER TABLE challenges DROP COLUMN IF EXISTS consequence_tables;
@@ -0,0 +1,6 @@
+-- Short random tables per outcome, e.g. {"failure": ["The alarm sounds", "A guard turns"]}
+ALTER TABLE challenges ADD COLUMN consequence_tables JSONB;
+ALTER TABLE challenge_templates ADD COLUMN consequence_tables JSONB;
+
+-- The consequence a roll against a challenge resolved to, kept even if the challenge changes
+ALTER TABLE roll_history ADD COLUMN consequence JSONB;
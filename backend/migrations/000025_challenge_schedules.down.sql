@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_challenges_status;
+
+ALTER TABLE challenges DROP COLUMN IF EXISTS is_active;
+ALTER TABLE challenges ADD COLUMN is_active BOOLEAN DEFAULT TRUE;
+UPDATE challenges SET is_active = status IN ('scheduled', 'active');
+CREATE INDEX idx_challenges_active ON challenges(campaign_id, is_active);
+
+ALTER TABLE challenges DROP COLUMN IF EXISTS status;
+ALTER TABLE challenges DROP CONSTRAINT IF EXISTS challenges_day_window;
+ALTER TABLE challenges DROP COLUMN IF EXISTS ends_after_day;
+ALTER TABLE challenges DROP COLUMN IF EXISTS starts_on_day;
@@ -0,0 +1,15 @@
+-- Challenges can be limited to a window of campaign days. A challenge is
+-- scheduled before starts_on_day and expires once the day passes ends_after_day.
+ALTER TABLE challenges ADD COLUMN starts_on_day INTEGER CHECK (starts_on_day >= 1);
+ALTER TABLE challenges ADD COLUMN ends_after_day INTEGER CHECK (ends_after_day >= 1);
+ALTER TABLE challenges ADD CONSTRAINT challenges_day_window CHECK (ends_after_day >= starts_on_day);
+
+ALTER TABLE challenges ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('scheduled', 'active', 'completed', 'expired'));
+UPDATE challenges SET status = 'completed' WHERE is_active IS FALSE;
+
+-- is_active is kept for existing readers but now follows status
+ALTER TABLE challenges DROP COLUMN is_active;
+ALTER TABLE challenges ADD COLUMN is_active BOOLEAN GENERATED ALWAYS AS (status = 'active') STORED;
+
+CREATE INDEX idx_challenges_status ON challenges(campaign_id, status);
@@ -0,0 +1,4 @@
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS scene_id;
+ALTER TABLE roll_history DROP COLUMN IF EXISTS scene_id;
+ALTER TABLE challenges DROP COLUMN IF EXISTS scene_id;
+DROP TABLE IF EXISTS scenes;
@@ -0,0 +1,27 @@
+-- Scenes group a campaign's challenges, images and rolls into encounters
+CREATE TABLE scenes (
+    id SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    position INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by_user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_scenes_campaign ON scenes(campaign_id, position);
+
+-- Every client focuses on the one active scene
+CREATE UNIQUE INDEX idx_one_active_scene_per_campaign
+ON scenes(campaign_id)
+WHERE is_active;
+
+ALTER TABLE challenges ADD COLUMN scene_id INTEGER REFERENCES scenes(id) ON DELETE SET NULL;
+ALTER TABLE roll_history ADD COLUMN scene_id INTEGER REFERENCES scenes(id) ON DELETE SET NULL;
+ALTER TABLE campaign_images ADD COLUMN scene_id INTEGER REFERENCES scenes(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_challenges_scene ON challenges(scene_id);
+CREATE INDEX idx_roll_history_scene ON roll_history(scene_id);
+CREATE INDEX idx_campaign_images_scene ON campaign_images(scene_id);
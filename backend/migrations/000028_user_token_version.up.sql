@@ -0,0 +1,2 @@
+-- Bumped whenever a user's role changes; access tokens carrying an older version are refused
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 1;
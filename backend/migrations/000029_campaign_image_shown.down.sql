@@ -0,0 +1 @@
+ALTER TABLE campaign_images DROP COLUMN IF EXISTS shown_at;
@@ -0,0 +1,4 @@
+-- Set the first time the GM shows an image; until then only the GM can see it.
+-- Images uploaded before this were already visible to every member.
+ALTER TABLE campaign_images ADD COLUMN shown_at TIMESTAMP;
+UPDATE campaign_images SET shown_at = uploaded_at;
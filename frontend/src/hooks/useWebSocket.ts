@@ -1,6 +1,6 @@
 import { useEffect, useRef, useCallback, useState } from 'react';
 import { useAuthStore } from '../store/authStore';
-import { WS_HOST } from '../services/api';
+import { WS_HOST, getAccessToken } from '../services/api';
 
 export type MessageType = 
   | 'roll_complete'
@@ -33,12 +33,17 @@ export function useWebSocket({
 }: UseWebSocketOptions) {
   const wsRef = useRef<WebSocket | null>(null);
   const reconnectTimeoutRef = useRef<ReturnType<typeof setTimeout> | null>(null);
+  const mountedRef = useRef(false);
   const [isConnected, setIsConnected] = useState(false);
   const user = useAuthStore((state) => state.user);
 
-  const connect = useCallback(() => {
+  const connect = useCallback(async () => {
     if (!user?.id || !campaignId) return;
 
+    // The token is only needed for the handshake, so fetch a fresh one at connect time
+    const token = await getAccessToken();
+    if (!token || !mountedRef.current) return;
+
     // Clean up existing connection
     if (wsRef.current) {
       wsRef.current.close();
@@ -47,10 +52,11 @@ export function useWebSocket({
     // Build WebSocket URL
     const wsProtocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
     const wsHost = WS_HOST;
-    const wsUrl = `${wsProtocol}//${wsHost}/ws/campaigns/${campaignId}?user_id=${user.id}`;
+    const wsUrl = `${wsProtocol}//${wsHost}/ws/campaigns/${campaignId}`;
 
     console.log('Connecting to WebSocket:', wsUrl);
-    const ws = new WebSocket(wsUrl);
+    // Browsers can't send an Authorization header on the handshake, so the token is offered as a subprotocol
+    const ws = new WebSocket(wsUrl, ['access_token', token]);
 
     ws.onopen = () => {
       console.log('WebSocket connected');
@@ -110,9 +116,11 @@ export function useWebSocket({
 
   // Connect on mount, disconnect on unmount
   useEffect(() => {
+    mountedRef.current = true;
     connect();
 
     return () => {
+      mountedRef.current = false;
       if (reconnectTimeoutRef.current) {
         clearTimeout(reconnectTimeoutRef.current);
       }
@@ -95,6 +95,9 @@ export default function CampaignDetail() {
     } else if (action === 'completed') {
       // Remove completed challenge from active list
       setChallenges(prev => prev.filter(c => c.id !== challenge.id));
+    } else if (action === 'removed') {
+      // The challenge was hidden from us; only its ID is sent
+      setChallenges(prev => prev.filter(c => c.id !== payload.id));
     }
   }, []);
 
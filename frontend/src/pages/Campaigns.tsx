@@ -1,6 +1,7 @@
 import { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useAuthStore } from '../store/authStore';
+import { authService } from '../services/authService';
 import { campaignService } from '../services/campaignService';
 import type { Campaign } from '../types';
 
@@ -12,7 +13,7 @@ export default function Campaigns() {
   const [isCreating, setIsCreating] = useState(false);
   
   const navigate = useNavigate();
-  const { user, logout, isAdmin, canCreateCampaigns } = useAuthStore();
+  const { user, isAdmin, canCreateCampaigns } = useAuthStore();
 
   useEffect(() => {
     loadCampaigns();
@@ -46,8 +47,8 @@ export default function Campaigns() {
     }
   };
 
-  const handleLogout = () => {
-    logout();
+  const handleLogout = async () => {
+    await authService.logout();
     navigate('/login');
   };
 
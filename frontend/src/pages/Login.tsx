@@ -19,7 +19,7 @@ export default function Login() {
 
     try {
       const data = await authService.login(email, password);
-      setAuth(data.user, data.token);
+      setAuth(data);
       navigate('/campaigns');
     } catch (err: any) {
       setError(err.response?.data || 'Login failed');
@@ -1,7 +1,12 @@
 import axios from 'axios';
+import type { AxiosError, InternalAxiosRequestConfig } from 'axios';
+import { useAuthStore } from '../store/authStore';
+import type { AuthResponse } from '../types';
 
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8090/api';
-const AUTH_TOKEN_KEY = import.meta.env.VITE_AUTH_TOKEN_KEY || 'sleeper_auth_token';
+
+// Refresh this long before the access token expires so in-flight requests don't race it
+const REFRESH_MARGIN_MS = 30 * 1000;
 
 const getWsHost = () => {
   if (import.meta.env.VITE_WS_HOST) {
@@ -20,13 +25,95 @@ const api = axios.create({
   },
 });
 
+// Name of the Web Lock that serializes refreshes across tabs
+const REFRESH_LOCK = 'sleeper_auth_refresh';
+
+const expiresSoon = (tokenExpiresAt: string | null) =>
+  !!tokenExpiresAt && new Date(tokenExpiresAt).getTime() - Date.now() < REFRESH_MARGIN_MS;
+
+// Run fn while holding the refresh lock, where the browser supports Web Locks
+const withRefreshLock = (fn: () => Promise<string | null>): Promise<string | null> =>
+  navigator.locks ? navigator.locks.request(REFRESH_LOCK, fn) : fn();
+
+// Each refresh token works once, so concurrent callers share a single refresh
+let refreshing: Promise<string | null> | null = null;
+
+// refreshAccessToken trades the refresh token for a new token pair, logging
+// out if the session has been revoked or has expired
+export const refreshAccessToken = (): Promise<string | null> => {
+  if (!refreshing) {
+    const staleRefreshToken = useAuthStore.getState().refreshToken;
+    refreshing = withRefreshLock(async () => {
+      // Another tab may have rotated the token while we waited. Replaying the old
+      // one would look like theft to the server and revoke the whole session.
+      useAuthStore.getState().syncFromStorage();
+      const { token, refreshToken, tokenExpiresAt, setAuth, logout } = useAuthStore.getState();
+      if (!refreshToken) {
+        logout();
+        return null;
+      }
+      if (refreshToken !== staleRefreshToken && token && !expiresSoon(tokenExpiresAt)) {
+        return token;
+      }
+      try {
+        // Plain axios so a failed refresh doesn't loop through the interceptors below
+        const response = await axios.post<AuthResponse>(`${API_BASE_URL}/auth/refresh`, {
+          refresh_token: refreshToken,
+        });
+        setAuth(response.data);
+        return response.data.token;
+      } catch (error) {
+        if (axios.isAxiosError(error) && error.response?.status === 401) {
+          logout();
+        }
+        return null;
+      }
+    }).finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+};
+
+// getAccessToken returns a token that is good for at least REFRESH_MARGIN_MS,
+// refreshing it first if needed
+export const getAccessToken = async (): Promise<string | null> => {
+  const { token, tokenExpiresAt } = useAuthStore.getState();
+  if (!token) {
+    return null;
+  }
+  if (expiresSoon(tokenExpiresAt)) {
+    return refreshAccessToken();
+  }
+  return token;
+};
+
 // Add auth token to requests
-api.interceptors.request.use((config) => {
-  const token = localStorage.getItem(AUTH_TOKEN_KEY);
+api.interceptors.request.use(async (config) => {
+  if (config.url?.startsWith('/auth/login') || config.url?.startsWith('/auth/register')) {
+    return config;
+  }
+  const token = await getAccessToken();
   if (token) {
     config.headers.Authorization = `Bearer ${token}`;
   }
   return config;
 });
 
-export default api;
\ No newline at end of file
+// Retry once with a fresh token when the server rejects the current one
+api.interceptors.response.use(undefined, async (error: AxiosError) => {
+  const config = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined;
+  if (error.response?.status !== 401 || !config || config._retried || !useAuthStore.getState().refreshToken) {
+    return Promise.reject(error);
+  }
+
+  config._retried = true;
+  const token = await refreshAccessToken();
+  if (!token) {
+    return Promise.reject(error);
+  }
+  config.headers.Authorization = `Bearer ${token}`;
+  return api(config);
+});
+
+export default api;
@@ -1,4 +1,5 @@
 import api from './api';
+import { useAuthStore } from '../store/authStore';
 import type { AuthResponse } from '../types';
 
 export const authService = {
@@ -18,4 +19,15 @@ export const authService = {
     });
     return response.data;
   },
+
+  // Revokes this device's session on the server, then forgets it locally either way
+  logout: async (): Promise<void> => {
+    try {
+      await api.post('/auth/logout');
+    } catch (error) {
+      console.error('Failed to revoke session:', error);
+    } finally {
+      useAuthStore.getState().logout();
+    }
+  },
 };
\ No newline at end of file
@@ -1,32 +1,57 @@
 import { create } from 'zustand';
-import type { User } from '../types';
+import type { AuthResponse, User } from '../types';
 import { ROLE_ADMIN, ROLE_GAME_MASTER } from '../types';
 
 const AUTH_TOKEN_KEY = import.meta.env.VITE_AUTH_TOKEN_KEY || 'sleeper_auth_token';
+const AUTH_REFRESH_TOKEN_KEY = 'sleeper_auth_refresh_token';
+const AUTH_TOKEN_EXPIRES_KEY = 'sleeper_auth_token_expires_at';
 const AUTH_USER_KEY = 'sleeper_auth_user';
 
-// Initialize from localStorage immediately
-const getInitialAuth = () => {
+const clearStoredAuth = () => {
+  localStorage.removeItem(AUTH_TOKEN_KEY);
+  localStorage.removeItem(AUTH_REFRESH_TOKEN_KEY);
+  localStorage.removeItem(AUTH_TOKEN_EXPIRES_KEY);
+  localStorage.removeItem(AUTH_USER_KEY);
+};
+
+const signedOut = { user: null, token: null, refreshToken: null, tokenExpiresAt: null };
+
+// Read the stored login, or null if there isn't a usable one
+const readStoredAuth = () => {
   const token = localStorage.getItem(AUTH_TOKEN_KEY);
+  const refreshToken = localStorage.getItem(AUTH_REFRESH_TOKEN_KEY);
+  const tokenExpiresAt = localStorage.getItem(AUTH_TOKEN_EXPIRES_KEY);
   const userStr = localStorage.getItem(AUTH_USER_KEY);
-  
-  if (token && userStr) {
-    try {
-      const user = JSON.parse(userStr);
-      return { user, token };
-    } catch (e) {
-      localStorage.removeItem(AUTH_TOKEN_KEY);
-      localStorage.removeItem(AUTH_USER_KEY);
-    }
+
+  // Logins from before refresh tokens can't be renewed
+  if (!token || !refreshToken || !userStr) {
+    return null;
+  }
+  try {
+    const user: User = JSON.parse(userStr);
+    return { user, token, refreshToken, tokenExpiresAt };
+  } catch (e) {
+    return null;
   }
-  
-  return { user: null, token: null };
+};
+
+// Initialize from localStorage immediately, starting over if the stored login is unusable
+const getInitialAuth = () => {
+  const stored = readStoredAuth();
+  if (!stored) {
+    clearStoredAuth();
+    return signedOut;
+  }
+  return stored;
 };
 
 interface AuthState {
   user: User | null;
   token: string | null;
-  setAuth: (user: User, token: string) => void;
+  refreshToken: string | null;
+  tokenExpiresAt: string | null;
+  setAuth: (auth: AuthResponse) => void;
+  syncFromStorage: () => void;
   logout: () => void;
   isAuthenticated: () => boolean;
   isAdmin: () => boolean;
@@ -35,19 +60,29 @@ interface AuthState {
 
 export const useAuthStore = create<AuthState>((set, get) => ({
   ...getInitialAuth(), // Initialize state immediately
-  
-  setAuth: (user, token) => {
+
+  setAuth: ({ user, token, refresh_token, token_expires_at }) => {
     localStorage.setItem(AUTH_TOKEN_KEY, token);
+    localStorage.setItem(AUTH_REFRESH_TOKEN_KEY, refresh_token);
+    localStorage.setItem(AUTH_TOKEN_EXPIRES_KEY, token_expires_at);
     localStorage.setItem(AUTH_USER_KEY, JSON.stringify(user));
-    set({ user, token });
+    set({ user, token, refreshToken: refresh_token, tokenExpiresAt: token_expires_at });
+  },
+
+  // Pick up a refresh, login or logout that another tab wrote to localStorage
+  syncFromStorage: () => {
+    const stored = readStoredAuth() ?? signedOut;
+    const { token, refreshToken } = get();
+    if (stored.token !== token || stored.refreshToken !== refreshToken) {
+      set(stored);
+    }
   },
-  
+
   logout: () => {
-    localStorage.removeItem(AUTH_TOKEN_KEY);
-    localStorage.removeItem(AUTH_USER_KEY);
-    set({ user: null, token: null });
+    clearStoredAuth();
+    set(signedOut);
   },
-  
+
   isAuthenticated: () => {
     return !!get().token;
   },
@@ -60,4 +95,13 @@ export const useAuthStore = create<AuthState>((set, get) => ({
     const role = get().user?.system_role;
     return role === ROLE_ADMIN || role === ROLE_GAME_MASTER;
   }
-}));
\ No newline at end of file
+}));
+
+// Every tab shares one refresh token, so follow the other tabs' refreshes and logouts
+// instead of replaying a token they've already rotated
+const AUTH_KEYS = [AUTH_TOKEN_KEY, AUTH_REFRESH_TOKEN_KEY, AUTH_TOKEN_EXPIRES_KEY, AUTH_USER_KEY];
+window.addEventListener('storage', (event) => {
+  if (event.key === null || AUTH_KEYS.includes(event.key)) {
+    useAuthStore.getState().syncFromStorage();
+  }
+});
@@ -14,6 +14,8 @@ export interface User {
 
 export interface AuthResponse {
   token: string;
+  token_expires_at: string;
+  refresh_token: string;
   user: User;
 }
 